@@ -4,6 +4,10 @@ Copyright © 2026 Raypaste
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -31,3 +35,74 @@ func TestGetInputFromArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestParseVarFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{"empty", nil, map[string]string{}, false},
+		{"single", []string{"tone=formal"}, map[string]string{"tone": "formal"}, false},
+		{"value with equals", []string{"query=a=b"}, map[string]string{"query": "a=b"}, false},
+		{"empty value", []string{"tone="}, map[string]string{"tone": ""}, false},
+		{"later flag wins", []string{"tone=a", "tone=b"}, map[string]string{"tone": "b"}, false},
+		{"missing equals", []string{"tone"}, nil, true},
+		{"missing key", []string{"=formal"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVarFlags(tt.flags)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVarFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseVarFlags() = %v, want %v", got, tt.want)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("parseVarFlags()[%q] = %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestEditAnnotations(t *testing.T) {
+	original := "# my prompt\nname: demo\nsystem: \"{{.Input}}\"\n"
+	annotated := annotateEdit([]byte(original), []string{"unknown field '.Input'", "multi\nline"})
+
+	if got := string(annotated); !strings.HasPrefix(got, editAnnotationPrefix) || !strings.HasSuffix(got, original) {
+		t.Errorf("annotateEdit() = %q, want annotations followed by the original", got)
+	}
+	if got := string(stripEditAnnotations(annotated)); got != original {
+		t.Errorf("stripEditAnnotations() = %q, want %q", got, original)
+	}
+
+	// Only the block at the top is removed
+	own := "name: demo\nsystem: |\n" + editAnnotationPrefix + "kept\n"
+	if got := string(stripEditAnnotations(annotateEdit([]byte(own), []string{"problem"}))); got != own {
+		t.Errorf("stripEditAnnotations() = %q, want the user's lines kept: %q", got, own)
+	}
+}
+
+func TestRunEditor(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the editor runs without a shell on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "my prompt.yaml")
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", `sh -c 'echo edited > "$1"' editor`)
+
+	if err := runEditor(path); err != nil {
+		t.Fatalf("runEditor() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "edited\n" {
+		t.Errorf("edited file = %q, %v, want the editor's output at a path with a space", data, err)
+	}
+}
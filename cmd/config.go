@@ -26,7 +26,7 @@ Configuration is stored in ~/.raypaste/config.yaml.
 ` + output.Bold("Available config keys:") + `
   ` + output.Green("api-key") + `        - OpenRouter API key
   ` + output.Green("default-model") + `  - Default model alias or OpenRouter ID
-  ` + output.Green("default-length") + ` - Default output length (short|medium|long)
+  ` + output.Green("default-length") + ` - Default output length (short|medium|long or a custom length)
   ` + output.Green("disable-copy") + `   - Disable auto-copy to clipboard (true|false)
   ` + output.Green("temperature") + `    - Sampling temperature (0.0-2.0)
 
@@ -80,7 +80,13 @@ var configSetCmd = &cobra.Command{
 			fmt.Fprintf(os.Stderr, "%s Default model set to %s\n", output.Green("✓"), output.Cyan(value))
 
 		case "default-length", "length":
-			length, err := config.ValidateOutputLength(value)
+			// Prompt files can define lengths too
+			workingDir, _ := os.Getwd()
+			store, err := loadPromptStore(workingDir)
+			if err != nil {
+				return err
+			}
+			length, err := store.Lengths().Validate(value)
 			if err != nil {
 				return err
 			}
@@ -5,13 +5,14 @@ package cmd
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
 	"github.com/raypaste/raypaste-cli/internal/prompts"
-	"github.com/raypaste/raypaste-cli/pkg/types"
 	"github.com/spf13/cobra"
 )
 
@@ -21,20 +22,33 @@ var configPromptCmd = &cobra.Command{
 	Short: "Manage custom prompt templates",
 	Long: output.Bold("Manage custom prompt templates") + output.Cyan(" for raypaste.") + `
 
-This command allows you to create, view, list, and remove custom prompt templates.
-Custom prompts are stored in ~/.raypaste/prompts/.
+This command allows you to create, view, list, remove, and lint custom prompt templates.
+Custom prompts are stored in ~/.raypaste/prompts/. Team prompts can be checked into a
+repository under .raypaste/prompts/ and take precedence over your own.
 
 ` + output.Bold("Available subcommands:") + `
-  ` + output.Green("add") + `    - Add a new custom prompt interactively or via flags
-  ` + output.Green("list") + `   - List all available prompts (built-in and custom)
-  ` + output.Green("show") + `   - Show details of a specific prompt
-  ` + output.Green("remove") + ` - Remove a custom prompt
+  ` + output.Green("add") + `      - Add a new custom prompt interactively or via flags
+  ` + output.Green("list") + `     - List all available prompts and where each comes from
+  ` + output.Green("show") + `     - Show details of a specific prompt
+  ` + output.Green("edit") + `     - Edit a prompt in $EDITOR with validation on save
+  ` + output.Green("remove") + `   - Remove a custom prompt
+  ` + output.Green("reset") + `    - Restore a built-in prompt you have overridden
+  ` + output.Green("lint") + `     - Check prompts for problems
+  ` + output.Green("history") + `  - List saved versions of a prompt
+  ` + output.Green("diff") + `     - Compare versions of a prompt
+  ` + output.Green("rollback") + ` - Restore an earlier version of a prompt
+  ` + output.Green("export") + `   - Bundle prompts into a shareable pack
+  ` + output.Green("import") + `   - Install prompts from a pack
 
 ` + output.Bold("Examples:") + `
   raypaste config prompt add code-review
   raypaste config prompt list
   raypaste config prompt show metaprompt
-  raypaste config prompt remove my-custom-prompt`,
+  raypaste config prompt edit code-review
+  raypaste config prompt remove my-custom-prompt
+  raypaste config prompt lint --all
+  raypaste config prompt export code-review -o team.tar.gz
+  raypaste config prompt import team.tar.gz`,
 }
 
 // configPromptAddCmd represents the config prompt add command
@@ -47,14 +61,15 @@ var configPromptAddCmd = &cobra.Command{
 		name := args[0]
 
 		// Validate prompt name (no spaces, special chars)
-		if !isValidPromptName(name) {
-			return fmt.Errorf("invalid prompt name: %s (must contain only letters, numbers, hyphens, and underscores)", name)
+		if err := prompts.ValidateName(name); err != nil {
+			return err
 		}
 
 		// Check if prompt already exists
-		store, err := prompts.NewStore()
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
 		if err != nil {
-			return fmt.Errorf("failed to load prompts: %w", err)
+			return err
 		}
 
 		existing, _ := store.Get(name)
@@ -206,11 +221,12 @@ var configPromptAddCmd = &cobra.Command{
 var configPromptListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all available prompts",
-	Long:  `List all available prompt templates, including built-in and custom prompts.`,
+	Long:  `List all available prompt templates and their origin: built-in, user (~/.raypaste/prompts/), or project (.raypaste/prompts/ in the current directory or a parent).`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		store, err := prompts.NewStore()
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
 		if err != nil {
-			return fmt.Errorf("failed to load prompts: %w", err)
+			return err
 		}
 
 		allPrompts := store.List()
@@ -224,15 +240,14 @@ var configPromptListCmd = &cobra.Command{
 				continue
 			}
 
-			isBuiltIn := store.IsBuiltIn(name)
-			status := output.Green("[built-in]")
-			if !isBuiltIn {
-				status = output.Cyan("[custom]")
+			status := formatPromptOrigin(store.Origin(name))
+			if store.Overrides(name) {
+				status += " " + output.Yellow("[overrides built-in]")
 			}
 
 			// Show supported lengths
 			var lengths []string
-			for _, length := range []types.OutputLength{types.OutputLengthShort, types.OutputLengthMedium, types.OutputLengthLong} {
+			for _, length := range store.Lengths().List() {
 				if _, ok := prompt.LengthDirectives[string(length)]; ok {
 					lengths = append(lengths, string(length))
 				}
@@ -258,9 +273,10 @@ var configPromptShowCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		store, err := prompts.NewStore()
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
 		if err != nil {
-			return fmt.Errorf("failed to load prompts: %w", err)
+			return err
 		}
 
 		prompt, err := store.Get(name)
@@ -268,19 +284,23 @@ var configPromptShowCmd = &cobra.Command{
 			return err
 		}
 
-		isBuiltIn := store.IsBuiltIn(name)
-		status := output.Green("built-in")
-		if !isBuiltIn {
-			status = output.Cyan("custom")
+		status := formatPromptOrigin(store.Origin(name))
+		if store.Overrides(name) {
+			status += " " + output.Yellow("[overrides built-in]")
+		}
+		fmt.Fprintf(os.Stderr, "%s: %s %s\n", output.Bold("Name"), output.Cyan(prompt.Name), status)
+		if source := store.Source(name); source != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", output.Bold("Source"), source)
 		}
-
-		fmt.Fprintf(os.Stderr, "%s: %s (%s)\n", output.Bold("Name"), output.Cyan(prompt.Name), status)
 		fmt.Fprintf(os.Stderr, "%s: %s\n", output.Bold("Description"), prompt.Description)
+		if prompt.Extends != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", output.Bold("Extends"), output.Cyan(prompt.Extends))
+		}
 
 		// Show supported lengths
 		fmt.Fprintf(os.Stderr, "%s: ", output.Bold("Supported Lengths"))
 		var lengths []string
-		for _, length := range []types.OutputLength{types.OutputLengthShort, types.OutputLengthMedium, types.OutputLengthLong} {
+		for _, length := range store.Lengths().List() {
 			if directive, ok := prompt.LengthDirectives[string(length)]; ok {
 				if directive != "" {
 					lengths = append(lengths, string(length)+" (custom)")
@@ -290,6 +310,42 @@ var configPromptShowCmd = &cobra.Command{
 			}
 		}
 		fmt.Fprintln(os.Stderr, strings.Join(lengths, ", "))
+		if rules := describePostProcess(prompt.PostProcess); len(rules) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", output.Bold("Post-processing"), strings.Join(rules, ", "))
+		}
+		if len(prompt.Tools) > 0 {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", output.Bold("Tools"), strings.Join(prompt.Tools, ", "))
+		}
+		if prompt.Schema != nil {
+			data, err := json.MarshalIndent(prompt.Schema, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to encode schema: %w", err)
+			}
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, output.Bold("Output Schema:"))
+			fmt.Fprintln(os.Stderr, string(data))
+		}
+
+		// A pipeline has steps instead of a system prompt
+		if len(prompt.Steps) > 0 {
+			fmt.Fprintln(os.Stderr, "")
+			fmt.Fprintln(os.Stderr, output.Bold("Steps:"))
+			for i, step := range prompt.Steps {
+				var details []string
+				if step.Model != "" {
+					details = append(details, "model: "+step.Model)
+				}
+				if step.Length != "" {
+					details = append(details, "length: "+step.Length)
+				}
+				line := fmt.Sprintf("  %d. %s", i+1, output.Cyan(step.Prompt))
+				if len(details) > 0 {
+					line += " " + output.Yellow("("+strings.Join(details, ", ")+")")
+				}
+				fmt.Println(line)
+			}
+			return nil
+		}
 
 		fmt.Fprintln(os.Stderr, "")
 		fmt.Fprintln(os.Stderr, output.Bold("System Prompt:"))
@@ -301,21 +357,89 @@ var configPromptShowCmd = &cobra.Command{
 	},
 }
 
+// describePostProcess lists the rules that are turned on, by their YAML keys.
+func describePostProcess(rules *postprocess.Rules) []string {
+	if rules == nil {
+		return nil
+	}
+	var names []string
+	for _, rule := range []struct {
+		name string
+		on   bool
+	}{
+		{"strip_preamble", rules.StripPreamble},
+		{"trim_trailing_commentary", rules.TrimTrailingCommentary},
+		{"unwrap_code_block", rules.UnwrapCodeBlock},
+		{"collapse_whitespace", rules.CollapseWhitespace},
+	} {
+		if rule.on {
+			names = append(names, rule.name)
+		}
+	}
+	if len(rules.Replace) > 0 {
+		names = append(names, fmt.Sprintf("replace (%d)", len(rules.Replace)))
+	}
+	return names
+}
+
+// configPromptResetCmd represents the config prompt reset command
+var configPromptResetCmd = &cobra.Command{
+	Use:   "reset <name>",
+	Short: "Restore an overridden built-in prompt",
+	Long: `Remove your override of a built-in prompt, so the built-in is used again.
+
+A prompt in ~/.raypaste/prompts/ with the same name as a built-in (such as metaprompt)
+overrides it. Reset removes that file; it is kept in the prompt's history, so it can
+be restored with 'config prompt rollback'.`,
+	Example: `  raypaste config prompt reset metaprompt`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+
+		if store.Overrides(name) && store.Origin(name) == prompts.OriginProject {
+			return fmt.Errorf("'%s' is overridden by the project in %s; remove it from the repository instead", name, store.Source(name))
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if store.Overrides(name) && !force && !confirm(fmt.Sprintf("Remove your override %s and restore the built-in %s", store.Source(name), name)) {
+			fmt.Fprintln(os.Stderr, output.Red("Cancelled - prompt not reset"))
+			return nil
+		}
+
+		if err := store.ResetPrompt(name); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s %s %s\n", output.Green("✓"), output.Green("Prompt"), output.BoldBlue(name), output.Green("restored to the built-in"))
+		return nil
+	},
+}
+
 // configPromptRemoveCmd represents the config prompt remove command
 var configPromptRemoveCmd = &cobra.Command{
 	Use:   "remove [name]",
 	Short: "Remove a custom prompt",
-	Long:  `Remove a custom prompt template. Built-in prompts cannot be removed.`,
+	Long:  `Remove a custom prompt template. Built-in and project prompts cannot be removed.`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
 
-		store, err := prompts.NewStore()
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
 		if err != nil {
-			return fmt.Errorf("failed to load prompts: %w", err)
+			return err
 		}
 
-		// Check if it's a built-in prompt
+		// Check if it's a built-in prompt, or a user prompt overriding one
+		if store.Overrides(name) {
+			return fmt.Errorf("'%s' overrides a built-in prompt; use 'raypaste config prompt reset %s' to restore the built-in", name, name)
+		}
 		if store.IsBuiltIn(name) {
 			return fmt.Errorf("cannot remove built-in prompt: %s", name)
 		}
@@ -343,32 +467,16 @@ var configPromptRemoveCmd = &cobra.Command{
 	},
 }
 
-// isValidPromptName checks if a prompt name contains only valid characters
-func isValidPromptName(name string) bool {
-	if name == "" {
-		return false
-	}
-	for _, r := range name {
-		if !isValidPromptChar(r) {
-			return false
-		}
-	}
-	return true
-}
-
-// isValidPromptChar checks if a rune is a valid character for prompt names
-func isValidPromptChar(r rune) bool {
-	switch {
-	case r >= 'a' && r <= 'z':
-		return true
-	case r >= 'A' && r <= 'Z':
-		return true
-	case r >= '0' && r <= '9':
-		return true
-	case r == '-', r == '_':
-		return true
+// formatPromptOrigin returns a colored label for where a prompt was loaded from
+func formatPromptOrigin(origin prompts.Origin) string {
+	label := "[" + string(origin) + "]"
+	switch origin {
+	case prompts.OriginProject:
+		return output.Yellow(label)
+	case prompts.OriginUser:
+		return output.Cyan(label)
 	default:
-		return false
+		return output.Green(label)
 	}
 }
 
@@ -380,6 +488,7 @@ func init() {
 	configPromptCmd.AddCommand(configPromptListCmd)
 	configPromptCmd.AddCommand(configPromptShowCmd)
 	configPromptCmd.AddCommand(configPromptRemoveCmd)
+	configPromptCmd.AddCommand(configPromptResetCmd)
 
 	// Flags for add command
 	configPromptAddCmd.Flags().StringP("description", "d", "", "Description of the prompt")
@@ -391,4 +500,5 @@ func init() {
 
 	// Flags for remove command
 	configPromptRemoveCmd.Flags().BoolP("force", "f", false, "Force removal without confirmation")
+	configPromptResetCmd.Flags().BoolP("force", "f", false, "Reset without confirmation")
 }
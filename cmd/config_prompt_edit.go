@@ -0,0 +1,234 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// editAnnotationPrefix marks the problem lines added to the top of a prompt file
+// that failed validation. They are removed before the file is parsed or saved.
+const editAnnotationPrefix = "# raypaste: "
+
+// configPromptEditCmd represents the config prompt edit command
+var configPromptEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Edit a prompt in your editor",
+	Long: `Open a prompt's YAML file in $VISUAL or $EDITOR (default: vi). Like git, raypaste
+runs the editor through the shell, so the variable may include arguments.
+
+When the editor closes, the prompt is parsed and linted. If it has errors, they are
+added as comments at the top of the file and the editor reopens, so nothing invalid
+is saved. Warnings are shown but don't block saving.
+
+Built-in prompts can't be edited in place; instead, edit offers to create a user
+override copy in ~/.raypaste/prompts/ that takes precedence over the built-in.`,
+	Example: `  raypaste config prompt edit code-review
+  EDITOR="code --wait" raypaste config prompt edit metaprompt`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+		prompt, err := store.Get(name)
+		if err != nil {
+			return err
+		}
+
+		path := store.Source(name)
+		var original []byte
+		if path == "" {
+			if !confirm(fmt.Sprintf("'%s' is a built-in prompt. Create a user override copy to edit", name)) {
+				fmt.Fprintln(os.Stderr, output.Red("Cancelled - prompt not edited"))
+				return nil
+			}
+			promptsDir, err := config.GetPromptsDir()
+			if err != nil {
+				return err
+			}
+			if err := os.MkdirAll(promptsDir, 0755); err != nil {
+				return fmt.Errorf("failed to create prompts directory: %w", err)
+			}
+			path = filepath.Join(promptsDir, name+".yaml")
+			if original, err = yaml.Marshal(prompt); err != nil {
+				return fmt.Errorf("failed to marshal prompt to YAML: %w", err)
+			}
+		} else if original, err = os.ReadFile(path); err != nil {
+			return fmt.Errorf("failed to read prompt file: %w", err)
+		}
+
+		cmd.SilenceUsage = true
+		edited, err := editPromptFile(store, name, original)
+		if err != nil {
+			return err
+		}
+		if edited == nil {
+			fmt.Fprintln(os.Stderr, output.Yellow("No changes - prompt not saved"))
+			return nil
+		}
+
+		// Project prompts belong to the repository; only user prompts keep a history
+		if store.Origin(name) == prompts.OriginProject {
+			err = os.WriteFile(path, edited, 0644)
+		} else {
+			err = prompts.WritePromptFile(path, name, edited)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s %s %s\n", output.Green("✓"), output.Green("Prompt"), output.BoldBlue(name), output.Green("saved to "+path))
+		return nil
+	},
+}
+
+// editPromptFile opens data in the editor until it validates or the user gives up.
+// It returns the new contents, or nil when the prompt is unchanged.
+func editPromptFile(store *prompts.Store, name string, data []byte) ([]byte, error) {
+	tmp, err := os.CreateTemp("", "raypaste-"+name+"-*.yaml")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_ = tmp.Close()
+
+	contents := data
+	for {
+		if err := os.WriteFile(tmp.Name(), contents, 0600); err != nil {
+			return nil, fmt.Errorf("failed to write temporary file: %w", err)
+		}
+		if err := runEditor(tmp.Name()); err != nil {
+			return nil, err
+		}
+		edited, err := os.ReadFile(tmp.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read edited file: %w", err)
+		}
+		edited = stripEditAnnotations(edited)
+		if bytes.Equal(edited, data) {
+			return nil, nil
+		}
+
+		problems := validateEditedPrompt(store, name, edited)
+		if len(problems) == 0 {
+			return edited, nil
+		}
+
+		for _, problem := range problems {
+			fmt.Fprintf(os.Stderr, "%s %s\n", output.Red("error"), problem)
+		}
+		if !confirm("Reopen the editor to fix the errors") {
+			return nil, fmt.Errorf("prompt '%s' has errors; changes were not saved", name)
+		}
+		contents = annotateEdit(edited, problems)
+	}
+}
+
+// validateEditedPrompt parses and lints an edited prompt file, prints its warnings,
+// and returns its errors.
+func validateEditedPrompt(store *prompts.Store, name string, data []byte) []string {
+	prompt, issues, err := store.LintFile(data, cfg.Models, cfg.GetDefaultModel())
+	if err != nil {
+		return []string{err.Error()}
+	}
+	if prompt.Name != name {
+		return []string{fmt.Sprintf("name must stay '%s' (got '%s'); use 'config prompt add' to create a new prompt", name, prompt.Name)}
+	}
+
+	var problems []string
+	for _, issue := range issues {
+		if issue.Severity == prompts.LintError {
+			problems = append(problems, issue.Message)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "%s %s\n", output.Yellow("warning"), issue.Message)
+	}
+	return problems
+}
+
+// annotateEdit adds problems as comments at the top of data.
+func annotateEdit(data []byte, problems []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(editAnnotationPrefix + "The prompt was not saved. Fix these errors:\n")
+	for _, problem := range problems {
+		for _, line := range strings.Split(problem, "\n") {
+			buf.WriteString(editAnnotationPrefix + "  " + line + "\n")
+		}
+	}
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// stripEditAnnotations removes the block of lines annotateEdit added to the top of
+// data. Lines further down are the user's, even if they look like annotations.
+func stripEditAnnotations(data []byte) []byte {
+	for bytes.HasPrefix(data, []byte(editAnnotationPrefix)) {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			return data[len(data):]
+		}
+		data = data[end+1:]
+	}
+	return data
+}
+
+// runEditor opens path in $VISUAL, $EDITOR or vi and waits for it to exit. Like git,
+// it runs the editor through the shell, so the variable may hold arguments and
+// quoted paths.
+func runEditor(path string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+
+	var c *exec.Cmd
+	if runtime.GOOS == "windows" {
+		fields := strings.Fields(editor)
+		c = exec.Command(fields[0], append(fields[1:], path)...)
+	} else {
+		c = exec.Command("sh", "-c", editor+` "$@"`, editor, path)
+	}
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor %s failed: %w", editor, err)
+	}
+	return nil
+}
+
+// stdinReader is shared by every confirm call so buffered answers aren't lost.
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// confirm asks a yes/no question on stderr and reads the answer from stdin.
+func confirm(question string) bool {
+	fmt.Fprintf(os.Stderr, "%s %s? [y/N]: ", output.Yellow("?"), output.Yellow(question))
+	response, _ := stdinReader.ReadString('\n')
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
+}
+
+func init() {
+	configPromptCmd.AddCommand(configPromptEditCmd)
+}
@@ -0,0 +1,221 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/textdiff"
+	"github.com/spf13/cobra"
+)
+
+// configPromptHistoryCmd represents the config prompt history command
+var configPromptHistoryCmd = &cobra.Command{
+	Use:   "history <name>",
+	Short: "List saved versions of a prompt",
+	Long: `List the saved versions of a user prompt, oldest first.
+
+A version is recorded in ~/.raypaste/prompts/.history/<name>/ whenever raypaste writes
+the prompt (add, import, rollback) and before it overwrites or removes a file that was
+edited by hand, so earlier versions can be compared and restored.
+
+raypaste doesn't keep a usage ledger, so versions aren't linked to the generations
+they produced.`,
+	Example: `  raypaste config prompt history code-review`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		versions, err := prompts.ListVersions(name)
+		if err != nil {
+			return err
+		}
+		if len(versions) == 0 {
+			return fmt.Errorf("prompt '%s' has no saved versions", name)
+		}
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+		current, _ := currentPromptFile(store, name)
+
+		// The current version is the latest one matching the prompt file
+		currentVersion := 0
+		for _, v := range versions {
+			if data, err := os.ReadFile(v.Path); err == nil && current != nil && bytes.Equal(data, current) {
+				currentVersion = v.Number
+			}
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s\n", output.Bold("History of"), output.Cyan(name))
+		for _, v := range versions {
+			marker := ""
+			if v.Number == currentVersion {
+				marker = output.Green(" (current)")
+			}
+			fmt.Printf("v%-4d %s%s\n", v.Number, v.Time.Local().Format("2006-01-02 15:04:05"), marker)
+		}
+
+		switch {
+		case current == nil:
+			fmt.Fprintln(os.Stderr, output.Yellow("The prompt file has been removed; restore it with 'config prompt rollback'."))
+		case currentVersion == 0:
+			fmt.Fprintln(os.Stderr, output.Yellow("The prompt file has changes that aren't saved as a version yet."))
+		}
+		return nil
+	},
+}
+
+// configPromptDiffCmd represents the config prompt diff command
+var configPromptDiffCmd = &cobra.Command{
+	Use:   "diff <name> [v1] [v2]",
+	Short: "Compare versions of a prompt",
+	Long: `Show the line changes between two versions of a prompt.
+
+With no versions, compares the previous version with the current file. With one
+version, compares it with the current file. Versions are numbers from 'config prompt
+history', written as 3 or v3.`,
+	Example: `  raypaste config prompt diff code-review
+  raypaste config prompt diff code-review v2
+  raypaste config prompt diff code-review v1 v3`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+
+		var before, after []byte
+		var beforeLabel, afterLabel string
+		switch len(args) {
+		case 1:
+			current, err := currentPromptFile(store, name)
+			if err != nil {
+				return err
+			}
+			versions, err := prompts.ListVersions(name)
+			if err != nil {
+				return err
+			}
+			// The latest version is usually the current file; compare with the one before it
+			var previous *prompts.Version
+			for i := len(versions) - 1; i >= 0 && previous == nil; i-- {
+				if data, err := os.ReadFile(versions[i].Path); err == nil && !bytes.Equal(data, current) {
+					previous = &versions[i]
+					before = data
+				}
+			}
+			if previous == nil {
+				return fmt.Errorf("prompt '%s' has no earlier version to compare with", name)
+			}
+			beforeLabel, after, afterLabel = fmt.Sprintf("v%d", previous.Number), current, "current"
+		case 2:
+			if before, beforeLabel, err = readPromptVersion(name, args[1]); err != nil {
+				return err
+			}
+			if after, err = currentPromptFile(store, name); err != nil {
+				return err
+			}
+			afterLabel = "current"
+		default:
+			if before, beforeLabel, err = readPromptVersion(name, args[1]); err != nil {
+				return err
+			}
+			if after, afterLabel, err = readPromptVersion(name, args[2]); err != nil {
+				return err
+			}
+		}
+
+		diff := textdiff.Lines(string(before), string(after))
+		if diff == "" {
+			fmt.Fprintf(os.Stderr, "%s %s and %s are identical\n", output.Green("✓"), beforeLabel, afterLabel)
+			return nil
+		}
+		fmt.Printf("%s\n%s\n", output.Red("--- "+beforeLabel), output.Green("+++ "+afterLabel))
+		for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+			fmt.Println(output.ColorDiffLine(line))
+		}
+		return nil
+	},
+}
+
+// configPromptRollbackCmd represents the config prompt rollback command
+var configPromptRollbackCmd = &cobra.Command{
+	Use:   "rollback <name> <version>",
+	Short: "Restore an earlier version of a prompt",
+	Long: `Restore a saved version of a user prompt. The current file is kept in the history
+first, and the restored contents are saved as a new version, so a rollback can itself
+be undone. Removed prompts can be restored the same way.`,
+	Example: `  raypaste config prompt rollback code-review v2`,
+	Args:    cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		number, err := parseVersionArg(args[1])
+		if err != nil {
+			return err
+		}
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+		if err := store.Rollback(name, number); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s %s %s %s\n", output.Green("✓"), output.Green("Prompt"), output.BoldBlue(name), output.Green(fmt.Sprintf("restored to version %d", number)))
+		return nil
+	},
+}
+
+// currentPromptFile returns the contents of the file the named user prompt was
+// loaded from.
+func currentPromptFile(store *prompts.Store, name string) ([]byte, error) {
+	if store.Origin(name) != prompts.OriginUser {
+		return nil, fmt.Errorf("prompt '%s' is not a user prompt file", name)
+	}
+	data, err := os.ReadFile(store.Source(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file: %w", err)
+	}
+	return data, nil
+}
+
+// readPromptVersion reads a version given as "3" or "v3" and returns it with a label.
+func readPromptVersion(name, arg string) ([]byte, string, error) {
+	number, err := parseVersionArg(arg)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := prompts.ReadVersion(name, number)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, fmt.Sprintf("v%d", number), nil
+}
+
+// parseVersionArg parses a version number written as "3" or "v3".
+func parseVersionArg(arg string) (int, error) {
+	number, err := strconv.Atoi(strings.TrimPrefix(arg, "v"))
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid version %q: expected a number such as 3 or v3", arg)
+	}
+	return number, nil
+}
+
+func init() {
+	configPromptCmd.AddCommand(configPromptHistoryCmd)
+	configPromptCmd.AddCommand(configPromptDiffCmd)
+	configPromptCmd.AddCommand(configPromptRollbackCmd)
+}
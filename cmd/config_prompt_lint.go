@@ -0,0 +1,90 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+// configPromptLintCmd represents the config prompt lint command
+var configPromptLintCmd = &cobra.Command{
+	Use:   "lint [name]",
+	Short: "Check prompts for problems",
+	Long: `Check prompt templates for problems: templates that don't parse or fail to render,
+fields that Render doesn't supply, undeclared or unused variables, a missing {{.Context}},
+numeric directives and max_tokens above the model's maximum output, prompt files that
+fail to load, and prompt names defined in more than one file.
+
+Exits with a non-zero status when any error is found (or any warning, with --strict),
+so it can run in CI.`,
+	Example: `  raypaste config prompt lint code-review
+  raypaste config prompt lint --all
+  raypaste config prompt lint --all --strict`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		strict, _ := cmd.Flags().GetBool("strict")
+		if all == (len(args) == 1) {
+			return fmt.Errorf("specify a prompt name or --all")
+		}
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return fmt.Errorf("failed to load prompts: %w", err)
+		}
+
+		var issues []prompts.LintIssue
+		if all {
+			issues = store.LintAll(cfg.Models, cfg.GetDefaultModel())
+		} else {
+			issues, err = store.Lint(args[0], cfg.Models, cfg.GetDefaultModel())
+			if err != nil {
+				return err
+			}
+		}
+
+		errorCount, warningCount := printLintIssues(issues)
+
+		if len(issues) == 0 {
+			fmt.Fprintf(os.Stderr, "%s No problems found\n", output.Green("✓"))
+			return nil
+		}
+
+		fmt.Fprintf(os.Stderr, "\n%d error(s), %d warning(s)\n", errorCount, warningCount)
+		if errorCount > 0 || (strict && warningCount > 0) {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("prompt lint failed")
+		}
+		return nil
+	},
+}
+
+// printLintIssues prints each issue to stdout and returns the number of errors and
+// warnings.
+func printLintIssues(issues []prompts.LintIssue) (errorCount, warningCount int) {
+	for _, issue := range issues {
+		label := output.Yellow("warning")
+		if issue.Severity == prompts.LintError {
+			label = output.Red("error  ")
+			errorCount++
+		} else {
+			warningCount++
+		}
+		fmt.Printf("%s %s: %s\n", label, output.Bold(issue.Prompt), issue.Message)
+	}
+	return errorCount, warningCount
+}
+
+func init() {
+	configPromptCmd.AddCommand(configPromptLintCmd)
+
+	configPromptLintCmd.Flags().Bool("all", false, "Lint every prompt loaded from a file")
+	configPromptLintCmd.Flags().Bool("strict", false, "Exit non-zero on warnings as well as errors")
+}
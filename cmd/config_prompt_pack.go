@@ -0,0 +1,187 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/promptpack"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/spf13/cobra"
+)
+
+// configPromptExportCmd represents the config prompt export command
+var configPromptExportCmd = &cobra.Command{
+	Use:   "export <names...>",
+	Short: "Bundle prompts into a shareable pack",
+	Long: `Bundle prompts into a pack (.tar.gz) that others can install with 'config prompt import'.
+
+The pack contains each prompt file, the prompts they extend, the partials they include,
+and their test suites and recorded fixtures. Built-in prompts and partials are not
+exported, since every install already has them.`,
+	Example: `  raypaste config prompt export code-review sql -o team.tar.gz
+  raypaste config prompt export code-review -o review.tar.gz --author "Jane Doe" --version 1.2.0`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputPath, _ := cmd.Flags().GetString("output")
+		name, _ := cmd.Flags().GetString("name")
+		description, _ := cmd.Flags().GetString("description")
+		author, _ := cmd.Flags().GetString("author")
+		version, _ := cmd.Flags().GetString("version")
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+
+		pack, err := promptpack.Export(store, args, promptpack.Manifest{
+			Name:        name,
+			Description: description,
+			Author:      author,
+			Version:     version,
+			Created:     time.Now().UTC().Truncate(time.Second),
+		})
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to create pack: %w", err)
+		}
+		if err := pack.Write(file); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write pack: %w", err)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s Exported %d prompt(s), %d partial(s) and %d test suite(s) to %s\n",
+			output.Green("✓"), len(pack.Manifest.Prompts), len(pack.Manifest.Partials), len(pack.Tests), output.Bold(outputPath))
+		fmt.Fprintf(os.Stderr, "  %s %s\n", output.Cyan("Prompts:"), strings.Join(pack.Manifest.Prompts, ", "))
+		return nil
+	},
+}
+
+// configPromptImportCmd represents the config prompt import command
+var configPromptImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Install prompts from a pack",
+	Long: `Install the prompts, partials, tests and fixtures in a pack created with
+'config prompt export' into ~/.raypaste/prompts/.
+
+The pack is validated before anything is written: every prompt must load, resolve its
+parent, and lint without errors alongside your existing prompts. A prompt or partial
+whose name is already in use is a conflict; install it under another name with
+--rename old=new, or replace the existing one with --overwrite.`,
+	Example: `  raypaste config prompt import team.tar.gz
+  raypaste config prompt import team.tar.gz --rename code-review=team-review
+  raypaste config prompt import team.tar.gz --overwrite`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		renames, _ := cmd.Flags().GetStringArray("rename")
+		overwrite, _ := cmd.Flags().GetBool("overwrite")
+
+		file, err := os.Open(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to open pack: %w", err)
+		}
+		pack, err := promptpack.Read(file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+		printPackManifest(pack.Manifest)
+
+		for _, rename := range renames {
+			from, to, ok := strings.Cut(rename, "=")
+			if !ok || from == "" || prompts.ValidateName(to) != nil {
+				return fmt.Errorf("invalid --rename %q: expected old=new with a valid prompt name", rename)
+			}
+			if err := pack.Rename(from, to); err != nil {
+				return err
+			}
+		}
+
+		workingDir, _ := os.Getwd()
+		store, err := loadPromptStore(workingDir)
+		if err != nil {
+			return err
+		}
+
+		cmd.SilenceUsage = true
+		if conflicts := pack.Conflicts(store); len(conflicts) > 0 {
+			for _, c := range conflicts {
+				fmt.Fprintf(os.Stderr, "%s %s '%s' already exists %s\n", output.Yellow("conflict"), c.Kind, c.Name, formatPromptOrigin(c.Origin))
+				if overwrite && c.Origin == prompts.OriginProject {
+					fmt.Fprintf(os.Stderr, "  %s the project's %s will still take precedence in this repository\n", output.Yellow("Note:"), c.Kind)
+				}
+			}
+			if !overwrite {
+				return fmt.Errorf("pack conflicts with existing prompts; use --rename old=new or --overwrite")
+			}
+		}
+
+		issues, err := pack.Validate(store, cfg.Models, cfg.GetDefaultModel())
+		printLintIssues(issues)
+		if err != nil {
+			return fmt.Errorf("%w; nothing was installed", err)
+		}
+
+		written, err := pack.Install(store, overwrite)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stderr, "%s Installed %d prompt(s): %s\n", output.Green("✓"), len(pack.Manifest.Prompts), output.Bold(strings.Join(pack.Manifest.Prompts, ", ")))
+		for _, path := range written {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		return nil
+	},
+}
+
+// printPackManifest prints what a pack is and who made it.
+func printPackManifest(m promptpack.Manifest) {
+	title := m.Name
+	if title == "" {
+		title = "Prompt pack"
+	}
+	if m.Version != "" {
+		title += " " + m.Version
+	}
+	fmt.Fprintln(os.Stderr, output.Bold(title))
+	if m.Description != "" {
+		fmt.Fprintf(os.Stderr, "  %s\n", m.Description)
+	}
+	if m.Author != "" {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", output.Cyan("Author:"), m.Author)
+	}
+	fmt.Fprintf(os.Stderr, "  %s %s\n", output.Cyan("Prompts:"), strings.Join(m.Prompts, ", "))
+	if len(m.Partials) > 0 {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", output.Cyan("Partials:"), strings.Join(m.Partials, ", "))
+	}
+	fmt.Fprintln(os.Stderr)
+}
+
+func init() {
+	configPromptCmd.AddCommand(configPromptExportCmd)
+	configPromptCmd.AddCommand(configPromptImportCmd)
+
+	configPromptExportCmd.Flags().StringP("output", "o", "", "Pack file to write, e.g. team.tar.gz (required)")
+	configPromptExportCmd.Flags().String("name", "", "Name of the pack")
+	configPromptExportCmd.Flags().StringP("description", "d", "", "Description of the pack")
+	configPromptExportCmd.Flags().String("author", "", "Author of the pack")
+	configPromptExportCmd.Flags().String("version", "", "Version of the pack, e.g. 1.0.0")
+	_ = configPromptExportCmd.MarkFlagRequired("output")
+
+	configPromptImportCmd.Flags().StringArray("rename", nil, "Install a prompt under a new name, as old=new (repeatable)")
+	configPromptImportCmd.Flags().Bool("overwrite", false, "Replace existing prompts and partials with the pack's")
+}
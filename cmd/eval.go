@@ -0,0 +1,167 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/eval"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/spf13/cobra"
+)
+
+// evalItemTimeout bounds one dataset item: two generations and a judgement.
+const evalItemTimeout = 3 * time.Minute
+
+var (
+	evalDatasetFlag string
+	evalJudgeFlag   string
+	evalRubricFlag  string
+	evalJSONFlag    bool
+)
+
+// evalCmd represents the eval command
+var evalCmd = &cobra.Command{
+	Use:   "eval <variant-a> <variant-b>",
+	Short: "Compare two prompt variants with a judge model",
+	Long: output.Bold("Compare two prompt variants") + output.Cyan(" over a dataset, scored by a judge model.") + `
+
+Each variant is a prompt name, optionally with a model: ` + output.Green("metaprompt") + ` or ` + output.Green("metaprompt@openai-gpt5-nano") + `.
+Variants without a model use --model, then the prompt's model, then the default model.
+
+Every dataset item is run through both variants, and the judge model scores both
+outputs against a rubric and picks a winner. The order the outputs are shown to the
+judge alternates between items to cancel out position bias. The summary reports win
+rates, mean scores, and token usage for each variant and the judge.
+
+` + output.Bold("Dataset format (YAML):") + `
+  items:
+    - "a plain input"
+    - input: "an input with its own length and variables"
+      length: short
+      vars: {audience: developers}`,
+	Example: `  raypaste eval metaprompt metaprompt-v2 --dataset goals.yaml
+  raypaste eval metaprompt@cerebras-llama-8b metaprompt@openai-gpt5-nano --dataset goals.yaml --judge sonnet-4.6
+  raypaste eval metaprompt metaprompt-v2 --dataset goals.yaml --rubric rubric.txt --json > results.json`,
+	Args: cobra.ExactArgs(2),
+	RunE: runEval,
+}
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+
+	evalCmd.Flags().StringVar(&evalDatasetFlag, "dataset", "", "YAML file of inputs to evaluate (required)")
+	evalCmd.Flags().StringVar(&evalJudgeFlag, "judge", "", "Judge model alias or OpenRouter ID (default: the default model)")
+	evalCmd.Flags().StringVar(&evalRubricFlag, "rubric", "", "File with the judging rubric (default: built-in rubric)")
+	evalCmd.Flags().BoolVar(&evalJSONFlag, "json", false, "Print per-item results and the summary as JSON")
+	_ = evalCmd.MarkFlagRequired("dataset")
+}
+
+func runEval(cmd *cobra.Command, args []string) error {
+	a, err := eval.ParseVariant(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := eval.ParseVariant(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Load prompts first so datasets can use lengths that prompt files define
+	env, defaults, err := loadGenerateEnv(cmd)
+	if err != nil {
+		return err
+	}
+	for _, v := range []*eval.Variant{&a, &b} {
+		if _, err := env.Store.Get(v.Prompt); err != nil {
+			return err
+		}
+		if v.Model == "" {
+			v.Model = defaults.Model
+		}
+	}
+
+	dataset, err := eval.LoadDataset(evalDatasetFlag, env.Store.Lengths())
+	if err != nil {
+		return err
+	}
+
+	opts := eval.Options{JudgeModel: evalJudgeFlag, Length: defaults.Length}
+	if opts.JudgeModel == "" {
+		opts.JudgeModel = cfg.GetDefaultModel()
+	}
+	if evalRubricFlag != "" {
+		data, err := os.ReadFile(evalRubricFlag)
+		if err != nil {
+			return fmt.Errorf("failed to read rubric: %w", err)
+		}
+		opts.Rubric = string(data)
+	}
+
+	client := llm.NewClient(cfg.GetAPIKey())
+	results := make([]eval.ItemResult, 0, len(dataset.Items))
+	for i, item := range dataset.Items {
+		ctx, cancel := context.WithTimeout(context.Background(), evalItemTimeout)
+		result := eval.RunItem(ctx, env, client, a, b, item, i, opts)
+		cancel()
+
+		printEvalProgress(i+1, len(dataset.Items), result)
+		results = append(results, result)
+	}
+
+	summary := eval.Summarize(a, b, results)
+	if evalJSONFlag {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Summary eval.Summary      `json:"summary"`
+			Items   []eval.ItemResult `json:"items"`
+		}{summary, results})
+	}
+
+	printEvalSummary(summary, opts.JudgeModel)
+	return nil
+}
+
+func printEvalProgress(n, total int, result eval.ItemResult) {
+	prefix := output.Cyan(fmt.Sprintf("[%d/%d]", n, total))
+	if result.Verdict == nil {
+		fmt.Fprintf(os.Stderr, "%s %s %s\n", prefix, output.Red("error:"), result.Err)
+		return
+	}
+
+	v := result.Verdict
+	label := "tie"
+	switch v.Winner {
+	case eval.WinnerA:
+		label = output.Green("A wins")
+	case eval.WinnerB:
+		label = output.Yellow("B wins")
+	}
+	fmt.Fprintf(os.Stderr, "%s %s (%.0f vs %.0f) %s\n", prefix, label, v.ScoreA, v.ScoreB, v.Reason)
+}
+
+func printEvalSummary(s eval.Summary, judgeModel string) {
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\tVARIANT\tWINS\tWIN RATE\tMEAN SCORE\tPROMPT TOKENS\tCOMPLETION TOKENS")
+	for _, row := range []struct {
+		label string
+		v     eval.VariantSummary
+	}{{"A", s.A}, {"B", s.B}} {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1f%%\t%.2f\t%d\t%d\n",
+			row.label, row.v.Variant, row.v.Wins, row.v.WinRate*100, row.v.MeanScore,
+			row.v.Usage.PromptTokens, row.v.Usage.CompletionTokens)
+	}
+	_ = w.Flush()
+
+	fmt.Printf("\nJudged: %d  Ties: %d  Errors: %d\n", s.Judged, s.Ties, s.Errors)
+	fmt.Printf("Judge (%s): %d prompt tokens, %d completion tokens\n", judgeModel, s.Judge.PromptTokens, s.Judge.CompletionTokens)
+}
@@ -7,12 +7,12 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
 	"github.com/raypaste/raypaste-cli/internal/config"
 	"github.com/raypaste/raypaste-cli/internal/interactive"
 	"github.com/raypaste/raypaste-cli/internal/llm"
 	"github.com/raypaste/raypaste-cli/internal/output"
 	"github.com/raypaste/raypaste-cli/internal/projectcontext"
-	"github.com/raypaste/raypaste-cli/internal/prompts"
 
 	"github.com/spf13/cobra"
 )
@@ -36,6 +36,11 @@ continuously generate prompts with streaming output.
   ` + output.Green("/copy") + `                         - Copy last response to clipboard
 	` + output.Green("/prompt") + `                       - Show current prompt and list of available prompts
   ` + output.Green("/prompt [name]") + `         			  - Switch prompt template to provided prompt
+  ` + output.Green("/set [key] [value]") + `            - Set a template variable (no args lists variables)
+  ` + output.Green("/unset [key]") + `                  - Remove a template variable
+  ` + output.Green("/refine [n]") + `                   - Critique and rewrite each response for up to n rounds (0 turns it off)
+  ` + output.Green("/reasoning [on|off]") + `           - Show or hide the model's reasoning, dimmed, before each response
+  ` + output.Green("/attach [path]") + `                - Attach an image, PDF or text file to the next message (no args lists attachments)
   ` + output.Green("/help") + `                         - Show help
   ` + output.Green("/quit") + ` or ` + output.Green("/exit") + `                - Exit REPL
 
@@ -51,36 +56,59 @@ continuously generate prompts with streaming output.
 
 func init() {
 	rootCmd.AddCommand(interactiveCmd)
+	addGenerationFlags(interactiveCmd)
 }
 
 func runInteractive(cmd *cobra.Command, args []string) error {
-	state := &interactive.State{
-		Model:      modelFlag,
-		PromptName: promptFlag,
+	vars, err := parseVarFlags(varFlags)
+	if err != nil {
+		return err
+	}
+	if refineFlag < 0 {
+		return fmt.Errorf("--refine must be 0 or more, got %d", refineFlag)
+	}
+	if err := config.ValidateReasoning(reasoningEffortFlag, reasoningTokensFlag); err != nil {
+		return err
+	}
+	attachments, err := attachment.LoadAll(attachFlag)
+	if err != nil {
+		return err
 	}
 
-	if state.Model == "" {
-		state.Model = cfg.GetDefaultModel()
+	state := &interactive.State{
+		Model:       modelFlag,
+		PromptName:  promptFlag,
+		Vars:        vars,
+		Refine:      refineFlag,
+		CriticModel: criticFlag,
+		Attachments: attachments,
 	}
 
-	length, err := config.ValidateOutputLength(lengthFlag)
+	// Without --model, each prompt's preferred model applies before the config default
+	state.DefaultModel = cfg.GetDefaultModel()
+
+	workingDir, _ := os.Getwd()
+	state.Store, err = loadPromptStore(workingDir)
 	if err != nil {
 		return err
 	}
-	state.Length = length
 
-	state.Store, err = prompts.NewStore()
+	// Prompt files can define lengths, so validate after the store is loaded
+	state.Length, err = state.Store.Lengths().Validate(lengthFlag)
 	if err != nil {
-		return fmt.Errorf("failed to load prompts: %w", err)
+		return err
 	}
-
-	workingDir, _ := os.Getwd()
 	state.ProjCtx = projectcontext.Load(workingDir)
+	warnReasoningIgnored(state.CurrentModel())
 	state.Client = llm.NewClient(cfg.GetAPIKey())
 
 	return interactive.Run(state, interactive.Options{
-		Temperature: cfg.Temperature,
-		Models:      cfg.Models,
-		AutoCopy:    !noCopyFlag && !cfg.DisableCopy,
+		Temperature:         cfg.Temperature,
+		TemperatureOverride: temperatureOverride(cmd),
+		ReasoningEffort:     reasoningEffortFlag,
+		ReasoningMaxTokens:  reasoningTokensFlag,
+		Models:              cfg.Models,
+		AutoCopy:            !noCopyFlag && !cfg.DisableCopy,
+		ShowSteps:           showSteps,
 	})
 }
@@ -0,0 +1,62 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/mcp"
+	"github.com/raypaste/raypaste-cli/internal/output"
+
+	"github.com/spf13/cobra"
+)
+
+// mcpCmd represents the mcp command
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Run raypaste as a Model Context Protocol server over stdio",
+	Long: output.Bold("Run raypaste as a Model Context Protocol server") + output.Cyan(" over stdio.") + `
+
+Every prompt template is exposed as an MCP tool, so agent tools can call
+raypaste's curated prompts directly:
+
+  ` + output.Green("generate_metaprompt") + ` - metaprompt
+  ` + output.Green("organize_bullets") + `    - bulletlist
+  ` + output.Green("generate_<name>") + `     - every custom prompt (hyphens become underscores)
+
+Resources ` + output.Green("raypaste://prompts") + ` and ` + output.Green("raypaste://models") + ` list prompts and models,
+and ` + output.Green("raypaste://prompts/<name>") + ` returns a prompt's template.
+
+The --model and --length flags set the defaults for tool calls that omit them.
+Prompts with tools read files under the project root of the directory mcp was
+started in and send them to the model; files that commonly hold secrets are refused.
+
+` + output.Bold("Example client configuration:") + `
+  {"mcpServers": {"raypaste": {"command": "raypaste", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: runMCP,
+}
+
+func init() {
+	rootCmd.AddCommand(mcpCmd)
+}
+
+func runMCP(cmd *cobra.Command, args []string) error {
+	env, defaults, err := loadGenerateEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	server := mcp.New(env, llm.NewClient(cfg.GetAPIKey()), defaults, Version)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// stdout carries the protocol; diagnostics must go to stderr.
+	return server.Serve(ctx, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/prompttest"
+	"github.com/spf13/cobra"
+)
+
+// promptCaseTimeout bounds a single test case's completion.
+const promptCaseTimeout = 60 * time.Second
+
+// promptCmd represents the prompt command
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Test prompt templates",
+	Long: output.Bold("Test prompt templates") + output.Cyan(" against models or recorded fixtures.") + `
+
+To create, list, or remove prompts, use 'raypaste config prompt'.
+
+` + output.Bold("Available subcommands:") + `
+  ` + output.Green("test") + ` - Run a prompt's regression test cases`,
+}
+
+// promptTestCmd represents the prompt test command
+var promptTestCmd = &cobra.Command{
+	Use:   "test [name]",
+	Short: "Run a prompt's regression test cases",
+	Long: `Run the test cases in ~/.raypaste/prompts/tests/<name>.yaml and report pass/fail.
+
+Each case sends an input through the prompt and checks the output with assertions:
+must_contain, must_not_contain, must_not_start_with, max_words, matches (regex) and
+json. Cases run against the model chosen by --model, the prompt's model, or the
+default model, and use --length unless they set their own length.
+
+With --record, outputs are saved as fixtures in tests/fixtures/<name>.yaml. With
+--fixtures, the recorded outputs are checked instead of calling the model, and a case
+fails if the prompt now renders a different system prompt than when it was recorded.
+Failing cases show a diff against the recorded fixture.
+
+Exits with a non-zero status when any case fails.`,
+	Example: `  raypaste prompt test code-review
+  raypaste prompt test code-review -m openai-gpt5-nano --record
+  raypaste prompt test --all --fixtures`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPromptTest,
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptTestCmd)
+
+	promptTestCmd.Flags().Bool("all", false, "Run every test suite")
+	promptTestCmd.Flags().Bool("record", false, "Save outputs as fixtures")
+	promptTestCmd.Flags().Bool("fixtures", false, "Check recorded fixtures instead of calling the model")
+}
+
+func runPromptTest(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	record, _ := cmd.Flags().GetBool("record")
+	offline, _ := cmd.Flags().GetBool("fixtures")
+	if all == (len(args) == 1) {
+		return fmt.Errorf("specify a prompt name or --all")
+	}
+	if record && offline {
+		return fmt.Errorf("--record and --fixtures cannot be used together")
+	}
+	if !offline && cfg.GetAPIKey() == "" {
+		return fmt.Errorf("API key not found: set RAYPASTE_API_KEY or add api_key to config.yaml, or use --fixtures")
+	}
+
+	// Load prompts first so suites can use lengths that prompt files define
+	env, defaults, err := loadGenerateEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	if all {
+		if paths, err = prompttest.ListSuites(); err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no test suites found")
+		}
+	} else {
+		path, err := prompttest.SuitePath(args[0])
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("no test suite for prompt '%s' (expected %s)", args[0], path)
+		}
+		paths = []string{path}
+	}
+
+	client := llm.NewClient(cfg.GetAPIKey())
+	passed, failed := 0, 0
+	for _, path := range paths {
+		suite, err := prompttest.LoadSuite(path, env.Store.Lengths())
+		if err != nil {
+			return err
+		}
+		fixtures, err := prompttest.LoadFixtures(suite.Prompt)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(os.Stderr, output.Bold(suite.Prompt))
+		for _, c := range suite.Cases {
+			result := runPromptTestCase(env, client, suite.Prompt, c, prompttest.Options{
+				Model:    defaults.Model,
+				Length:   defaults.Length,
+				Fixtures: fixtures,
+				Offline:  offline,
+			})
+			printPromptTestResult(result)
+
+			if result.Passed() {
+				passed++
+			} else {
+				failed++
+			}
+			if record && result.Fixture != nil {
+				fixtures[c.Name] = *result.Fixture
+			}
+		}
+
+		if record {
+			if err := prompttest.SaveFixtures(suite.Prompt, fixtures); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(os.Stderr, "")
+	}
+
+	fmt.Fprintf(os.Stderr, "%d passed, %d failed\n", passed, failed)
+	if failed > 0 {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%d test case(s) failed", failed)
+	}
+	return nil
+}
+
+// runPromptTestCase runs a single case with its own timeout.
+func runPromptTestCase(env *generate.Env, client generate.Completer, promptName string, c prompttest.Case, opts prompttest.Options) prompttest.Result {
+	ctx, cancel := context.WithTimeout(context.Background(), promptCaseTimeout)
+	defer cancel()
+
+	return prompttest.RunCase(ctx, env, client, promptName, c, opts)
+}
+
+func printPromptTestResult(result prompttest.Result) {
+	if result.Passed() {
+		fmt.Fprintf(os.Stderr, "  %s %s\n", output.Green("✓"), result.Case.Name)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "  %s %s\n", output.Red("✗"), result.Case.Name)
+	for _, failure := range result.Failures {
+		fmt.Fprintf(os.Stderr, "      %s\n", failure)
+	}
+	if result.Diff == "" {
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "      %s %s\n", output.Red("--- recorded"), output.Green("+++ current"))
+	for _, line := range strings.Split(strings.TrimSuffix(result.Diff, "\n"), "\n") {
+		fmt.Fprintf(os.Stderr, "      %s\n", output.ColorDiffLine(line))
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultProxyAddr = "127.0.0.1:7767"
+
+var proxyAddrFlag string
+
+// proxyCmd represents the proxy command
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Run an OpenAI-compatible proxy that injects raypaste prompts",
+	Long: output.Bold("Run an OpenAI-compatible proxy") + output.Cyan(" that injects raypaste prompt templates.") + `
+
+Accepts OpenAI-style ` + output.Green("POST /v1/chat/completions") + ` requests and forwards them through
+the configured provider after prepending a raypaste prompt template (rendered with
+project context) as the first system message. The template is selected via the model name:
+
+  ` + output.Green("raypaste/<prompt>") + `          - use the prompt with the default model
+  ` + output.Green("raypaste/<prompt>@<model>") + `  - use the prompt with a model alias or OpenRouter ID
+
+` + output.Green("GET /v1/models") + ` lists the available raypaste/<prompt> names. The --length flag sets the
+length directive; a request's max_tokens and temperature override the defaults.
+
+` + output.Bold("Example:") + `
+  raypaste proxy
+  OPENAI_BASE_URL=http://127.0.0.1:7767/v1 some-openai-client --model raypaste/metaprompt`,
+	Args: cobra.NoArgs,
+	RunE: runProxy,
+}
+
+func init() {
+	rootCmd.AddCommand(proxyCmd)
+	proxyCmd.Flags().StringVar(&proxyAddrFlag, "addr", defaultProxyAddr, "Address to listen on (host:port)")
+}
+
+func runProxy(cmd *cobra.Command, args []string) error {
+	env, defaults, err := loadGenerateEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	handler := server.NewProxy(env, llm.NewClient(cfg.GetAPIKey()), defaults, proxyAddrFlag)
+	return listenAndServe(proxyAddrFlag, handler, env, "raypaste proxy listening on")
+}
@@ -11,12 +11,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
 	"github.com/raypaste/raypaste-cli/internal/clipboard"
 	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate"
 	"github.com/raypaste/raypaste-cli/internal/llm"
 	"github.com/raypaste/raypaste-cli/internal/output"
 	"github.com/raypaste/raypaste-cli/internal/projectcontext"
 	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/refine"
+	"github.com/raypaste/raypaste-cli/pkg/types"
 
 	"github.com/spf13/cobra"
 )
@@ -27,7 +31,16 @@ var (
 	lengthFlag string
 	promptFlag string
 	noCopyFlag bool
+	varFlags   []string
+	tempFlag   float64
+	showSteps  bool
+	refineFlag int
+	criticFlag string
+	attachFlag []string
 	cfg        *config.Config
+
+	reasoningEffortFlag string
+	reasoningTokensFlag int
 )
 
 // Version information (set via -ldflags during build)
@@ -55,6 +68,7 @@ with configurable output lengths and fast/small model routing.
 ` + output.Bold("Examples:") + `
   raypaste "help me write a blog post" ` + output.Green("--length short") + `
   raypaste "analyze CSV data" ` + output.Green("-l long") + `
+  raypaste "a prompt to build this screen" ` + output.Green("--attach mockup.png") + `
   echo "my goal" | raypaste
   raypaste interactive`,
 	Args: func(cmd *cobra.Command, args []string) error {
@@ -94,15 +108,31 @@ func init() {
 	// Persistent flags (available to all subcommands)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.raypaste/config.yaml)")
 	rootCmd.PersistentFlags().StringVarP(&modelFlag, "model", "m", "", "Model alias or OpenRouter ID")
-	rootCmd.PersistentFlags().StringVarP(&lengthFlag, "length", "l", "medium", "Output length: short|medium|long")
+	rootCmd.PersistentFlags().StringVarP(&lengthFlag, "length", "l", "medium", "Output length: short|medium|long or a custom length")
 	rootCmd.PersistentFlags().StringVarP(&promptFlag, "prompt", "p", "metaprompt", "Prompt template name")
 	rootCmd.PersistentFlags().BoolVar(&noCopyFlag, "no-copy", false, "Disable auto-copy to clipboard")
+	rootCmd.PersistentFlags().Float64Var(&tempFlag, "temperature", 0, "Sampling temperature (overrides config and prompt defaults)")
+	rootCmd.PersistentFlags().StringVar(&reasoningEffortFlag, "reasoning-effort", "", "Reasoning effort: minimal|low|medium|high (overrides the prompt and model)")
+	rootCmd.PersistentFlags().IntVar(&reasoningTokensFlag, "reasoning-max-tokens", 0, "Cap reasoning at N tokens instead of setting an effort (overrides the prompt and model)")
+
+	addGenerationFlags(rootCmd)
+}
+
+// addGenerationFlags adds the flags that only apply when generating output, which
+// the root and interactive commands do.
+func addGenerationFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&varFlags, "var", nil, "Template variable as key=value (repeatable)")
+	cmd.Flags().BoolVar(&showSteps, "show-steps", false, "Show the output of each step of a pipeline prompt")
+	cmd.Flags().IntVar(&refineFlag, "refine", 0, "Critique and rewrite the output for up to N rounds")
+	cmd.Flags().StringVar(&criticFlag, "critic", "", "Model that critiques the output with --refine (default: the generating model)")
+	cmd.Flags().StringArrayVar(&attachFlag, "attach", nil, "Attach an image, PDF or text file to the input (repeatable)")
 }
 
 // initConfig reads in config file and ENV variables if set
 func initConfig() {
-	// Skip API key validation for config commands (they can set the key)
-	if len(os.Args) > 1 && os.Args[1] == "config" {
+	// Skip API key validation for config commands (they can set the key) and prompt
+	// commands (which can run offline and check the key themselves)
+	if len(os.Args) > 1 && (os.Args[1] == "config" || os.Args[1] == "prompt") {
 		var err error
 		cfg, err = config.LoadConfig(cfgFile)
 		if err != nil {
@@ -127,7 +157,7 @@ func initConfig() {
 }
 
 // runGenerate handles the generation logic for raypaste "text"
-func runGenerate(_ *cobra.Command, args []string) error {
+func runGenerate(cmd *cobra.Command, args []string) error {
 	// Get input from args or stdin
 	input, err := getInput(args)
 	if err != nil {
@@ -138,65 +168,106 @@ func runGenerate(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("no input provided")
 	}
 
-	// Validate and get output length
-	length, err := config.ValidateOutputLength(lengthFlag)
+	vars, err := parseVarFlags(varFlags)
 	if err != nil {
 		return err
 	}
-
-	// Get model (use flag if set, otherwise config default)
-	model := modelFlag
-	if model == "" {
-		model = cfg.GetDefaultModel()
+	if refineFlag < 0 {
+		return fmt.Errorf("--refine must be 0 or more, got %d", refineFlag)
 	}
-
-	store, err := prompts.NewStore()
+	if err := config.ValidateReasoning(reasoningEffortFlag, reasoningTokensFlag); err != nil {
+		return err
+	}
+	attachments, err := attachment.LoadAll(attachFlag)
 	if err != nil {
-		return fmt.Errorf("failed to load prompts: %w", err)
+		return err
 	}
 
 	workingDir, _ := os.Getwd()
-	projCtx := projectcontext.Load(workingDir)
+	store, err := loadPromptStore(workingDir)
+	if err != nil {
+		return err
+	}
 
-	systemPrompt, err := store.Render(promptFlag, length, projCtx.Content)
+	// Validate the output length once prompt files have registered their own lengths
+	length, err := store.Lengths().Validate(lengthFlag)
 	if err != nil {
-		return fmt.Errorf("failed to render prompt: %w", err)
+		return err
+	}
+	projCtx := projectcontext.Load(workingDir)
+
+	env := &generate.Env{
+		Store:               store,
+		ProjCtx:             projCtx,
+		Temperature:         cfg.Temperature,
+		Models:              cfg.Models,
+		DefaultModel:        cfg.GetDefaultModel(),
+		TemperatureOverride: temperatureOverride(cmd),
+		ReasoningEffort:     reasoningEffortFlag,
+		ReasoningMaxTokens:  reasoningTokensFlag,
 	}
 
-	maxTokensOverride := store.GetMaxTokensOverride(promptFlag, length)
+	// Model precedence: --model flag, then the prompt's model, then the config default
+	model := env.ResolveModel(promptFlag, modelFlag)
+	warnReasoningIgnored(model)
 
-	req, err := llm.BuildRequest(
-		model,
-		systemPrompt,
-		input,
-		length,
-		cfg.Temperature,
-		false, // no streaming for generate
-		cfg.Models,
-		maxTokensOverride,
-	)
+	// Each step of a pipeline prompt is a completion of its own
+	steps, err := store.Steps(promptFlag)
 	if err != nil {
-		return fmt.Errorf("failed to build request: %w", err)
+		return err
 	}
 
 	client := llm.NewClient(cfg.GetAPIKey())
 
 	// Show progress indicator
 	fmt.Fprintln(os.Stderr, output.GeneratingMessage(model, string(length), projCtx.Filename))
+	printAttachments(attachments)
 	fmt.Fprintln(os.Stderr, "")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(max(len(steps), 1))*30*time.Second)
 	defer cancel()
 
 	startTime := time.Now()
-	result, usage, err := client.Complete(ctx, req)
-	durationMs := time.Since(startTime).Milliseconds()
+	params := generate.Params{
+		Input:       input,
+		PromptName:  promptFlag,
+		Model:       modelFlag,
+		Length:      length,
+		Vars:        vars,
+		Attachments: attachment.Parts(attachments),
+	}
+	run, err := env.Run(ctx, client, params, generate.RunOptions{OnStep: stepPrinter(), OnToolCall: printToolCall})
 	if err != nil {
 		return fmt.Errorf("generation failed: %w", err)
 	}
+	result, usage := run.Output, run.Usage
+
+	if refineFlag > 0 {
+		refineCtx, cancel := context.WithTimeout(context.Background(), time.Duration(refineFlag)*60*time.Second)
+		defer cancel()
+
+		refined, err := refine.Run(refineCtx, env, client, params, run, refine.Options{
+			Rounds:      refineFlag,
+			CriticModel: criticFlag,
+			OnRound:     printRefineRound(refineFlag),
+		})
+		// A failed round keeps the last version that finished
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: refinement failed, keeping the last finished version: %v\n", err)
+		}
+		result = refined.Output
+		usage.PromptTokens += refined.Usage.PromptTokens
+		usage.CompletionTokens += refined.Usage.CompletionTokens
+		usage.TotalTokens += refined.Usage.TotalTokens
+	}
+	durationMs := time.Since(startTime).Milliseconds()
 
-	// Print result to stdout (colorize if markdown)
-	fmt.Println(output.ColorizeMarkdown(result))
+	// Print result to stdout (colorize if markdown; structured output is indented JSON)
+	if run.Structured {
+		fmt.Println(result)
+	} else {
+		fmt.Println(output.ColorizeMarkdown(result))
+	}
 
 	// Copy to clipboard by default unless disabled
 	shouldCopy := !noCopyFlag && !cfg.DisableCopy
@@ -215,6 +286,50 @@ func runGenerate(_ *cobra.Command, args []string) error {
 	return nil
 }
 
+// stepPrinter returns a RunOptions.OnStep callback that prints each intermediate step
+// of a pipeline to stderr when --show-steps is set, or nil otherwise.
+func stepPrinter() func(generate.StepResult) {
+	if !showSteps {
+		return nil
+	}
+	n := 0
+	return func(step generate.StepResult) {
+		n++
+		fmt.Fprintln(os.Stderr, output.StepMessage(n, step.Prompt, step.Model))
+		fmt.Fprintln(os.Stderr, step.Output)
+		fmt.Fprintln(os.Stderr, "")
+	}
+}
+
+// printToolCall prints a tool the model called to stderr.
+func printToolCall(call types.ToolCall, _ string) {
+	fmt.Fprintln(os.Stderr, output.ToolCallMessage(call.Function.Name, call.Function.Arguments))
+}
+
+// printAttachments prints the files attached to the input to stderr.
+func printAttachments(attachments []attachment.Attachment) {
+	for _, a := range attachments {
+		fmt.Fprintln(os.Stderr, output.AttachmentMessage(a.Name, a.MIMEType, attachment.FormatSize(a.Size)))
+	}
+}
+
+// printRefineRound returns a refine.Options.OnRound callback that prints each round's
+// critique and the changes it made to stderr.
+func printRefineRound(total int) func(refine.Round) {
+	return func(round refine.Round) {
+		fmt.Fprintln(os.Stderr, output.RefineRoundMessage(round.Number, total, round.Approved))
+		if !round.Approved {
+			fmt.Fprintln(os.Stderr, output.Cyan(round.Critique))
+			if diff := round.Diff(); diff != "" {
+				for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+					fmt.Fprintln(os.Stderr, output.ColorDiffLine(line))
+				}
+			}
+		}
+		fmt.Fprintln(os.Stderr, "")
+	}
+}
+
 // getInput gets input from args or stdin
 func getInput(args []string) (string, error) {
 	if len(args) > 0 {
@@ -238,3 +353,51 @@ func getInput(args []string) (string, error) {
 
 	return "", nil
 }
+
+// parseVarFlags converts repeated --var key=value flags into a map.
+// Later flags override earlier ones with the same key.
+func parseVarFlags(flags []string) (map[string]string, error) {
+	vars := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, ok := strings.Cut(flag, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --var %q: expected key=value", flag)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// loadPromptStore loads all prompts, including project prompts found above
+// workingDir, and points template functions such as {{include}} at the project
+// containing workingDir.
+func loadPromptStore(workingDir string) (*prompts.Store, error) {
+	store, err := prompts.NewStoreWithLengths(workingDir, cfg.Lengths)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompts: %w", err)
+	}
+	store.SetProjectRoot(projectcontext.FindRoot(workingDir))
+	store.AllowEnv(cfg.TemplateEnv...)
+	return store, nil
+}
+
+// warnReasoningIgnored warns when --reasoning-effort or --reasoning-max-tokens is set
+// but model is known not to reason, so requests to it leave the settings out.
+func warnReasoningIgnored(model string) {
+	if reasoningEffortFlag == "" && reasoningTokensFlag == 0 {
+		return
+	}
+	if resolved, err := config.ResolveModel(model, cfg.Models); err == nil && config.Disabled(resolved.Reasoning) {
+		fmt.Fprintf(os.Stderr, "Warning: model %s doesn't support reasoning; ignoring the reasoning effort and token budget\n", model)
+	}
+}
+
+// temperatureOverride returns the --temperature flag value if it was set.
+func temperatureOverride(cmd *cobra.Command) *float64 {
+	if !cmd.Flags().Changed("temperature") {
+		return nil
+	}
+	temperature := tempFlag
+	return &temperature
+}
@@ -0,0 +1,154 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/projectcontext"
+	"github.com/raypaste/raypaste-cli/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+const defaultServeAddr = "127.0.0.1:7766"
+
+var serveAddrFlag string
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve raypaste over a local HTTP API",
+	Long: output.Bold("Serve raypaste over a local HTTP API") + output.Cyan(" for editors and other tools.") + `
+
+Starts an HTTP server that shares this raypaste configuration, prompts and
+project context, so tools can call raypaste without shelling out.
+
+` + output.Bold("Endpoints:") + `
+  ` + output.Green("GET  /v1/prompts") + `   - List available prompts
+  ` + output.Green("GET  /v1/models") + `    - List available models
+  ` + output.Green("POST /v1/render") + `    - Render a prompt's system prompt ({"prompt","length"})
+  ` + output.Green("POST /v1/generate") + `  - Generate ({"input","prompt","model","length","stream"})
+  ` + output.Green("GET  /healthz") + `      - Health check
+
+Set "stream": true on /v1/generate to receive Server-Sent Events.
+The --model, --length and --prompt flags set the defaults for requests that omit them.
+Prompts with tools read files under the project root of the directory serve was
+started in and send them to the model; files that commonly hold secrets are refused.
+
+` + output.Bold("Examples:") + `
+  raypaste serve
+  raypaste serve --addr 127.0.0.1:9000 -p bulletlist`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&serveAddrFlag, "addr", defaultServeAddr, "Address to listen on (host:port)")
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	env, defaults, err := loadGenerateEnv(cmd)
+	if err != nil {
+		return err
+	}
+
+	handler := server.New(env, llm.NewClient(cfg.GetAPIKey()), defaults, serveAddrFlag)
+	return listenAndServe(serveAddrFlag, handler, env, "raypaste server listening on")
+}
+
+// loadGenerateEnv builds the shared generation environment and request defaults
+// from the loaded config and the root persistent flags.
+func loadGenerateEnv(cmd *cobra.Command) (*generate.Env, generate.Defaults, error) {
+	workingDir, _ := os.Getwd()
+	store, err := loadPromptStore(workingDir)
+	if err != nil {
+		return nil, generate.Defaults{}, err
+	}
+
+	// Prompt files can define lengths, so validate after the store is loaded
+	length, err := store.Lengths().Validate(lengthFlag)
+	if err != nil {
+		return nil, generate.Defaults{}, err
+	}
+
+	if _, err := store.Get(promptFlag); err != nil {
+		return nil, generate.Defaults{}, err
+	}
+	if err := config.ValidateReasoning(reasoningEffortFlag, reasoningTokensFlag); err != nil {
+		return nil, generate.Defaults{}, err
+	}
+
+	env := &generate.Env{
+		Store:               store,
+		ProjCtx:             projectcontext.Load(workingDir),
+		Temperature:         cfg.Temperature,
+		Models:              cfg.Models,
+		DefaultModel:        cfg.GetDefaultModel(),
+		TemperatureOverride: temperatureOverride(cmd),
+		ReasoningEffort:     reasoningEffortFlag,
+		ReasoningMaxTokens:  reasoningTokensFlag,
+	}
+
+	warnReasoningIgnored(env.ResolveModel(promptFlag, modelFlag))
+
+	return env, generate.Defaults{
+		PromptName: promptFlag,
+		Model:      modelFlag,
+		Length:     length,
+	}, nil
+}
+
+// listenAndServe runs handler on addr until interrupted, then shuts down gracefully.
+func listenAndServe(addr string, handler http.Handler, env *generate.Env, banner string) error {
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	fmt.Fprintf(os.Stderr, "%s %s\n", output.Green(banner), output.BoldWhite("http://"+addr))
+	if env.ProjCtx.Filename != "" {
+		fmt.Fprintf(os.Stderr, "%s %s\n", output.White("Using project context from"), output.Magenta(env.ProjCtx.Filename))
+	}
+	fmt.Fprintln(os.Stderr, output.Cyan("Press Ctrl+C to stop"))
+
+	select {
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down server: %w", err)
+	}
+
+	fmt.Fprintln(os.Stderr, output.Yellow("Server stopped"))
+	return nil
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package attachment
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+const (
+	// MaxImageBytes caps the size of an attached image.
+	MaxImageBytes = 5 << 20
+	// MaxPDFBytes caps the size of an attached PDF.
+	MaxPDFBytes = 10 << 20
+	// MaxTextBytes caps the size of an attached text file.
+	MaxTextBytes = 256 << 10
+	// MaxTotalBytes caps the combined size of the files attached to one request.
+	MaxTotalBytes = 20 << 20
+)
+
+// imageTypes are the image formats models accept.
+var imageTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// Attachment is a file loaded to send with a request.
+type Attachment struct {
+	// Name is the file's base name.
+	Name string
+	// MIMEType is detected from the file's contents.
+	MIMEType string
+	// Size is the file's size in bytes.
+	Size int
+	// Part is the file as message content: an image, a PDF file or text.
+	Part types.ContentPart
+}
+
+// Load reads the file at path and returns it as an attachment. Its type is detected
+// from its contents: PNG, JPEG, GIF and WebP images are sent as images, PDFs as
+// files and UTF-8 text as text. Other types, empty files and files over the size
+// limit for their type are an error.
+func Load(path string) (Attachment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	info, err := f.Stat()
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to open attachment: %w", err)
+	}
+	if info.IsDir() {
+		return Attachment{}, fmt.Errorf("can't attach %s: it is a directory", path)
+	}
+
+	// Read one byte past the largest limit so oversized files are caught
+	data, err := io.ReadAll(io.LimitReader(f, MaxPDFBytes+1))
+	if err != nil {
+		return Attachment{}, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	if len(data) == 0 {
+		return Attachment{}, fmt.Errorf("can't attach %s: the file is empty", path)
+	}
+
+	a := Attachment{Name: filepath.Base(path), MIMEType: DetectType(data), Size: len(data)}
+	var limit int
+	switch {
+	case imageTypes[a.MIMEType]:
+		limit = MaxImageBytes
+		a.Part = types.ContentPart{Type: "image_url", ImageURL: &types.ImageURL{URL: dataURL(a.MIMEType, data)}}
+	case a.MIMEType == "application/pdf":
+		limit = MaxPDFBytes
+		a.Part = types.ContentPart{Type: "file", File: &types.File{Filename: a.Name, FileData: dataURL(a.MIMEType, data)}}
+	case a.MIMEType == "text/plain":
+		limit = MaxTextBytes
+		a.Part = types.ContentPart{Type: "text", Text: fmt.Sprintf("<attachment name=%q>\n%s\n</attachment>", a.Name, data)}
+	default:
+		return Attachment{}, fmt.Errorf("can't attach %s: unsupported file type %s (attach a PNG, JPEG, GIF or WebP image, a PDF or a text file)", path, a.MIMEType)
+	}
+	if a.Size > limit {
+		return Attachment{}, fmt.Errorf("can't attach %s: %s files are limited to %s", path, a.MIMEType, FormatSize(limit))
+	}
+	return a, nil
+}
+
+// LoadAll loads each path in turn and checks their combined size.
+func LoadAll(paths []string) ([]Attachment, error) {
+	attachments := make([]Attachment, 0, len(paths))
+	for _, path := range paths {
+		a, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	if err := CheckTotal(attachments); err != nil {
+		return nil, err
+	}
+	return attachments, nil
+}
+
+// CheckTotal returns an error if attachments are together larger than MaxTotalBytes.
+func CheckTotal(attachments []Attachment) error {
+	total := 0
+	for _, a := range attachments {
+		total += a.Size
+	}
+	if total > MaxTotalBytes {
+		return fmt.Errorf("attachments total %s; the limit is %s per request", FormatSize(total), FormatSize(MaxTotalBytes))
+	}
+	return nil
+}
+
+// Parts returns the content parts of attachments, in order.
+func Parts(attachments []Attachment) []types.ContentPart {
+	if len(attachments) == 0 {
+		return nil
+	}
+	parts := make([]types.ContentPart, len(attachments))
+	for i, a := range attachments {
+		parts[i] = a.Part
+	}
+	return parts
+}
+
+// DetectType returns the MIME type of data, without parameters. Text of any kind,
+// such as Markdown, HTML or JSON, is text/plain as long as it is valid UTF-8.
+func DetectType(data []byte) string {
+	detected, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if strings.HasPrefix(detected, "text/") && utf8.Valid(data) {
+		return "text/plain"
+	}
+	return detected
+}
+
+// FormatSize formats a byte count for display, such as "340 KB" or "1.5 MB".
+func FormatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/(1<<20)), ".0") + " MB"
+	case n >= 1<<10:
+		return fmt.Sprintf("%d KB", n>>10)
+	}
+	return fmt.Sprintf("%d bytes", n)
+}
+
+// dataURL returns data as a base64 data URL.
+func dataURL(mimeType string, data []byte) string {
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package attachment
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// pngHeader is the signature of a PNG file.
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"screen.png": pngHeader + "pixels",
+		"spec.pdf":   "%PDF-1.7\n...",
+		"notes.md":   "# Notes\n",
+		"empty.txt":  "",
+		"app.bin":    "\x00\x01\x02\xff\xfe",
+		"nul.bin":    "\x00\x01",
+		"huge.png":   pngHeader + strings.Repeat("x", MaxImageBytes),
+		"long.txt":   strings.Repeat("a", MaxTextBytes+1),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		wantType string
+		wantPart string
+		wantErr  string
+	}{
+		{"image", "screen.png", "image/png", `"image_url":{"url":"data:image/png;base64,iVBORw0KGgpwaXhlbHM="}`, ""},
+		{"pdf", "spec.pdf", "application/pdf", `"file":{"filename":"spec.pdf","file_data":"data:application/pdf;base64,`, ""},
+		{"text", "notes.md", "text/plain", `"text":"<attachment name=\"notes.md\">\n# Notes\n\n</attachment>"`, ""},
+		{"empty", "empty.txt", "", "", "the file is empty"},
+		{"unsupported type", "app.bin", "", "", "unsupported file type application/octet-stream"},
+		{"binary that is valid UTF-8", "nul.bin", "", "", "unsupported file type application/octet-stream"},
+		{"image too large", "huge.png", "", "", "image/png files are limited to 5 MB"},
+		{"text too large", "long.txt", "", "", "text/plain files are limited to 256 KB"},
+		{"missing", "missing.png", "", "", "failed to open attachment"},
+		{"directory", ".", "", "", "it is a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Load(filepath.Join(dir, tt.file))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Load() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if a.Name != tt.file || a.MIMEType != tt.wantType || a.Size != len(files[tt.file]) {
+				t.Errorf("Load() = %s (%s, %d bytes), want %s (%s, %d bytes)", a.Name, a.MIMEType, a.Size, tt.file, tt.wantType, len(files[tt.file]))
+			}
+			var part strings.Builder
+			enc := json.NewEncoder(&part)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(a.Part); err != nil || !strings.Contains(part.String(), tt.wantPart) {
+				t.Errorf("Part = %s, want containing %s", part.String(), tt.wantPart)
+			}
+		})
+	}
+}
+
+func TestCheckTotal(t *testing.T) {
+	half := Attachment{Size: MaxTotalBytes / 2}
+	if err := CheckTotal([]Attachment{half, half}); err != nil {
+		t.Errorf("CheckTotal() error = %v, want none at the limit", err)
+	}
+	if err := CheckTotal([]Attachment{half, half, {Size: 1}}); err == nil || !strings.Contains(err.Error(), "the limit is 20 MB") {
+		t.Errorf("CheckTotal() error = %v, want over the limit", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{512, "512 bytes"},
+		{340 << 10, "340 KB"},
+		{5 << 20, "5 MB"},
+		{3 << 19, "1.5 MB"},
+	}
+	for _, tt := range tests {
+		if got := FormatSize(tt.n); got != tt.want {
+			t.Errorf("FormatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMessageWithParts(t *testing.T) {
+	plain, err := json.Marshal(types.Message{Role: "user", Content: "hi"})
+	if err != nil || string(plain) != `{"role":"user","content":"hi"}` {
+		t.Errorf("Marshal() = %s, %v, want string content", plain, err)
+	}
+
+	image := types.ContentPart{Type: "image_url", ImageURL: &types.ImageURL{URL: "data:image/png;base64,AA=="}}
+	withParts, err := json.Marshal(types.Message{Role: "user", Content: "hi", Parts: []types.ContentPart{image}})
+	want := `{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}},{"type":"text","text":"hi"}]}`
+	if err != nil || string(withParts) != want {
+		t.Errorf("Marshal() = %s, %v, want %s", withParts, err, want)
+	}
+}
@@ -15,13 +15,15 @@ import (
 
 // Config represents the application configuration
 type Config struct {
-	APIKey        string             `mapstructure:"api_key"`
-	DefaultModel  string             `mapstructure:"default_model"`
-	DefaultLength types.OutputLength `mapstructure:"default_length"`
-	AutoCopy      bool               `mapstructure:"auto_copy"`    // Deprecated: kept for backward compatibility
-	DisableCopy   bool               `mapstructure:"disable_copy"` // New field to disable clipboard copying
-	Models        map[string]Model   `mapstructure:"models"`
-	Temperature   float64            `mapstructure:"temperature"`
+	APIKey        string                        `mapstructure:"api_key"`
+	DefaultModel  string                        `mapstructure:"default_model"`
+	DefaultLength types.OutputLength            `mapstructure:"default_length"`
+	AutoCopy      bool                          `mapstructure:"auto_copy"`    // Deprecated: kept for backward compatibility
+	DisableCopy   bool                          `mapstructure:"disable_copy"` // New field to disable clipboard copying
+	Models        map[string]Model              `mapstructure:"models"`
+	Temperature   float64                       `mapstructure:"temperature"`
+	TemplateEnv   []string                      `mapstructure:"template_env"` // Extra environment variables prompt templates may read
+	Lengths       map[string]types.LengthParams `mapstructure:"lengths"`      // User-defined output lengths
 }
 
 var globalConfig *Config
@@ -93,6 +95,24 @@ func LoadConfig(configPath string) (*Config, error) {
 		cfg.Models = make(map[string]Model)
 	}
 
+	for alias, model := range cfg.Models {
+		if err := ValidateReasoning(model.ReasoningEffort, model.ReasoningMaxTokens); err != nil {
+			return nil, fmt.Errorf("invalid model '%s' in config: %w", alias, err)
+		}
+		// Configuring reasoning settings implies the model reasons
+		if model.ReasoningEffort != "" || model.ReasoningMaxTokens > 0 {
+			if Disabled(model.Reasoning) {
+				return nil, fmt.Errorf("invalid model '%s' in config: reasoning settings are set but reasoning is false", alias)
+			}
+			model.Reasoning = Bool(true)
+			cfg.Models[alias] = model
+		}
+	}
+
+	if err := NewLengths().Define("", cfg.Lengths); err != nil {
+		return nil, fmt.Errorf("invalid length in config: %w", err)
+	}
+
 	globalConfig = &cfg
 	return &cfg, nil
 }
@@ -142,16 +162,6 @@ func (c *Config) GetDefaultLength() types.OutputLength {
 	return types.OutputLengthMedium
 }
 
-// ValidateOutputLength validates and returns a valid OutputLength
-func ValidateOutputLength(length string) (types.OutputLength, error) {
-	switch types.OutputLength(length) {
-	case types.OutputLengthShort, types.OutputLengthMedium, types.OutputLengthLong:
-		return types.OutputLength(length), nil
-	default:
-		return "", fmt.Errorf("invalid output length: %s (must be short, medium, or long)", length)
-	}
-}
-
 // GetConfigDir returns the configuration directory path
 func GetConfigDir() (string, error) {
 	home, err := os.UserHomeDir()
@@ -199,6 +209,12 @@ func (c *Config) SaveTo(path string) error {
 	if c.Models != nil {
 		v.Set("models", c.Models)
 	}
+	if len(c.TemplateEnv) > 0 {
+		v.Set("template_env", c.TemplateEnv)
+	}
+	if len(c.Lengths) > 0 {
+		v.Set("lengths", c.Lengths)
+	}
 
 	if err := v.WriteConfigAs(configPath); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
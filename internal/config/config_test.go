@@ -10,7 +10,7 @@ import (
 	"github.com/raypaste/raypaste-cli/pkg/types"
 )
 
-func TestValidateOutputLength(t *testing.T) {
+func TestLengthsValidate(t *testing.T) {
 	tests := []struct {
 		name    string
 		input   string
@@ -26,13 +26,14 @@ func TestValidateOutputLength(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := ValidateOutputLength(tt.input)
+			var lengths *Lengths
+			got, err := lengths.Validate(tt.input)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("ValidateOutputLength() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if got != tt.want {
-				t.Errorf("ValidateOutputLength() = %v, want %v", got, tt.want)
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,146 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// BuiltInLengths lists the output lengths that are always available, in display order.
+var BuiltInLengths = []types.OutputLength{
+	types.OutputLengthShort,
+	types.OutputLengthMedium,
+	types.OutputLengthLong,
+}
+
+// IsBuiltInLength reports whether length is one of BuiltInLengths.
+func IsBuiltInLength(length types.OutputLength) bool {
+	return slices.Contains(BuiltInLengths, length)
+}
+
+// Lengths holds the user-defined output lengths, from config.yaml and prompt files.
+// Each length is owned by the prompt that defined it, or by config.yaml. A nil
+// *Lengths holds none; the built-in lengths are always available.
+type Lengths struct {
+	mu     sync.RWMutex
+	params map[types.OutputLength]types.LengthParams
+	// owners maps each length to the prompt that defined it, or "" for config.yaml.
+	owners map[types.OutputLength]string
+}
+
+// NewLengths returns an empty set of user-defined lengths.
+func NewLengths() *Lengths {
+	return &Lengths{
+		params: make(map[types.OutputLength]types.LengthParams),
+		owners: make(map[types.OutputLength]string),
+	}
+}
+
+// validateLength checks a user-defined length's name and parameters.
+func validateLength(name string, params types.LengthParams) error {
+	switch {
+	case name == "" || strings.ContainsAny(name, " \t\n"):
+		return fmt.Errorf("invalid length name %q", name)
+	case IsBuiltInLength(types.OutputLength(name)):
+		return fmt.Errorf("length '%s' is built in and cannot be redefined", name)
+	case params.MaxTokens <= 0:
+		return fmt.Errorf("length '%s' must set a positive max_tokens", name)
+	}
+	return nil
+}
+
+// Define replaces the lengths owner defined before with lengths; owner is a prompt
+// name, or "" for config.yaml. Built-in lengths cannot be redefined, and a length
+// another owner defined with different parameters is rejected. Nothing changes
+// unless every length is valid.
+func (l *Lengths) Define(owner string, lengths map[string]types.LengthParams) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(lengths)) {
+		params := lengths[name]
+		if err := validateLength(name, params); err != nil {
+			return err
+		}
+		length := types.OutputLength(name)
+		if existing, ok := l.params[length]; ok && l.owners[length] != owner && existing != params {
+			return fmt.Errorf("length '%s' is already defined with different parameters", name)
+		}
+	}
+
+	for length, o := range l.owners {
+		if o == owner {
+			delete(l.params, length)
+			delete(l.owners, length)
+		}
+	}
+	for name, params := range lengths {
+		length := types.OutputLength(name)
+		if _, ok := l.params[length]; !ok {
+			l.owners[length] = owner
+		}
+		l.params[length] = params
+	}
+	return nil
+}
+
+// Clone returns a copy of l, to validate definitions against without changing l.
+func (l *Lengths) Clone() *Lengths {
+	clone := NewLengths()
+	if l == nil {
+		return clone
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	maps.Copy(clone.params, l.params)
+	maps.Copy(clone.owners, l.owners)
+	return clone
+}
+
+// Get returns the parameters of a user-defined length.
+func (l *Lengths) Get(length types.OutputLength) (types.LengthParams, bool) {
+	if l == nil {
+		return types.LengthParams{}, false
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	params, ok := l.params[length]
+	return params, ok
+}
+
+// List returns the built-in lengths followed by user-defined lengths, sorted.
+func (l *Lengths) List() []types.OutputLength {
+	var custom []types.OutputLength
+	if l != nil {
+		l.mu.RLock()
+		custom = slices.Sorted(maps.Keys(l.params))
+		l.mu.RUnlock()
+	}
+	return append(slices.Clone(BuiltInLengths), custom...)
+}
+
+// Validate returns length as an OutputLength if it is built in or defined in l.
+func (l *Lengths) Validate(length string) (types.OutputLength, error) {
+	if IsBuiltInLength(types.OutputLength(length)) {
+		return types.OutputLength(length), nil
+	}
+	if _, ok := l.Get(types.OutputLength(length)); ok {
+		return types.OutputLength(length), nil
+	}
+
+	lengths := l.List()
+	names := make([]string, len(lengths))
+	for i, name := range lengths {
+		names[i] = string(name)
+	}
+	return "", fmt.Errorf("invalid output length: %s (must be one of: %s)", length, strings.Join(names, ", "))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestLengthsDefine(t *testing.T) {
+	tweet := types.LengthParams{MaxTokens: 80, Directive: "Fit in one tweet."}
+
+	tests := []struct {
+		name    string
+		owner   string
+		length  string
+		params  types.LengthParams
+		wantErr bool
+	}{
+		{"valid", "social", "tweet", tweet, false},
+		{"identical definition by another owner", "thread", "tweet", tweet, false},
+		{"conflicting definition by another owner", "other", "tweet", types.LengthParams{MaxTokens: 100}, true},
+		{"built-in name", "social", "short", tweet, true},
+		{"empty name", "social", "", tweet, true},
+		{"name with space", "social", "two words", tweet, true},
+		{"missing max_tokens", "social", "essay", types.LengthParams{Directive: "Write an essay."}, true},
+	}
+
+	lengths := NewLengths()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := lengths.Define(tt.owner, map[string]types.LengthParams{tt.length: tt.params})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Define() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got, ok := lengths.Get("tweet"); !ok || got != tweet {
+		t.Errorf("Get(tweet) = %v, %v, want %v, true", got, ok, tweet)
+	}
+}
+
+func TestLengthsRedefine(t *testing.T) {
+	lengths := NewLengths()
+	if err := lengths.Define("social", map[string]types.LengthParams{"tweet": {MaxTokens: 100}, "toot": {MaxTokens: 150}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An owner can change and drop its own lengths
+	if err := lengths.Define("social", map[string]types.LengthParams{"tweet": {MaxTokens: 120}}); err != nil {
+		t.Fatalf("Define() error = %v, want redefinition by the same owner to succeed", err)
+	}
+	if got, _ := lengths.Get("tweet"); got.MaxTokens != 120 {
+		t.Errorf("Get(tweet) = %v, want the new max_tokens", got)
+	}
+	if _, ok := lengths.Get("toot"); ok {
+		t.Error("Get(toot) found a length its owner no longer defines")
+	}
+
+	// A rejected definition changes nothing
+	if err := lengths.Define("social", map[string]types.LengthParams{"tweet": {MaxTokens: 90}, "short": {MaxTokens: 10}}); err == nil {
+		t.Fatal("Define() should reject a built-in name")
+	}
+	if got, _ := lengths.Get("tweet"); got.MaxTokens != 120 {
+		t.Errorf("Get(tweet) = %v after a rejected definition, want it unchanged", got)
+	}
+
+	// A clone changes independently
+	clone := lengths.Clone()
+	if err := clone.Define("social", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := lengths.Get("tweet"); !ok {
+		t.Error("Define() on a clone changed the original")
+	}
+}
+
+func TestLengthsList(t *testing.T) {
+	lengths := NewLengths()
+	if err := lengths.Define("", map[string]types.LengthParams{"tweet": {MaxTokens: 100}, "essay": {MaxTokens: 100}}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []types.OutputLength{types.OutputLengthShort, types.OutputLengthMedium, types.OutputLengthLong, "essay", "tweet"}
+	if got := lengths.List(); !slices.Equal(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	var none *Lengths
+	if got := none.List(); !slices.Equal(got, BuiltInLengths) {
+		t.Errorf("List() on nil = %v, want the built-in lengths", got)
+	}
+}
+
+func TestLengthsValidateCustom(t *testing.T) {
+	lengths := NewLengths()
+	if _, err := lengths.Validate("tweet"); err == nil {
+		t.Error("Validate() should reject an undefined length")
+	}
+	if err := lengths.Define("", map[string]types.LengthParams{"tweet": {MaxTokens: 80}}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := lengths.Validate("tweet"); err != nil || got != "tweet" {
+		t.Errorf("Validate() = %v, %v, want tweet, nil", got, err)
+	}
+}
@@ -3,53 +3,235 @@ Copyright © 2026 Raypaste
 */
 package config
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
 
-// Model represents an LLM model configuration
+// Model represents an LLM model configuration. Its capabilities tell BuildRequest
+// how to shape requests for it. A capability left unset (nil) comes from the
+// model's family, such as GPT-5, when its ID is recognized, and is otherwise
+// unknown: the request is sent as asked and the provider decides.
 type Model struct {
 	ID       string `yaml:"id" mapstructure:"id"`
 	Provider string `yaml:"provider" mapstructure:"provider"`
 	Tier     string `yaml:"tier" mapstructure:"tier"`
+	// ContextWindow is the most tokens the model accepts per request, input and
+	// output together; 0 means unknown.
+	ContextWindow int `yaml:"context_window,omitempty" mapstructure:"context_window"`
+	// MaxOutputTokens is the most tokens the model can generate per response; 0 means unknown.
+	MaxOutputTokens int `yaml:"max_output_tokens,omitempty" mapstructure:"max_output_tokens"`
+	// StreamingUsage is set when the model reports token usage at the end of a
+	// stream if asked to with stream_options.
+	StreamingUsage *bool `yaml:"streaming_usage,omitempty" mapstructure:"streaming_usage"`
+	// Reasoning is set when the model reasons before answering and accepts a
+	// reasoning effort or token budget.
+	Reasoning *bool `yaml:"reasoning,omitempty" mapstructure:"reasoning"`
+	// StructuredOutputs is set when the model accepts a JSON Schema response_format.
+	StructuredOutputs *bool `yaml:"structured_outputs,omitempty" mapstructure:"structured_outputs"`
+	// Images is set when the model accepts images in messages.
+	Images *bool `yaml:"images,omitempty" mapstructure:"images"`
+	// UsesMaxCompletionTokens is set when the model takes its output budget as
+	// max_completion_tokens, which also covers its reasoning, instead of max_tokens.
+	UsesMaxCompletionTokens *bool `yaml:"uses_max_completion_tokens,omitempty" mapstructure:"uses_max_completion_tokens"`
+	// ReasoningEffort is sent as the reasoning effort for this model unless a prompt
+	// or flag sets one.
+	ReasoningEffort string `yaml:"reasoning_effort,omitempty" mapstructure:"reasoning_effort"`
+	// ReasoningMaxTokens caps the model's reasoning tokens when > 0, in place of
+	// ReasoningEffort.
+	ReasoningMaxTokens int `yaml:"reasoning_max_tokens,omitempty" mapstructure:"reasoning_max_tokens"`
+}
+
+// Bool returns a pointer to b, for setting a capability.
+func Bool(b bool) *bool {
+	return &b
+}
+
+// Enabled reports whether capability c is set and true.
+func Enabled(c *bool) bool {
+	return c != nil && *c
+}
+
+// Disabled reports whether capability c is set and false. An unset capability is
+// neither enabled nor disabled.
+func Disabled(c *bool) bool {
+	return c != nil && !*c
+}
+
+// Capabilities lists the names of the features the model supports.
+func (m Model) Capabilities() []string {
+	var names []string
+	for _, c := range []struct {
+		name string
+		ok   *bool
+	}{
+		{"streaming_usage", m.StreamingUsage},
+		{"reasoning", m.Reasoning},
+		{"structured_outputs", m.StructuredOutputs},
+		{"images", m.Images},
+	} {
+		if Enabled(c.ok) {
+			names = append(names, c.name)
+		}
+	}
+	return names
+}
+
+// withFamilyDefaults fills in what m leaves unset from the first model family
+// whose prefix its ID starts with.
+func (m Model) withFamilyDefaults() Model {
+	id := strings.ToLower(m.ID)
+	i := slices.IndexFunc(modelFamilies, func(f modelFamily) bool { return strings.HasPrefix(id, f.prefix) })
+	if i < 0 {
+		return m
+	}
+	family := modelFamilies[i].defaults
+
+	for _, c := range []struct{ field, fallback **bool }{
+		{&m.StreamingUsage, &family.StreamingUsage},
+		{&m.Reasoning, &family.Reasoning},
+		{&m.StructuredOutputs, &family.StructuredOutputs},
+		{&m.Images, &family.Images},
+		{&m.UsesMaxCompletionTokens, &family.UsesMaxCompletionTokens},
+	} {
+		if *c.field == nil {
+			*c.field = *c.fallback
+		}
+	}
+	if m.ContextWindow == 0 {
+		m.ContextWindow = family.ContextWindow
+	}
+	if m.MaxOutputTokens == 0 {
+		m.MaxOutputTokens = family.MaxOutputTokens
+	}
+	if m.ReasoningEffort == "" && m.ReasoningMaxTokens == 0 && !Disabled(m.Reasoning) {
+		m.ReasoningEffort, m.ReasoningMaxTokens = family.ReasoningEffort, family.ReasoningMaxTokens
+	}
+	return m
+}
+
+// modelFamily gives the capabilities of the models whose IDs start with prefix.
+type modelFamily struct {
+	prefix   string
+	defaults Model
+}
+
+// gpt5 are the capabilities of GPT-5 models.
+var gpt5 = Model{
+	ContextWindow:           400000,
+	MaxOutputTokens:         128000,
+	StreamingUsage:          Bool(true),
+	Reasoning:               Bool(true),
+	StructuredOutputs:       Bool(true),
+	Images:                  Bool(true),
+	UsesMaxCompletionTokens: Bool(true),
+	// GPT-5 reasoning counts against the output budget, so keep it short by
+	// default to avoid empty replies
+	ReasoningEffort: "minimal",
+}
+
+// openAIReasoning are the capabilities of OpenAI's o-series reasoning models.
+var openAIReasoning = Model{
+	StreamingUsage:          Bool(true),
+	Reasoning:               Bool(true),
+	StructuredOutputs:       Bool(true),
+	UsesMaxCompletionTokens: Bool(true),
+}
+
+// modelFamilies are the model families known by ID prefix, for models that don't
+// declare their capabilities. Prefixes are lowercase and the first match applies.
+// Capabilities a family leaves unset stay unknown.
+var modelFamilies = []modelFamily{
+	{"openai/gpt-5", gpt5},
+	{"gpt-5", gpt5},
+	{"openai/o1", openAIReasoning},
+	{"openai/o3", openAIReasoning},
+	{"openai/o4", openAIReasoning},
+	{"openai/gpt-oss", Model{ContextWindow: 131072, StreamingUsage: Bool(true), Reasoning: Bool(true), StructuredOutputs: Bool(true), Images: Bool(false)}},
+	{"openai/gpt-4", Model{StreamingUsage: Bool(true), Reasoning: Bool(false), StructuredOutputs: Bool(true)}},
+	{"anthropic/claude", Model{Images: Bool(true)}},
+	{"google/gemini", Model{Images: Bool(true), StructuredOutputs: Bool(true)}},
+	{"meta-llama/llama-3.1", Model{ContextWindow: 131072, Reasoning: Bool(false), Images: Bool(false)}},
+}
+
+// ReasoningEfforts lists the accepted reasoning effort values.
+var ReasoningEfforts = []string{"minimal", "low", "medium", "high"}
+
+// ValidateReasoning checks a reasoning effort and token budget; empty and 0 are unset.
+func ValidateReasoning(effort string, maxTokens int) error {
+	if effort != "" && !slices.Contains(ReasoningEfforts, effort) {
+		return fmt.Errorf("invalid reasoning effort '%s' (expected one of: %s)", effort, strings.Join(ReasoningEfforts, ", "))
+	}
+	if maxTokens < 0 {
+		return fmt.Errorf("reasoning max tokens must be 0 or more, got %d", maxTokens)
+	}
+	return nil
 }
 
 // DefaultModels contains the built-in model registry
 var DefaultModels = map[string]Model{
 	"cerebras-llama-8b": {
-		ID:       "meta-llama/llama-3.1-8b-instruct",
-		Provider: "cerebras",
-		Tier:     "fast",
+		ID:              "meta-llama/llama-3.1-8b-instruct",
+		Provider:        "cerebras",
+		Tier:            "fast",
+		ContextWindow:   131072,
+		MaxOutputTokens: 8192,
+		StreamingUsage:  Bool(true),
+		Reasoning:       Bool(false),
+		Images:          Bool(false),
 	},
 	"cerebras-gpt-oss-120b": {
-		ID:       "openai/gpt-oss-120b",
-		Provider: "cerebras",
-		Tier:     "balanced",
+		ID:                "openai/gpt-oss-120b",
+		Provider:          "cerebras",
+		Tier:              "balanced",
+		ContextWindow:     131072,
+		MaxOutputTokens:   32768,
+		StreamingUsage:    Bool(true),
+		Reasoning:         Bool(true),
+		StructuredOutputs: Bool(true),
+		Images:            Bool(false),
 	},
 	"openai-gpt5-nano": {
 		ID:       "openai/gpt-5-nano",
 		Provider: "openai",
 		Tier:     "fast",
+		// The rest comes from the GPT-5 family
 	},
 }
 
 // ResolveModel resolves a model alias to a Model struct
-// If the alias is not found in the registry, it treats it as a direct OpenRouter model ID
+// If the alias is not found in the registry, it treats it as a direct OpenRouter model ID,
+// with the capabilities of a registered model that has that ID, if any. Capabilities
+// the model doesn't set come from its family.
 func ResolveModel(alias string, customModels map[string]Model) (Model, error) {
 	// Check custom models first
 	if model, ok := customModels[alias]; ok {
-		return model, nil
+		return model.withFamilyDefaults(), nil
 	}
 
 	// Check default models
 	if model, ok := DefaultModels[alias]; ok {
-		return model, nil
+		return model.withFamilyDefaults(), nil
+	}
+
+	// A direct ID of a registered model has its capabilities
+	for _, registry := range []map[string]Model{customModels, DefaultModels} {
+		for _, name := range slices.Sorted(maps.Keys(registry)) {
+			if registry[name].ID == alias {
+				return registry[name].withFamilyDefaults(), nil
+			}
+		}
 	}
 
 	// If not found, treat as direct OpenRouter model ID
-	return Model{
+	model := Model{
 		ID:       alias,
 		Provider: "unknown",
 		Tier:     "unknown",
-	}, nil
+	}
+	return model.withFamilyDefaults(), nil
 }
 
 // ListModels returns a list of all available model aliases
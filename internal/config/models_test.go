@@ -4,6 +4,7 @@ Copyright © 2026 Raypaste
 package config
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -17,15 +18,18 @@ func TestResolveModel(t *testing.T) {
 	}
 
 	tests := []struct {
-		name    string
-		alias   string
-		want    string
-		wantErr bool
+		name          string
+		alias         string
+		want          string
+		wantReasoning *bool
+		wantErr       bool
 	}{
-		{"default model", "cerebras-llama-8b", "meta-llama/llama-3.1-8b-instruct", false},
-		{"default openai model", "openai-gpt5-nano", "openai/gpt-5-nano", false},
-		{"custom model", "custom", "custom/model", false},
-		{"direct ID", "provider/direct-model", "provider/direct-model", false},
+		{"default model", "cerebras-llama-8b", "meta-llama/llama-3.1-8b-instruct", Bool(false), false},
+		{"default openai model", "openai-gpt5-nano", "openai/gpt-5-nano", Bool(true), false},
+		{"custom model", "custom", "custom/model", nil, false},
+		{"direct ID of a default model", "openai/gpt-5-nano", "openai/gpt-5-nano", Bool(true), false},
+		{"direct ID of a custom model", "custom/model", "custom/model", nil, false},
+		{"direct ID", "provider/direct-model", "provider/direct-model", nil, false},
 	}
 
 	for _, tt := range tests {
@@ -38,8 +42,64 @@ func TestResolveModel(t *testing.T) {
 			if got.ID != tt.want {
 				t.Errorf("ResolveModel() ID = %v, want %v", got.ID, tt.want)
 			}
+			if !reflect.DeepEqual(got.Reasoning, tt.wantReasoning) {
+				t.Errorf("ResolveModel() Reasoning = %v, want %v", got.Reasoning, tt.wantReasoning)
+			}
+		})
+	}
+}
+
+func TestResolveModelFamilyDefaults(t *testing.T) {
+	customModels := map[string]Model{
+		"gpt5":          {ID: "openai/gpt-5"},
+		"gpt5-budget":   {ID: "openai/gpt-5-mini", ReasoningMaxTokens: 1000},
+		"gpt5-no-think": {ID: "openai/gpt-5", Reasoning: Bool(false)},
+		"gpt5-small":    {ID: "openai/gpt-5", MaxOutputTokens: 4000, Images: Bool(false)},
+	}
+
+	tests := []struct {
+		name                   string
+		alias                  string
+		wantMaxCompletion      bool
+		wantReasoning          *bool
+		wantImages             *bool
+		wantEffort             string
+		wantReasoningMaxTokens int
+		wantMaxOutputTokens    int
+	}{
+		{"direct ID", "openai/gpt-5-codex", true, Bool(true), Bool(true), "minimal", 0, 128000},
+		{"direct ID in another case", "OpenAI/GPT-5", true, Bool(true), Bool(true), "minimal", 0, 128000},
+		{"custom alias without capabilities", "gpt5", true, Bool(true), Bool(true), "minimal", 0, 128000},
+		{"custom budget replaces the family effort", "gpt5-budget", true, Bool(true), Bool(true), "", 1000, 128000},
+		{"custom capability replaces the family's", "gpt5-no-think", true, Bool(false), Bool(true), "", 0, 128000},
+		{"custom limits replace the family's", "gpt5-small", true, Bool(true), Bool(false), "minimal", 0, 4000},
+		{"unknown family", "provider/direct-model", false, nil, nil, "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveModel(tt.alias, customModels)
+			if err != nil {
+				t.Fatalf("ResolveModel() error = %v", err)
+			}
+			if Enabled(got.UsesMaxCompletionTokens) != tt.wantMaxCompletion {
+				t.Errorf("UsesMaxCompletionTokens = %v, want %v", got.UsesMaxCompletionTokens, tt.wantMaxCompletion)
+			}
+			if !reflect.DeepEqual(got.Reasoning, tt.wantReasoning) || !reflect.DeepEqual(got.Images, tt.wantImages) {
+				t.Errorf("Reasoning, Images = %v, %v, want %v, %v", got.Reasoning, got.Images, tt.wantReasoning, tt.wantImages)
+			}
+			if got.ReasoningEffort != tt.wantEffort || got.ReasoningMaxTokens != tt.wantReasoningMaxTokens {
+				t.Errorf("reasoning = %q, %d, want %q, %d", got.ReasoningEffort, got.ReasoningMaxTokens, tt.wantEffort, tt.wantReasoningMaxTokens)
+			}
+			if got.MaxOutputTokens != tt.wantMaxOutputTokens {
+				t.Errorf("MaxOutputTokens = %d, want %d", got.MaxOutputTokens, tt.wantMaxOutputTokens)
+			}
 		})
 	}
+
+	if _, ok := customModels["gpt5"]; !ok || customModels["gpt5"].Reasoning != nil {
+		t.Error("ResolveModel() modified the configured model")
+	}
 }
 
 func TestGetModelID(t *testing.T) {
@@ -103,3 +163,26 @@ func TestListModels(t *testing.T) {
 		t.Error("ListModels() should include custom model 'custom1'")
 	}
 }
+
+func TestValidateReasoning(t *testing.T) {
+	tests := []struct {
+		name      string
+		effort    string
+		maxTokens int
+		wantErr   bool
+	}{
+		{"unset", "", 0, false},
+		{"effort", "high", 0, false},
+		{"budget", "", 4000, false},
+		{"unknown effort", "extreme", 0, true},
+		{"negative budget", "", -1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateReasoning(tt.effort, tt.maxTokens); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateReasoning() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
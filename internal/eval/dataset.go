@@ -0,0 +1,67 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package eval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Dataset is the list of inputs both variants are run over.
+type Dataset struct {
+	Items []Item `yaml:"items"`
+}
+
+// Item is a single dataset input. Length and Vars are optional.
+type Item struct {
+	Input  string            `yaml:"input" json:"input"`
+	Length string            `yaml:"length,omitempty" json:"length,omitempty"`
+	Vars   map[string]string `yaml:"vars,omitempty" json:"vars,omitempty"`
+}
+
+// UnmarshalYAML accepts either a plain string or a mapping for an item.
+func (i *Item) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		i.Input = node.Value
+		return nil
+	}
+
+	type plain Item
+	return node.Decode((*plain)(i))
+}
+
+// LoadDataset reads and validates a dataset file; item lengths must be built in or
+// defined in lengths.
+func LoadDataset(path string, lengths *config.Lengths) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dataset: %w", err)
+	}
+
+	var dataset Dataset
+	if err := yaml.Unmarshal(data, &dataset); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset %s: %w", filepath.Base(path), err)
+	}
+
+	if len(dataset.Items) == 0 {
+		return nil, fmt.Errorf("dataset %s has no items", filepath.Base(path))
+	}
+	for i, item := range dataset.Items {
+		if strings.TrimSpace(item.Input) == "" {
+			return nil, fmt.Errorf("dataset items[%d]: input is required", i)
+		}
+		if item.Length != "" {
+			if _, err := lengths.Validate(item.Length); err != nil {
+				return nil, fmt.Errorf("dataset items[%d]: %w", i, err)
+			}
+		}
+	}
+
+	return &dataset, nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package eval
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// judgeMaxTokens caps the judge's reply, which is a short JSON object.
+const judgeMaxTokens = 500
+
+// Variant is one side of the comparison: a prompt and, optionally, the model to run
+// it on. Without a model, the prompt's model or the default model is used.
+type Variant struct {
+	Prompt string `json:"prompt"`
+	Model  string `json:"model,omitempty"`
+}
+
+// ParseVariant parses "prompt" or "prompt@model".
+func ParseVariant(s string) (Variant, error) {
+	prompt, model, _ := strings.Cut(s, "@")
+	if prompt == "" {
+		return Variant{}, fmt.Errorf("invalid variant %q: expected prompt or prompt@model", s)
+	}
+	return Variant{Prompt: prompt, Model: model}, nil
+}
+
+// String returns the variant in the form ParseVariant accepts.
+func (v Variant) String() string {
+	if v.Model == "" {
+		return v.Prompt
+	}
+	return v.Prompt + "@" + v.Model
+}
+
+// Options controls an evaluation.
+type Options struct {
+	// JudgeModel is the model alias or ID that scores each pair.
+	JudgeModel string
+	// Rubric is the judging criteria; DefaultRubric is used when empty.
+	Rubric string
+	// Length is used for items that don't set their own.
+	Length types.OutputLength
+}
+
+// ItemResult is the outcome of one dataset item.
+type ItemResult struct {
+	Item       Item             `json:"item"`
+	OutputA    string           `json:"output_a"`
+	OutputB    string           `json:"output_b"`
+	Verdict    *Verdict         `json:"verdict,omitempty"`
+	UsageA     types.TokenUsage `json:"usage_a"`
+	UsageB     types.TokenUsage `json:"usage_b"`
+	UsageJudge types.TokenUsage `json:"usage_judge"`
+	Err        string           `json:"error,omitempty"`
+}
+
+// RunItem runs item through both variants and asks the judge to compare the outputs.
+// index alternates the order the outputs are shown to the judge, so a judge that
+// favors the first (or second) response doesn't favor one variant.
+func RunItem(ctx context.Context, env *generate.Env, client generate.Completer, a, b Variant, item Item, index int, opts Options) ItemResult {
+	result := ItemResult{Item: item}
+	length := opts.Length
+	if item.Length != "" {
+		length = types.OutputLength(item.Length)
+	}
+
+	var err error
+	result.OutputA, result.UsageA, err = complete(ctx, env, client, a, item, length)
+	if err != nil {
+		result.Err = fmt.Sprintf("variant A: %v", err)
+		return result
+	}
+	result.OutputB, result.UsageB, err = complete(ctx, env, client, b, item, length)
+	if err != nil {
+		result.Err = fmt.Sprintf("variant B: %v", err)
+		return result
+	}
+
+	swapped := index%2 == 1
+	first, second := result.OutputA, result.OutputB
+	if swapped {
+		first, second = second, first
+	}
+
+	rubric := opts.Rubric
+	if strings.TrimSpace(rubric) == "" {
+		rubric = DefaultRubric
+	}
+	req, err := llm.BuildRequest(
+		opts.JudgeModel,
+		judgeSystemPrompt(rubric),
+		judgeUserPrompt(item.Input, first, second),
+		types.OutputLengthMedium,
+		0,
+		false,
+		env.Models,
+		env.Store.Lengths(),
+		types.RequestOptions{MaxTokens: judgeMaxTokens},
+	)
+	if err != nil {
+		result.Err = fmt.Sprintf("judge: %v", err)
+		return result
+	}
+
+	reply, usage, err := client.Complete(ctx, req)
+	result.UsageJudge = usage
+	if err != nil {
+		result.Err = fmt.Sprintf("judge: %v", err)
+		return result
+	}
+
+	verdict, err := parseVerdict(reply, swapped)
+	if err != nil {
+		result.Err = fmt.Sprintf("judge: %v", err)
+		return result
+	}
+	result.Verdict = &verdict
+	return result
+}
+
+// complete generates item with variant v the way raypaste would print it, running
+// pipelines step by step and cleaning up the output.
+func complete(ctx context.Context, env *generate.Env, client generate.Completer, v Variant, item Item, length types.OutputLength) (string, types.TokenUsage, error) {
+	result, err := env.Run(ctx, client, generate.Params{
+		Input:      item.Input,
+		PromptName: v.Prompt,
+		Model:      v.Model,
+		Length:     length,
+		Vars:       item.Vars,
+	}, generate.RunOptions{})
+	if err != nil {
+		return "", result.Usage, err
+	}
+	return result.Output, result.Usage, nil
+}
+
+// VariantSummary aggregates one variant's results.
+type VariantSummary struct {
+	Variant   Variant          `json:"variant"`
+	Wins      int              `json:"wins"`
+	WinRate   float64          `json:"win_rate"`
+	MeanScore float64          `json:"mean_score"`
+	Usage     types.TokenUsage `json:"usage"`
+}
+
+// Summary aggregates an evaluation. Win rates and mean scores are over judged items;
+// items that failed are counted in Errors only.
+type Summary struct {
+	A      VariantSummary   `json:"a"`
+	B      VariantSummary   `json:"b"`
+	Ties   int              `json:"ties"`
+	Judged int              `json:"judged"`
+	Errors int              `json:"errors"`
+	Judge  types.TokenUsage `json:"judge_usage"`
+}
+
+// Summarize aggregates the results of an evaluation of a against b.
+func Summarize(a, b Variant, results []ItemResult) Summary {
+	s := Summary{A: VariantSummary{Variant: a}, B: VariantSummary{Variant: b}}
+
+	var scoreA, scoreB float64
+	for _, r := range results {
+		addUsage(&s.A.Usage, r.UsageA)
+		addUsage(&s.B.Usage, r.UsageB)
+		addUsage(&s.Judge, r.UsageJudge)
+
+		if r.Verdict == nil {
+			s.Errors++
+			continue
+		}
+		s.Judged++
+		scoreA += r.Verdict.ScoreA
+		scoreB += r.Verdict.ScoreB
+		switch r.Verdict.Winner {
+		case WinnerA:
+			s.A.Wins++
+		case WinnerB:
+			s.B.Wins++
+		default:
+			s.Ties++
+		}
+	}
+
+	if s.Judged > 0 {
+		n := float64(s.Judged)
+		s.A.WinRate = float64(s.A.Wins) / n
+		s.B.WinRate = float64(s.B.Wins) / n
+		s.A.MeanScore = scoreA / n
+		s.B.MeanScore = scoreB / n
+	}
+	return s
+}
+
+func addUsage(total *types.TokenUsage, usage types.TokenUsage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
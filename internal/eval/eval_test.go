@@ -0,0 +1,186 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package eval
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestRunItem(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	a := Variant{Prompt: "metaprompt", Model: "model-a"}
+	b := Variant{Prompt: "bulletlist", Model: "model-b"}
+	item := Item{Input: "plan a launch", Length: "short"}
+	opts := Options{JudgeModel: "judge", Length: types.OutputLengthMedium}
+
+	tests := []struct {
+		name       string
+		index      int
+		judgeReply string
+		wantFirst  string
+		wantWinner Winner
+	}{
+		{"even item shows A first", 0, `{"winner": "A", "score_a": 9, "score_b": 3}`, "out-a", WinnerA},
+		{"odd item shows B first", 1, `{"winner": "A", "score_a": 9, "score_b": 3}`, "out-b", WinnerB},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &generatetest.Completer{Replies: []string{"out-a", "out-b", tt.judgeReply}}
+			result := RunItem(context.Background(), env, client, a, b, item, tt.index, opts)
+			if result.Err != "" {
+				t.Fatalf("RunItem() error = %s", result.Err)
+			}
+			if result.Verdict.Winner != tt.wantWinner {
+				t.Errorf("RunItem() winner = %s, want %s", result.Verdict.Winner, tt.wantWinner)
+			}
+
+			if len(client.Requests) != 3 {
+				t.Fatalf("RunItem() made %d requests, want 3", len(client.Requests))
+			}
+			if client.Requests[0].Model != "test/a" || client.Requests[1].Model != "test/b" || client.Requests[2].Model != "test/judge" {
+				t.Errorf("RunItem() models = %s, %s, %s", client.Requests[0].Model, client.Requests[1].Model, client.Requests[2].Model)
+			}
+			judgeInput := client.Requests[2].Messages[1].Content
+			if !strings.Contains(judgeInput, "RESPONSE A:\n"+tt.wantFirst) {
+				t.Errorf("judge input shows the wrong response first:\n%s", judgeInput)
+			}
+		})
+	}
+
+	client := &generatetest.Completer{Replies: []string{"out-a"}}
+	if result := RunItem(context.Background(), env, client, a, b, item, 0, opts); result.Verdict != nil || !strings.HasPrefix(result.Err, "variant B:") {
+		t.Errorf("RunItem() = %+v, want a variant B error", result)
+	}
+}
+
+func TestRunItemPipeline(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	store, err := env.Store.WithPrompts([]*prompts.Prompt{{
+		Name:  "plan",
+		Steps: []prompts.Step{{Prompt: "bulletlist"}, {Prompt: "metaprompt"}},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Store = store
+
+	client := &generatetest.Completer{Replies: []string{"outline", "final", "out-b", `{"winner": "A", "score_a": 9, "score_b": 3}`}}
+	a := Variant{Prompt: "plan", Model: "model-a"}
+	b := Variant{Prompt: "metaprompt", Model: "model-b"}
+	result := RunItem(context.Background(), env, client, a, b, Item{Input: "plan a launch"}, 0, Options{JudgeModel: "judge", Length: types.OutputLengthShort})
+	if result.Err != "" {
+		t.Fatalf("RunItem() error = %s", result.Err)
+	}
+	if result.OutputA != "final" || result.UsageA.TotalTokens != 2*generatetest.Usage.TotalTokens {
+		t.Errorf("RunItem() variant A = %q (%d tokens), want the last step's output from two requests", result.OutputA, result.UsageA.TotalTokens)
+	}
+	if len(client.Requests) != 4 || client.Requests[1].Messages[len(client.Requests[1].Messages)-1].Content != "outline" {
+		t.Errorf("RunItem() should feed the first step's output to the second")
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	usage := types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	results := []ItemResult{
+		{Verdict: &Verdict{Winner: WinnerA, ScoreA: 8, ScoreB: 4}, UsageA: usage, UsageB: usage, UsageJudge: usage},
+		{Verdict: &Verdict{Winner: WinnerA, ScoreA: 6, ScoreB: 5}, UsageA: usage, UsageB: usage, UsageJudge: usage},
+		{Verdict: &Verdict{Winner: WinnerB, ScoreA: 3, ScoreB: 9}, UsageA: usage, UsageB: usage, UsageJudge: usage},
+		{Verdict: &Verdict{Winner: WinnerTie, ScoreA: 7, ScoreB: 7}, UsageA: usage, UsageB: usage, UsageJudge: usage},
+		{Err: "judge: boom", UsageA: usage},
+	}
+
+	s := Summarize(Variant{Prompt: "a"}, Variant{Prompt: "b"}, results)
+	if s.Judged != 4 || s.Errors != 1 || s.Ties != 1 || s.A.Wins != 2 || s.B.Wins != 1 {
+		t.Errorf("Summarize() counts = %+v", s)
+	}
+	if s.A.WinRate != 0.5 || s.B.WinRate != 0.25 {
+		t.Errorf("Summarize() win rates = %v, %v, want 0.5, 0.25", s.A.WinRate, s.B.WinRate)
+	}
+	if s.A.MeanScore != 6 || s.B.MeanScore != 6.25 {
+		t.Errorf("Summarize() mean scores = %v, %v, want 6, 6.25", s.A.MeanScore, s.B.MeanScore)
+	}
+	if s.A.Usage.PromptTokens != 50 || s.B.Usage.PromptTokens != 40 || s.Judge.TotalTokens != 60 {
+		t.Errorf("Summarize() usage = %+v, %+v, %+v", s.A.Usage, s.B.Usage, s.Judge)
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Variant
+		wantErr bool
+	}{
+		{"metaprompt", Variant{Prompt: "metaprompt"}, false},
+		{"metaprompt@openai/gpt-5-nano", Variant{Prompt: "metaprompt", Model: "openai/gpt-5-nano"}, false},
+		{"@model", Variant{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseVariant(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseVariant() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseVariant() = %+v, want %+v", got, tt.want)
+			}
+			if !tt.wantErr && got.String() != tt.input {
+				t.Errorf("String() = %q, want %q", got.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestLoadDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []Item
+		wantErr bool
+	}{
+		{
+			name:    "strings and mappings",
+			content: "items:\n  - plain input\n  - input: detailed\n    length: short\n    vars: {k: v}\n",
+			want:    []Item{{Input: "plain input"}, {Input: "detailed", Length: "short", Vars: map[string]string{"k": "v"}}},
+		},
+		{name: "empty", content: "items: []\n", wantErr: true},
+		{name: "blank input", content: "items:\n  - input: \"  \"\n", wantErr: true},
+		{name: "unknown length", content: "items:\n  - input: x\n    length: huge\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dataset.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := LoadDataset(path, config.NewLengths())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDataset() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got.Items) != len(tt.want) {
+				t.Fatalf("LoadDataset() = %+v, want %+v", got.Items, tt.want)
+			}
+			for i := range tt.want {
+				if got.Items[i].Input != tt.want[i].Input || got.Items[i].Length != tt.want[i].Length || len(got.Items[i].Vars) != len(tt.want[i].Vars) {
+					t.Errorf("item %d = %+v, want %+v", i, got.Items[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
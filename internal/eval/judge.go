@@ -0,0 +1,96 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DefaultRubric is used when no rubric file is given. It suits prompts like
+// metaprompt whose output is itself a prompt.
+const DefaultRubric = `Judge which response better accomplishes the user's input. Consider, in order:
+1. Faithfulness: follows the user's request without inventing requirements or technologies.
+2. Usefulness: specific, actionable, and complete for the requested length.
+3. Format: no preamble, explanation, or wrapper text around the answer.
+4. Concision: no filler or repetition.`
+
+// judgeInstructions is appended to every rubric and fixes the verdict format.
+const judgeInstructions = `You are an impartial judge comparing two responses, A and B, to the same input.
+
+RUBRIC:
+%s
+
+Score each response from 1 (poor) to 10 (excellent) against the rubric, then pick the
+better one, or "tie" if neither is clearly better. The order the responses are shown in
+says nothing about their quality.
+
+Reply with ONLY a JSON object, no other text:
+{"winner": "A" | "B" | "tie", "score_a": <1-10>, "score_b": <1-10>, "reason": "<one sentence>"}`
+
+// Winner identifies the better variant in a verdict.
+type Winner string
+
+const (
+	WinnerA   Winner = "A"
+	WinnerB   Winner = "B"
+	WinnerTie Winner = "tie"
+)
+
+// Verdict is the judge's decision for one item, always expressed in terms of the
+// variants as given (A is the first variant), whatever order they were shown in.
+type Verdict struct {
+	Winner Winner  `json:"winner"`
+	ScoreA float64 `json:"score_a"`
+	ScoreB float64 `json:"score_b"`
+	Reason string  `json:"reason"`
+}
+
+// judgeSystemPrompt returns the judge's system prompt for rubric.
+func judgeSystemPrompt(rubric string) string {
+	return fmt.Sprintf(judgeInstructions, strings.TrimSpace(rubric))
+}
+
+// judgeUserPrompt presents the input and the two responses to the judge.
+func judgeUserPrompt(input, first, second string) string {
+	return fmt.Sprintf("INPUT:\n%s\n\nRESPONSE A:\n%s\n\nRESPONSE B:\n%s", input, first, second)
+}
+
+// parseVerdict extracts the verdict from the judge's reply. When swapped is true
+// the responses were shown in reverse order, and the verdict is mapped back.
+func parseVerdict(reply string, swapped bool) (Verdict, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return Verdict{}, fmt.Errorf("judge reply contains no JSON object: %q", reply)
+	}
+
+	var v Verdict
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &v); err != nil {
+		return Verdict{}, fmt.Errorf("failed to parse judge reply: %w", err)
+	}
+
+	switch strings.ToLower(string(v.Winner)) {
+	case "a":
+		v.Winner = WinnerA
+	case "b":
+		v.Winner = WinnerB
+	case "tie":
+		v.Winner = WinnerTie
+	default:
+		return Verdict{}, fmt.Errorf("judge picked an unknown winner %q", v.Winner)
+	}
+
+	if swapped {
+		v.ScoreA, v.ScoreB = v.ScoreB, v.ScoreA
+		switch v.Winner {
+		case WinnerA:
+			v.Winner = WinnerB
+		case WinnerB:
+			v.Winner = WinnerA
+		}
+	}
+	return v, nil
+}
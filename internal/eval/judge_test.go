@@ -0,0 +1,63 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package eval
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		swapped bool
+		want    Verdict
+		wantErr bool
+	}{
+		{
+			name:  "plain JSON",
+			reply: `{"winner": "A", "score_a": 8, "score_b": 5, "reason": "clearer"}`,
+			want:  Verdict{Winner: WinnerA, ScoreA: 8, ScoreB: 5, Reason: "clearer"},
+		},
+		{
+			name:  "JSON wrapped in prose and lowercase winner",
+			reply: "Verdict:\n```json\n{\"winner\": \"b\", \"score_a\": 4, \"score_b\": 9, \"reason\": \"r\"}\n```",
+			want:  Verdict{Winner: WinnerB, ScoreA: 4, ScoreB: 9, Reason: "r"},
+		},
+		{
+			name:    "swapped order maps back",
+			reply:   `{"winner": "A", "score_a": 8, "score_b": 5, "reason": "r"}`,
+			swapped: true,
+			want:    Verdict{Winner: WinnerB, ScoreA: 5, ScoreB: 8, Reason: "r"},
+		},
+		{
+			name:    "swapped tie stays a tie",
+			reply:   `{"winner": "tie", "score_a": 6, "score_b": 7}`,
+			swapped: true,
+			want:    Verdict{Winner: WinnerTie, ScoreA: 7, ScoreB: 6},
+		},
+		{"no JSON", "A is better", false, Verdict{}, true},
+		{"unknown winner", `{"winner": "C"}`, false, Verdict{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVerdict(tt.reply, tt.swapped)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVerdict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseVerdict() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJudgeSystemPrompt(t *testing.T) {
+	got := judgeSystemPrompt("  Prefer SQL over prose.\n")
+	if !strings.Contains(got, "RUBRIC:\nPrefer SQL over prose.\n") {
+		t.Errorf("judgeSystemPrompt() does not include the trimmed rubric:\n%s", got)
+	}
+}
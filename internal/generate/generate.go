@@ -0,0 +1,241 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package generate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/projectcontext"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// Completer is the subset of llm.Client used to run generations.
+// It exists so callers such as the HTTP server can be tested without network access.
+type Completer interface {
+	Complete(ctx context.Context, req types.CompletionRequest) (string, types.TokenUsage, error)
+	StreamComplete(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.TokenUsage, error)
+}
+
+// Params describes a single generation, independent of how it was invoked
+// (root command, REPL, HTTP server, ...).
+type Params struct {
+	Input      string
+	PromptName string
+	Model      string
+	Length     types.OutputLength
+	Stream     bool
+	Vars       map[string]string
+	// Attachments are images and files sent with the input. A pipeline sends them to
+	// its first step only.
+	Attachments []types.ContentPart
+}
+
+// Defaults holds the values used when a caller omits prompt, model or length.
+type Defaults struct {
+	PromptName string
+	Model      string
+	Length     types.OutputLength
+}
+
+// PromptInfo summarizes a prompt for listing by API-style entry points.
+type PromptInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	BuiltIn     bool               `json:"built_in"`
+	Origin      string             `json:"origin"`
+	Lengths     []string           `json:"lengths"`
+	Variables   []prompts.Variable `json:"variables,omitempty"`
+	Steps       []prompts.Step     `json:"steps,omitempty"`
+}
+
+// ModelInfo summarizes a model alias for listing by API-style entry points.
+type ModelInfo struct {
+	Alias    string `json:"alias"`
+	ID       string `json:"id"`
+	Provider string `json:"provider"`
+	Tier     string `json:"tier"`
+}
+
+// Env holds the shared dependencies needed to turn Params into a completion request.
+type Env struct {
+	Store       *prompts.Store
+	ProjCtx     projectcontext.Result
+	Temperature float64
+	Models      map[string]config.Model
+
+	// DefaultModel is used when neither Params.Model nor the prompt selects a model.
+	DefaultModel string
+	// TemperatureOverride, when set, wins over both the prompt's and the configured temperature.
+	TemperatureOverride *float64
+	// ReasoningEffort and ReasoningMaxTokens, when either is set (such as by flags),
+	// replace the reasoning settings of the prompt and the model.
+	ReasoningEffort    string
+	ReasoningMaxTokens int
+}
+
+// RenderSystemPrompt renders the system prompt for the given prompt, length and
+// variables using the environment's project context.
+func (e *Env) RenderSystemPrompt(promptName string, length types.OutputLength, vars map[string]string) (string, error) {
+	systemPrompt, err := e.Store.Render(promptName, length, e.ProjCtx.Content, vars)
+	if err != nil {
+		return "", fmt.Errorf("failed to render prompt: %w", err)
+	}
+	return systemPrompt, nil
+}
+
+// BuildRequest renders the prompt template and builds the completion request for p.
+func (e *Env) BuildRequest(p Params) (types.CompletionRequest, error) {
+	systemPrompt, err := e.RenderSystemPrompt(p.PromptName, p.Length, p.Vars)
+	if err != nil {
+		return types.CompletionRequest{}, err
+	}
+
+	prompt, err := e.Store.Get(p.PromptName)
+	if err != nil {
+		return types.CompletionRequest{}, err
+	}
+
+	opts := prompt.RequestOptions(p.Length)
+	if e.TemperatureOverride != nil {
+		opts.Temperature = e.TemperatureOverride
+	}
+	if e.ReasoningEffort != "" || e.ReasoningMaxTokens > 0 {
+		opts.ReasoningEffort, opts.ReasoningMaxTokens = e.ReasoningEffort, e.ReasoningMaxTokens
+	}
+	opts.Attachments = p.Attachments
+
+	req, err := llm.BuildRequest(
+		e.ResolveModel(p.PromptName, p.Model),
+		systemPrompt,
+		p.Input,
+		p.Length,
+		e.Temperature,
+		p.Stream,
+		e.Models,
+		e.Store.Lengths(),
+		opts,
+	)
+	if err != nil {
+		return types.CompletionRequest{}, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	return req, nil
+}
+
+// ResolveModel returns the model for a generation: model if non-empty (an explicit
+// choice such as the --model flag), otherwise the prompt's preferred model, otherwise
+// the environment's default.
+func (e *Env) ResolveModel(promptName, model string) string {
+	if model != "" {
+		return model
+	}
+	if prompt, err := e.Store.Get(promptName); err == nil && prompt.Model != "" {
+		return prompt.Model
+	}
+	return e.DefaultModel
+}
+
+// BuildChatRequest builds a completion request that prepends the rendered prompt
+// template as a system message to an existing conversation. Model, length and
+// prompt handling match BuildRequest.
+func (e *Env) BuildChatRequest(promptName, model string, length types.OutputLength, vars map[string]string, messages []types.Message, stream bool) (types.CompletionRequest, error) {
+	req, err := e.BuildRequest(Params{
+		PromptName: promptName,
+		Model:      model,
+		Length:     length,
+		Stream:     stream,
+		Vars:       vars,
+	})
+	if err != nil {
+		return types.CompletionRequest{}, err
+	}
+
+	// Keep the rendered system message and any few-shot examples, and replace the
+	// empty user turn with the conversation.
+	prefix := len(req.Messages) - 1
+	req.Messages = append(req.Messages[:prefix:prefix], messages...)
+	return req, nil
+}
+
+// ResolvePromptAndLength applies defaults to an optional prompt name and length
+// and validates both.
+func (e *Env) ResolvePromptAndLength(d Defaults, promptName, lengthName string) (string, types.OutputLength, error) {
+	if promptName == "" {
+		promptName = d.PromptName
+	}
+	if _, err := e.Store.Get(promptName); err != nil {
+		return "", "", err
+	}
+
+	length := d.Length
+	if lengthName != "" {
+		var err error
+		length, err = e.Store.Lengths().Validate(lengthName)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	return promptName, length, nil
+}
+
+// ListPrompts returns every prompt in the store, sorted by name.
+func (e *Env) ListPrompts() []PromptInfo {
+	names := e.Store.List()
+	sort.Strings(names)
+
+	infos := make([]PromptInfo, 0, len(names))
+	for _, name := range names {
+		prompt, err := e.Store.Get(name)
+		if err != nil {
+			continue
+		}
+
+		var lengths []string
+		for _, length := range e.Store.Lengths().List() {
+			if prompt.SupportsLength(length) {
+				lengths = append(lengths, string(length))
+			}
+		}
+
+		infos = append(infos, PromptInfo{
+			Name:        name,
+			Description: prompt.Description,
+			BuiltIn:     e.Store.IsBuiltIn(name),
+			Origin:      string(e.Store.Origin(name)),
+			Lengths:     lengths,
+			Variables:   prompt.Variables,
+			Steps:       prompt.Steps,
+		})
+	}
+
+	return infos
+}
+
+// ListModels returns every available model alias, sorted by alias.
+func (e *Env) ListModels() []ModelInfo {
+	aliases := config.ListModels(e.Models)
+	sort.Strings(aliases)
+
+	infos := make([]ModelInfo, 0, len(aliases))
+	for _, alias := range aliases {
+		model, err := config.ResolveModel(alias, e.Models)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, ModelInfo{
+			Alias:    alias,
+			ID:       model.ID,
+			Provider: model.Provider,
+			Tier:     model.Tier,
+		})
+	}
+
+	return infos
+}
@@ -0,0 +1,175 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// newTestEnv loads the built-in prompts plus the given user prompt files from a
+// temporary home directory, like generatetest.NewEnv, which this package's tests
+// can't import.
+func newTestEnv(t *testing.T, files map[string]string) *Env {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	promptsDir := filepath.Join(home, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := prompts.NewStore()
+	if err != nil {
+		t.Fatalf("prompts.NewStore() error = %v", err)
+	}
+	return &Env{
+		Store:        store,
+		Temperature:  0.7,
+		Models:       map[string]config.Model{},
+		DefaultModel: "cerebras-llama-8b",
+	}
+}
+
+const reviewPrompt = `name: review
+system: "Review the code. {{.LengthDirective}}"
+model: openai-gpt5-nano
+temperature: 0.2
+max_tokens:
+  short: 300
+stop: ["END"]
+reasoning_effort: low
+`
+
+func TestBuildRequestPromptParameters(t *testing.T) {
+	override := 1.1
+	tests := []struct {
+		name            string
+		prompt          string
+		model           string
+		tempOverride    *float64
+		length          types.OutputLength
+		wantModel       string
+		wantTemperature float64
+		wantMaxTokens   int
+		wantEffort      string
+		wantStop        int
+	}{
+		{"prompt defaults", "review", "", nil, types.OutputLengthShort, "openai/gpt-5-nano", 0.2, 300, "low", 1},
+		{"length without max_tokens uses length default", "review", "", nil, types.OutputLengthLong, "openai/gpt-5-nano", 0.2, 1600, "low", 1},
+		{"flags override prompt", "review", "cerebras-gpt-oss-120b", &override, types.OutputLengthShort, "openai/gpt-oss-120b", 1.1, 300, "low", 1},
+		{"prompt without parameters", "metaprompt", "", nil, types.OutputLengthShort, "meta-llama/llama-3.1-8b-instruct", 0.7, 550, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv(t, map[string]string{"review.yaml": reviewPrompt})
+			env.TemperatureOverride = tt.tempOverride
+
+			req, err := env.BuildRequest(Params{Input: "x", PromptName: tt.prompt, Model: tt.model, Length: tt.length})
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			if req.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", req.Model, tt.wantModel)
+			}
+			if req.Temperature != tt.wantTemperature {
+				t.Errorf("Temperature = %v, want %v", req.Temperature, tt.wantTemperature)
+			}
+			if got := req.MaxTokens + req.MaxCompletionTokens; got != tt.wantMaxTokens {
+				t.Errorf("max tokens = %d, want %d", got, tt.wantMaxTokens)
+			}
+			if req.ReasoningEffort != tt.wantEffort {
+				t.Errorf("ReasoningEffort = %q, want %q", req.ReasoningEffort, tt.wantEffort)
+			}
+			if len(req.Stop) != tt.wantStop {
+				t.Errorf("Stop = %v, want %d entries", req.Stop, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestBuildRequestReasoningOverride(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"review.yaml": reviewPrompt})
+	env.ReasoningMaxTokens = 1200
+
+	req, err := env.BuildRequest(Params{Input: "x", PromptName: "review", Length: types.OutputLengthShort})
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	if req.ReasoningEffort != "" || req.Reasoning == nil || req.Reasoning.MaxTokens != 1200 {
+		t.Errorf("reasoning = %q, %+v, want the flag's budget in place of the prompt's effort", req.ReasoningEffort, req.Reasoning)
+	}
+}
+
+func TestBuildRequestPromptReasoningBudget(t *testing.T) {
+	env := newTestEnv(t, map[string]string{
+		"deep.yaml":   "name: deep\nsystem: \"Think it through.\"\nmodel: openai-gpt5-nano\nreasoning_max_tokens: 2000\n",
+		"deeper.yaml": "name: deeper\nextends: deep\nsystem: \"Think harder.\"\n",
+	})
+
+	for _, name := range []string{"deep", "deeper"} {
+		req, err := env.BuildRequest(Params{Input: "x", PromptName: name, Length: types.OutputLengthShort})
+		if err != nil {
+			t.Fatalf("BuildRequest(%s) error = %v", name, err)
+		}
+		if req.ReasoningEffort != "" || req.Reasoning == nil || req.Reasoning.MaxTokens != 2000 {
+			t.Errorf("%s reasoning = %q, %+v, want the prompt's budget", name, req.ReasoningEffort, req.Reasoning)
+		}
+		if req.MaxCompletionTokens != 550+2000 {
+			t.Errorf("%s max_completion_tokens = %d, want the length's budget plus the reasoning budget", name, req.MaxCompletionTokens)
+		}
+	}
+}
+
+func TestResolveModel(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"review.yaml": reviewPrompt})
+	tests := []struct {
+		prompt string
+		model  string
+		want   string
+	}{
+		{"review", "", "openai-gpt5-nano"},
+		{"review", "explicit", "explicit"},
+		{"metaprompt", "", "cerebras-llama-8b"},
+	}
+	for _, tt := range tests {
+		if got := env.ResolveModel(tt.prompt, tt.model); got != tt.want {
+			t.Errorf("ResolveModel(%q, %q) = %q, want %q", tt.prompt, tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestBuildChatRequestKeepsExamples(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"tagger.yaml": `name: tagger
+system: "Tag the text."
+examples:
+  - input: "apples and pears"
+    output: "fruit"
+`})
+
+	req, err := env.BuildChatRequest("tagger", "", types.OutputLengthMedium, nil, []types.Message{{Role: "user", Content: "carrots"}}, false)
+	if err != nil {
+		t.Fatalf("BuildChatRequest() error = %v", err)
+	}
+
+	roles := ""
+	for _, msg := range req.Messages {
+		roles += msg.Role[:1]
+	}
+	if roles != "suau" || req.Messages[3].Content != "carrots" {
+		t.Errorf("messages = %+v, want system, example pair, then the conversation", req.Messages)
+	}
+}
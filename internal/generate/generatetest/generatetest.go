@@ -0,0 +1,145 @@
+/*
+Copyright © 2026 Raypaste
+*/
+
+// Package generatetest provides a fake model client and a generation environment for
+// the tests of packages built on generate.
+package generatetest
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// Usage is the token usage Completer reports for every request.
+var Usage = types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+
+// Completer is a fake generate.Completer that records every request. Each request
+// is answered with the next of Replies; once they run out, with Reply, or an error
+// if Reply is empty too. Streamed replies are sent a word at a time. It is safe for
+// concurrent use, though fields must not be changed while requests are running.
+type Completer struct {
+	mu sync.Mutex
+
+	Replies []string
+	Reply   string
+	// Err, when set, fails every request.
+	Err error
+
+	Requests []types.CompletionRequest
+}
+
+// Complete records req and returns the next reply.
+func (c *Completer) Complete(_ context.Context, req types.CompletionRequest) (string, types.TokenUsage, error) {
+	reply, err := c.next(req)
+	if err != nil {
+		return "", types.TokenUsage{}, err
+	}
+	return reply, Usage, nil
+}
+
+// StreamComplete records req and passes the next reply to callback a word at a
+// time.
+func (c *Completer) StreamComplete(_ context.Context, req types.CompletionRequest, callback func(string) error) (types.TokenUsage, error) {
+	reply, err := c.next(req)
+	if err != nil {
+		return types.TokenUsage{}, err
+	}
+	for _, token := range words(reply) {
+		if err := callback(token); err != nil {
+			return types.TokenUsage{}, err
+		}
+	}
+	return Usage, nil
+}
+
+// LastRequest returns the most recent request, or a zero request if none was made.
+func (c *Completer) LastRequest() types.CompletionRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.Requests) == 0 {
+		return types.CompletionRequest{}
+	}
+	return c.Requests[len(c.Requests)-1]
+}
+
+func (c *Completer) next(req types.CompletionRequest) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Requests = append(c.Requests, req)
+	if c.Err != nil {
+		return "", c.Err
+	}
+	if len(c.Replies) > 0 {
+		reply := c.Replies[0]
+		c.Replies = c.Replies[1:]
+		return reply, nil
+	}
+	if c.Reply == "" {
+		return "", errors.New("no reply scripted")
+	}
+	return c.Reply, nil
+}
+
+// words splits text before each space, so the pieces join back into text.
+func words(text string) []string {
+	var tokens []string
+	for len(text) > 0 {
+		end := strings.IndexByte(text[1:], ' ') + 1
+		if end == 0 {
+			end = len(text)
+		}
+		tokens = append(tokens, text[:end])
+		text = text[end:]
+	}
+	return tokens
+}
+
+// Models are the model aliases NewEnv registers, alongside the built-in ones.
+var Models = map[string]config.Model{
+	"writer":  {ID: "test/writer"},
+	"critic":  {ID: "test/critic"},
+	"judge":   {ID: "test/judge"},
+	"model-a": {ID: "test/a"},
+	"model-b": {ID: "test/b"},
+}
+
+// NewEnv returns an environment with the built-in prompts plus the given user prompt
+// files, loaded from a temporary home directory, and Models, defaulting to "writer".
+func NewEnv(t testing.TB, files map[string]string) *generate.Env {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	promptsDir := filepath.Join(home, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := prompts.NewStore()
+	if err != nil {
+		t.Fatalf("prompts.NewStore() error = %v", err)
+	}
+	return &generate.Env{
+		Store:        store,
+		Temperature:  0.7,
+		Models:       maps.Clone(Models),
+		DefaultModel: "writer",
+	}
+}
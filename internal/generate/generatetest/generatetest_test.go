@@ -0,0 +1,40 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package generatetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestCompleter(t *testing.T) {
+	ctx := context.Background()
+	client := &Completer{Replies: []string{"first"}, Reply: "Hello big world"}
+
+	if reply, _, err := client.Complete(ctx, types.CompletionRequest{Model: "a"}); err != nil || reply != "first" {
+		t.Errorf("first Complete() = %q, %v, want the scripted reply", reply, err)
+	}
+	var tokens []string
+	usage, err := client.StreamComplete(ctx, types.CompletionRequest{Model: "b"}, func(token string) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	if err != nil || usage != Usage {
+		t.Fatalf("StreamComplete() = %+v, %v", usage, err)
+	}
+	if want := []string{"Hello", " big", " world"}; !reflect.DeepEqual(tokens, want) {
+		t.Errorf("streamed tokens = %q, want %q", tokens, want)
+	}
+	if len(client.Requests) != 2 || client.LastRequest().Model != "b" {
+		t.Errorf("Requests = %+v, want both requests recorded", client.Requests)
+	}
+
+	client.Reply = ""
+	if _, _, err := client.Complete(ctx, types.CompletionRequest{}); err == nil {
+		t.Error("Complete() should fail once there is nothing to reply")
+	}
+}
@@ -0,0 +1,349 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package generate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/internal/projectcontext"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/schema"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// StepResult is the output of one step of a generation.
+type StepResult struct {
+	Prompt string           `json:"prompt"`
+	Model  string           `json:"model"`
+	Length string           `json:"length"`
+	Output string           `json:"output"`
+	Usage  types.TokenUsage `json:"usage"`
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	// Output is the final step's output.
+	Output string
+	// Model is the model the final step ran on.
+	Model string
+	// Usage is the total token usage of every step.
+	Usage types.TokenUsage
+	// Steps holds every step of a pipeline prompt, including the final one. It is
+	// nil for a single prompt.
+	Steps []StepResult
+	// Structured is set when the final prompt declares a schema; Output is then JSON
+	// that matched it, indented for display.
+	Structured bool
+}
+
+// RunOptions controls how Run reports progress.
+type RunOptions struct {
+	// OnToken, when set, receives the final step's output as it streams. Earlier
+	// steps always complete in full before the next one starts. Output that must
+	// match a schema is validated first and passed as a single token.
+	OnToken func(string) error
+	// OnReasoning, when set with OnToken, receives the reasoning tokens a reasoning
+	// model streams before the final step's output, if client is an
+	// llm.ReasoningStreamer. Reasoning is never part of Result.Output.
+	OnReasoning func(string) error
+	// OnStep, when set, is called with each intermediate step's result before the
+	// next step starts.
+	OnStep func(StepResult)
+	// OnToolCall, when set, is called after each tool a prompt's model calls has run,
+	// with the result sent back to the model.
+	OnToolCall func(call types.ToolCall, result string)
+}
+
+// Validate builds the request for every step of p without calling a model, so a
+// pipeline whose later step can't render fails before any tokens are spent.
+func (e *Env) Validate(p Params) error {
+	steps, err := e.steps(p.PromptName)
+	if err != nil {
+		return err
+	}
+	for i, step := range steps {
+		stepParams, err := e.stepParams(p, step, p.Input)
+		if err != nil {
+			return stepError(p.PromptName, i, step, err)
+		}
+		if _, err := e.BuildRequest(stepParams); err != nil {
+			return stepError(p.PromptName, i, step, err)
+		}
+	}
+	return nil
+}
+
+// Run generates p with client. A pipeline prompt (one with steps) runs each step in
+// turn, feeding each step's output to the next as its input; any other prompt is a
+// single completion. Each step's output is cleaned up by its prompt's postprocess
+// rules, and the final output by the pipeline's too. While streaming, only a leading
+// preamble is stripped from the tokens passed to OnToken; Result.Output has every
+// rule applied. A step whose prompt lists tools runs them for the model until it
+// answers; client must then also be an llm.MessageCompleter. A step whose prompt
+// declares a schema doesn't stream; its reply is validated and, if it doesn't
+// match, retried once with the validation error.
+func (e *Env) Run(ctx context.Context, client Completer, p Params, opts RunOptions) (Result, error) {
+	if err := e.Validate(p); err != nil {
+		return Result{}, err
+	}
+	steps, err := e.steps(p.PromptName)
+	if err != nil {
+		return Result{}, err
+	}
+	pipeline, err := e.Store.Get(p.PromptName)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	input := p.Input
+	for i, step := range steps {
+		final := i == len(steps)-1
+		stepParams, err := e.stepParams(p, step, input)
+		if err != nil {
+			return Result{}, stepError(p.PromptName, i, step, err)
+		}
+		stepPrompt, err := e.Store.Get(step.Prompt)
+		if err != nil {
+			return Result{}, stepError(p.PromptName, i, step, err)
+		}
+		stepParams.Stream = final && opts.OnToken != nil && stepPrompt.Schema == nil
+		if i == 0 {
+			stepParams.Attachments = p.Attachments
+		}
+
+		req, err := e.BuildRequest(stepParams)
+		if err != nil {
+			return Result{}, stepError(p.PromptName, i, step, err)
+		}
+
+		var output string
+		var usage types.TokenUsage
+		var onToken, onReasoning func(string) error
+		var filter *postprocess.Stream
+		if stepParams.Stream {
+			filter = postprocess.NewStream(stepPrompt.PostProcess, pipeline.PostProcess)
+			onToken = func(token string) error {
+				if text := filter.Process(token); text != "" {
+					return opts.OnToken(text)
+				}
+				return nil
+			}
+			onReasoning = opts.OnReasoning
+		}
+		reasoningClient, streamsReasoning := client.(llm.ReasoningStreamer)
+		switch {
+		case len(stepPrompt.Tools) > 0:
+			output, usage, err = e.runTools(ctx, client, req, stepPrompt.Tools, onToken, onReasoning, opts.OnToolCall)
+		case onToken != nil && onReasoning != nil && streamsReasoning:
+			var message types.Message
+			message, usage, err = reasoningClient.StreamCompleteReasoning(ctx, req, onToken, onReasoning)
+			output = message.Content
+		case onToken != nil:
+			var buf strings.Builder
+			usage, err = client.StreamComplete(ctx, req, func(token string) error {
+				buf.WriteString(token)
+				return onToken(token)
+			})
+			output = buf.String()
+		default:
+			output, usage, err = client.Complete(ctx, req)
+		}
+		if filter != nil {
+			if text := filter.Flush(); err == nil && text != "" {
+				err = opts.OnToken(text)
+			}
+		}
+		if err != nil {
+			return Result{}, stepError(p.PromptName, i, step, err)
+		}
+		clean := func(output string) string {
+			output = stepPrompt.PostProcess.Apply(output)
+			if final && stepPrompt != pipeline {
+				output = pipeline.PostProcess.Apply(output)
+			}
+			return output
+		}
+		output = clean(output)
+
+		if stepPrompt.Schema != nil {
+			var retryUsage types.TokenUsage
+			output, retryUsage, err = enforceSchema(ctx, client, req, stepPrompt.Schema, output, clean)
+			addUsage(&usage, retryUsage)
+			if err != nil {
+				return Result{}, stepError(p.PromptName, i, step, err)
+			}
+			if final && opts.OnToken != nil {
+				if err := opts.OnToken(output); err != nil {
+					return Result{}, err
+				}
+			}
+		}
+
+		stepResult := StepResult{
+			Prompt: step.Prompt,
+			Model:  stepParams.Model,
+			Length: string(stepParams.Length),
+			Output: output,
+			Usage:  usage,
+		}
+		addUsage(&result.Usage, usage)
+		result.Output = output
+		result.Model = stepParams.Model
+		result.Structured = stepPrompt.Schema != nil
+		if step.Prompt != p.PromptName {
+			result.Steps = append(result.Steps, stepResult)
+		}
+		if !final && opts.OnStep != nil {
+			opts.OnStep(stepResult)
+		}
+		input = output
+	}
+
+	return result, nil
+}
+
+// Complete sends req, built for the named prompt, and returns the reply before any
+// postprocess rules. If the prompt lists tools, they run for the model until it
+// answers, as in Run.
+func (e *Env) Complete(ctx context.Context, client Completer, promptName string, req types.CompletionRequest) (string, types.TokenUsage, error) {
+	prompt, err := e.Store.Get(promptName)
+	if err != nil {
+		return "", types.TokenUsage{}, err
+	}
+	if len(prompt.Tools) > 0 {
+		return e.runTools(ctx, client, req, prompt.Tools, nil, nil, nil)
+	}
+	return client.Complete(ctx, req)
+}
+
+// runTools sends req with the named project tools and runs the calls the model makes
+// until it answers. Tools only run inside a project (a git repository), so they
+// can't read whatever directory raypaste happens to run in.
+func (e *Env) runTools(ctx context.Context, client Completer, req types.CompletionRequest, names []string, onToken, onReasoning func(string) error, onToolCall func(types.ToolCall, string)) (string, types.TokenUsage, error) {
+	toolClient, ok := client.(llm.MessageCompleter)
+	if !ok {
+		return "", types.TokenUsage{}, fmt.Errorf("the prompt uses tools, which this client can't call")
+	}
+	root := e.Store.ProjectRoot()
+	if !projectcontext.IsRoot(root) {
+		return "", types.TokenUsage{}, fmt.Errorf("the prompt uses tools, which only run inside a git repository; %s isn't in one", root)
+	}
+	tools, err := llm.ProjectTools(root, names)
+	if err != nil {
+		return "", types.TokenUsage{}, err
+	}
+	return llm.RunTools(ctx, toolClient, req, llm.NewToolbox(tools...), llm.ToolLoopOptions{
+		OnToken:     onToken,
+		OnReasoning: onReasoning,
+		OnToolCall:  onToolCall,
+	})
+}
+
+// Structured reports whether the named prompt's output must match a schema: its own,
+// or for a pipeline, that of the final step's prompt.
+func (e *Env) Structured(promptName string) bool {
+	steps, err := e.steps(promptName)
+	if err != nil {
+		return false
+	}
+	prompt, err := e.Store.Get(steps[len(steps)-1].Prompt)
+	return err == nil && prompt.Schema != nil
+}
+
+// enforceSchema validates output against s. If it doesn't match, req is retried once
+// with the model's reply and the validation error added to the conversation. clean
+// is applied to the retried reply, as it was to output. It returns the matching
+// output indented for display and the retry's usage.
+func enforceSchema(ctx context.Context, client Completer, req types.CompletionRequest, s *schema.Schema, output string, clean func(string) string) (string, types.TokenUsage, error) {
+	pretty, err := s.Validate(output)
+	if err == nil {
+		return pretty, types.TokenUsage{}, nil
+	}
+
+	req.Stream = false
+	req.Messages = append(req.Messages[:len(req.Messages):len(req.Messages)],
+		types.Message{Role: "assistant", Content: output},
+		types.Message{Role: "user", Content: fmt.Sprintf(schemaRetryInstructions, err)},
+	)
+	retry, usage, err := client.Complete(ctx, req)
+	if err != nil {
+		return "", usage, fmt.Errorf("schema retry: %w", err)
+	}
+	pretty, err = s.Validate(clean(retry))
+	if err != nil {
+		return "", usage, fmt.Errorf("after one retry, %w", err)
+	}
+	return pretty, usage, nil
+}
+
+// schemaRetryInstructions asks the model to fix a reply that failed validation.
+const schemaRetryInstructions = `Your response was rejected: %v
+
+Reply with ONLY the corrected JSON, matching the required JSON Schema.`
+
+func addUsage(total *types.TokenUsage, usage types.TokenUsage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
+
+// steps returns the steps of the named prompt; a single prompt is one step.
+func (e *Env) steps(promptName string) ([]prompts.Step, error) {
+	steps, err := e.Store.Steps(promptName)
+	if err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		steps = []prompts.Step{{Prompt: promptName}}
+	}
+	return steps, nil
+}
+
+// stepParams returns the parameters for running step of pipeline p on input. The
+// step's model is, in order: the model p selects explicitly (such as --model), the
+// step's model, the step prompt's model, then the pipeline prompt's model or the
+// environment's default.
+func (e *Env) stepParams(p Params, step prompts.Step, input string) (Params, error) {
+	model := p.Model
+	if model == "" {
+		model = step.Model
+	}
+	if model == "" {
+		if prompt, err := e.Store.Get(step.Prompt); err == nil {
+			model = prompt.Model
+		}
+	}
+	if model == "" {
+		model = e.ResolveModel(p.PromptName, "")
+	}
+
+	length := p.Length
+	if step.Length != "" {
+		var err error
+		if length, err = e.Store.Lengths().Validate(step.Length); err != nil {
+			return Params{}, err
+		}
+	}
+
+	return Params{
+		Input:      input,
+		PromptName: step.Prompt,
+		Model:      model,
+		Length:     length,
+		Vars:       p.Vars,
+	}, nil
+}
+
+// stepError adds the step to err when the prompt is a pipeline.
+func stepError(pipeline string, i int, step prompts.Step, err error) error {
+	if step.Prompt == pipeline {
+		return err
+	}
+	return fmt.Errorf("pipeline '%s' step %d (%s): %w", pipeline, i+1, step.Prompt, err)
+}
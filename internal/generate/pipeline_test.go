@@ -0,0 +1,297 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package generate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// recordingCompleter answers each request with "out<n>", or the nth of replies when
+// set, and records the requests.
+type recordingCompleter struct {
+	reqs     []types.CompletionRequest
+	streamed []bool
+	replies  []string
+}
+
+func (c *recordingCompleter) Complete(_ context.Context, req types.CompletionRequest) (string, types.TokenUsage, error) {
+	c.reqs = append(c.reqs, req)
+	c.streamed = append(c.streamed, false)
+	reply := fmt.Sprintf("out%d", len(c.reqs))
+	if len(c.replies) >= len(c.reqs) {
+		reply = c.replies[len(c.reqs)-1]
+	}
+	return reply, types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, nil
+}
+
+func (c *recordingCompleter) StreamComplete(_ context.Context, req types.CompletionRequest, callback func(string) error) (types.TokenUsage, error) {
+	c.reqs = append(c.reqs, req)
+	c.streamed = append(c.streamed, true)
+	for _, token := range []string{"out", fmt.Sprint(len(c.reqs))} {
+		if err := callback(token); err != nil {
+			return types.TokenUsage{}, err
+		}
+	}
+	return types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, nil
+}
+
+const refinePipeline = `name: refine
+steps:
+  - prompt: bulletlist
+    length: short
+  - prompt: metaprompt
+    model: openai-gpt5-nano
+  - prompt: critique
+`
+
+const critiquePrompt = `name: critique
+system: "Critique and improve the prompt. {{.Vars.focus}}"
+variables:
+  - name: focus
+    required: true
+`
+
+func TestRunPipeline(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"refine.yaml": refinePipeline, "critique.yaml": critiquePrompt})
+	client := &recordingCompleter{}
+
+	var steps []StepResult
+	var streamed strings.Builder
+	notes := types.ContentPart{Type: "text", Text: "<attachment name=\"notes.md\">\n# Notes\n</attachment>"}
+	result, err := env.Run(context.Background(), client, Params{
+		Input:       "plan a launch",
+		PromptName:  "refine",
+		Length:      types.OutputLengthMedium,
+		Vars:        map[string]string{"focus": "clarity"},
+		Attachments: []types.ContentPart{notes},
+	}, RunOptions{
+		OnToken: func(token string) error {
+			streamed.WriteString(token)
+			return nil
+		},
+		OnStep: func(step StepResult) { steps = append(steps, step) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if len(client.reqs) != 3 {
+		t.Fatalf("sent %d requests, want 3", len(client.reqs))
+	}
+	for i, wantInput := range []string{"plan a launch", "out1", "out2"} {
+		msgs := client.reqs[i].Messages
+		if got := msgs[len(msgs)-1].Content; got != wantInput {
+			t.Errorf("step %d input = %q, want %q", i+1, got, wantInput)
+		}
+		wantParts := 0
+		if i == 0 {
+			wantParts = 1
+		}
+		if got := len(msgs[len(msgs)-1].Parts); got != wantParts {
+			t.Errorf("step %d sent %d attachments, want them with the first step only", i+1, got)
+		}
+		if client.streamed[i] != (i == 2) {
+			t.Errorf("step %d streamed = %v, want only the final step streamed", i+1, client.streamed[i])
+		}
+	}
+	if got, want := client.reqs[1].Model, config.DefaultModels["openai-gpt5-nano"].ID; got != want {
+		t.Errorf("step 2 model = %q, want the step's model %q", got, want)
+	}
+	if got, want := client.reqs[2].Model, config.DefaultModels["cerebras-llama-8b"].ID; got != want {
+		t.Errorf("step 3 model = %q, want the default %q", got, want)
+	}
+	if !strings.Contains(client.reqs[2].Messages[0].Content, "clarity") {
+		t.Error("variables should be passed to every step")
+	}
+
+	if len(steps) != 2 || steps[0].Prompt != "bulletlist" || steps[0].Length != "short" || steps[1].Output != "out2" {
+		t.Errorf("OnStep got %+v, want the two intermediate steps", steps)
+	}
+	if result.Output != "out3" || streamed.String() != "out3" {
+		t.Errorf("Output = %q, streamed %q, want out3", result.Output, streamed.String())
+	}
+	if len(result.Steps) != 3 || result.Usage.TotalTokens != 45 {
+		t.Errorf("Steps = %d, Usage = %+v, want 3 steps and summed usage", len(result.Steps), result.Usage)
+	}
+}
+
+func TestRunSinglePrompt(t *testing.T) {
+	env := newTestEnv(t, nil)
+	client := &recordingCompleter{}
+
+	result, err := env.Run(context.Background(), client, Params{Input: "x", PromptName: "metaprompt", Length: types.OutputLengthShort}, RunOptions{})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(client.reqs) != 1 || client.streamed[0] {
+		t.Errorf("sent %d requests (streamed %v), want one blocking request", len(client.reqs), client.streamed)
+	}
+	if result.Output != "out1" || result.Steps != nil || result.Model != "cerebras-llama-8b" {
+		t.Errorf("Run() = %+v", result)
+	}
+}
+
+func TestRunValidatesEveryStepFirst(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"refine.yaml": refinePipeline, "critique.yaml": critiquePrompt})
+	client := &recordingCompleter{}
+
+	// The final step requires a variable; nothing should be sent without it
+	_, err := env.Run(context.Background(), client, Params{Input: "x", PromptName: "refine", Length: types.OutputLengthMedium}, RunOptions{})
+	if err == nil || !strings.Contains(err.Error(), "step 3") {
+		t.Errorf("Run() error = %v, want an error naming step 3", err)
+	}
+	if len(client.reqs) != 0 {
+		t.Errorf("sent %d requests before failing, want 0", len(client.reqs))
+	}
+}
+
+const tagsPrompt = `name: tags
+system: Extract tags.
+schema:
+  type: object
+  required: [tags]
+  properties:
+    tags:
+      type: array
+      items: {type: string}
+`
+
+func TestRunSchema(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"tags.yaml": tagsPrompt})
+	pretty := "{\n  \"tags\": [\n    \"go\"\n  ]\n}"
+
+	tests := []struct {
+		name         string
+		replies      []string
+		wantRequests int
+		wantErr      string
+	}{
+		{"valid reply", []string{`{"tags":["go"]}`}, 1, ""},
+		{"retried once", []string{`{"labels":["go"]}`, `{"tags":["go"]}`}, 2, ""},
+		{"still invalid after retry", []string{`{}`, `not json`}, 2, "after one retry, response is not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &recordingCompleter{replies: tt.replies}
+			var tokens []string
+			result, err := env.Run(context.Background(), client, Params{Input: "a go cli", PromptName: "tags", Length: types.OutputLengthMedium}, RunOptions{
+				OnToken: func(token string) error {
+					tokens = append(tokens, token)
+					return nil
+				},
+			})
+			if len(client.reqs) != tt.wantRequests {
+				t.Fatalf("sent %d requests, want %d", len(client.reqs), tt.wantRequests)
+			}
+			if slices.Contains(client.streamed, true) {
+				t.Error("a prompt with a schema should not stream")
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Run() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if !result.Structured || result.Output != pretty || len(tokens) != 1 || tokens[0] != pretty {
+				t.Errorf("Run() = %+v with tokens %q, want the indented JSON as one token", result, tokens)
+			}
+			if result.Usage.TotalTokens != 15*tt.wantRequests {
+				t.Errorf("Usage.TotalTokens = %d, want %d", result.Usage.TotalTokens, 15*tt.wantRequests)
+			}
+			if tt.wantRequests == 2 {
+				retry := client.reqs[1].Messages
+				if retry[len(retry)-2].Content != tt.replies[0] || !strings.Contains(retry[len(retry)-1].Content, "missing property 'tags'") {
+					t.Errorf("retry messages = %+v, want the rejected reply and the validation error", retry)
+				}
+			}
+		})
+	}
+}
+
+// toolCompleter calls read_file on its first request and answers with the tool
+// result on its second.
+type toolCompleter struct {
+	recordingCompleter
+}
+
+func (c *toolCompleter) CompleteMessage(_ context.Context, req types.CompletionRequest) (types.Message, types.TokenUsage, error) {
+	c.reqs = append(c.reqs, req)
+	usage := types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}
+	if len(c.reqs) == 1 {
+		call := types.ToolCall{ID: "1", Type: "function", Function: types.ToolCallFunction{Name: "read_file", Arguments: `{"path":"notes.txt"}`}}
+		return types.Message{Role: "assistant", ToolCalls: []types.ToolCall{call}}, usage, nil
+	}
+	last := req.Messages[len(req.Messages)-1]
+	return types.Message{Role: "assistant", Content: "read: " + last.Content}, usage, nil
+}
+
+func (c *toolCompleter) StreamCompleteMessage(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.Message, types.TokenUsage, error) {
+	message, usage, err := c.CompleteMessage(ctx, req)
+	if err == nil && message.Content != "" {
+		err = callback(message.Content)
+	}
+	return message, usage, err
+}
+
+func TestRunTools(t *testing.T) {
+	env := newTestEnv(t, map[string]string{"grounded.yaml": "name: grounded\nsystem: Answer from the notes.\ntools: [read_file]\n"})
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.txt"), []byte("ship on friday"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env.Store.SetProjectRoot(root)
+	params := Params{Input: "when do we ship?", PromptName: "grounded", Length: types.OutputLengthMedium}
+	if _, err := env.Run(context.Background(), &toolCompleter{}, params, RunOptions{}); err == nil || !strings.Contains(err.Error(), "git repository") {
+		t.Errorf("Run() outside a git repository error = %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	client := &toolCompleter{}
+	var calls []string
+	var streamed strings.Builder
+	result, err := env.Run(context.Background(), client, params, RunOptions{
+		OnToken: func(token string) error {
+			streamed.WriteString(token)
+			return nil
+		},
+		OnToolCall: func(call types.ToolCall, _ string) { calls = append(calls, call.Function.Name) },
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Output != "read: ship on friday" || streamed.String() != result.Output {
+		t.Errorf("Output = %q (streamed %q), want the answer grounded in the file", result.Output, streamed.String())
+	}
+	if len(calls) != 1 || calls[0] != "read_file" || result.Usage.TotalTokens != 30 {
+		t.Errorf("tool calls = %v, usage = %+v", calls, result.Usage)
+	}
+
+	if _, err := env.Run(context.Background(), &recordingCompleter{}, params, RunOptions{}); err == nil || !strings.Contains(err.Error(), "can't call") {
+		t.Errorf("Run() with a client that can't call tools error = %v", err)
+	}
+
+	// Complete runs the tools too, for callers that build their own requests
+	req, err := env.BuildRequest(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output, _, err := env.Complete(context.Background(), &toolCompleter{}, "grounded", req); err != nil || output != "read: ship on friday" {
+		t.Errorf("Complete() = %q, %v, want the answer grounded in the file", output, err)
+	}
+}
@@ -14,6 +14,7 @@ type autoCompleter struct {
 	commandNames []string
 	modelNames   func() []string
 	promptNames  func() []string
+	lengthNames  func() []string
 }
 
 func newAutoCompleter(state *State, opts Options) readline.AutoCompleter {
@@ -25,6 +26,9 @@ func newAutoCompleter(state *State, opts Options) readline.AutoCompleter {
 		promptNames: func() []string {
 			return sortedCaseInsensitive(state.Store.List())
 		},
+		lengthNames: func() []string {
+			return supportedLengthNames(state)
+		},
 	}
 }
 
@@ -39,6 +43,7 @@ func (c *autoCompleter) Do(line []rune, pos int) ([][]rune, int) {
 		c.commandNames,
 		c.modelNames(),
 		c.promptNames(),
+		c.lengthNames(),
 	)
 	if len(candidates) == 0 {
 		return nil, 0
@@ -57,7 +62,7 @@ func (c *autoCompleter) Do(line []rune, pos int) ([][]rune, int) {
 	return newLine, offset
 }
 
-func completeLine(input string, commandNames, modelNames, promptNames []string) ([]string, string) {
+func completeLine(input string, commandNames, modelNames, promptNames, lengthNames []string) ([]string, string) {
 	trimmedLeft := strings.TrimLeft(input, " \t")
 	if !strings.HasPrefix(trimmedLeft, "/") {
 		return nil, ""
@@ -81,11 +86,35 @@ func completeLine(input string, commandNames, modelNames, promptNames []string)
 			prefix := argumentPrefix(trimmedLeft)
 			return filterByPrefixCaseInsensitive(promptNames, prefix), prefix
 		}
+	case "/length":
+		if commandHasArguments(trimmedLeft) {
+			prefix := argumentPrefix(trimmedLeft)
+			return filterByPrefixCaseInsensitive(lengthNames, prefix), prefix
+		}
+	case "/reasoning":
+		if commandHasArguments(trimmedLeft) {
+			prefix := argumentPrefix(trimmedLeft)
+			return filterByPrefixCaseInsensitive([]string{"on", "off"}, prefix), prefix
+		}
 	}
 
 	return filterByPrefixCaseInsensitive(commandNames, typedCommand), typedCommand
 }
 
+// supportedLengthNames lists the output lengths the current prompt supports,
+// built-in lengths first.
+func supportedLengthNames(state *State) []string {
+	prompt, err := state.Store.Get(state.PromptName)
+
+	var names []string
+	for _, length := range state.Store.Lengths().List() {
+		if err != nil || prompt.SupportsLength(length) {
+			names = append(names, string(length))
+		}
+	}
+	return names
+}
+
 func commandHasArguments(input string) bool {
 	return strings.ContainsAny(input, " \t")
 }
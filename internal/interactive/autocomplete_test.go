@@ -22,7 +22,7 @@ func TestCompleteLineCommandPrefixMatching(t *testing.T) {
 			name:            "slash shows command suggestions",
 			input:           "/",
 			wantPrefix:      "/",
-			wantSuggestions: []string{"/clear", "/length", "/model", "/copy", "/prompt", "/help", "/quit", "/exit"},
+			wantSuggestions: []string{"/clear", "/length", "/model", "/copy", "/prompt", "/set", "/unset", "/refine", "/reasoning", "/attach", "/help", "/quit", "/exit"},
 		},
 		{
 			name:            "prefix filters model command",
@@ -40,7 +40,7 @@ func TestCompleteLineCommandPrefixMatching(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames)
+			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames, nil)
 			if gotPrefix != tt.wantPrefix {
 				t.Fatalf("completeLine() prefix = %q, want %q", gotPrefix, tt.wantPrefix)
 			}
@@ -88,7 +88,7 @@ func TestCompleteLineModelSuggestions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames)
+			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames, nil)
 			if gotPrefix != tt.wantPrefix {
 				t.Fatalf("completeLine() prefix = %q, want %q", gotPrefix, tt.wantPrefix)
 			}
@@ -136,7 +136,7 @@ func TestCompleteLinePromptSuggestions(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames)
+			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, modelNames, promptNames, nil)
 			if gotPrefix != tt.wantPrefix {
 				t.Fatalf("completeLine() prefix = %q, want %q", gotPrefix, tt.wantPrefix)
 			}
@@ -151,6 +151,43 @@ func TestCompleteLinePromptSuggestions(t *testing.T) {
 	}
 }
 
+func TestCompleteLineLengthSuggestions(t *testing.T) {
+	commandNames := slashCommandAutocompleteNames(interactiveSlashCommands)
+	lengthNames := []string{"short", "medium", "long", "tweet"}
+
+	tests := []struct {
+		name            string
+		input           string
+		wantPrefix      string
+		wantSuggestions []string
+	}{
+		{
+			name:            "length command with trailing space suggests all lengths in order",
+			input:           "/length ",
+			wantPrefix:      "",
+			wantSuggestions: []string{"short", "medium", "long", "tweet"},
+		},
+		{
+			name:            "length alias filters by prefix",
+			input:           "/l tw",
+			wantPrefix:      "tw",
+			wantSuggestions: []string{"tweet"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSuggestions, gotPrefix := completeLine(tt.input, commandNames, nil, nil, lengthNames)
+			if gotPrefix != tt.wantPrefix {
+				t.Fatalf("completeLine() prefix = %q, want %q", gotPrefix, tt.wantPrefix)
+			}
+			if strings.Join(gotSuggestions, ",") != strings.Join(tt.wantSuggestions, ",") {
+				t.Fatalf("completeLine() suggestions = %v, want %v", gotSuggestions, tt.wantSuggestions)
+			}
+		})
+	}
+}
+
 func TestSuggestionPainter(t *testing.T) {
 	store, err := prompts.NewStore()
 	if err != nil {
@@ -198,14 +235,14 @@ func TestCompleteLineDeterministicOrdering(t *testing.T) {
 	modelNames := sortedCaseInsensitive([]string{"z-model", "A-model", "b-model"})
 	promptNames := sortedCaseInsensitive([]string{"z-prompt", "A-prompt", "b-prompt"})
 
-	firstModels, _ := completeLine("/model ", commandNames, modelNames, promptNames)
-	secondModels, _ := completeLine("/model ", commandNames, modelNames, promptNames)
+	firstModels, _ := completeLine("/model ", commandNames, modelNames, promptNames, nil)
+	secondModels, _ := completeLine("/model ", commandNames, modelNames, promptNames, nil)
 	if strings.Join(firstModels, ",") != strings.Join(secondModels, ",") {
 		t.Fatalf("model suggestions are not deterministic: %v vs %v", firstModels, secondModels)
 	}
 
-	firstPrompts, _ := completeLine("/prompt ", commandNames, modelNames, promptNames)
-	secondPrompts, _ := completeLine("/prompt ", commandNames, modelNames, promptNames)
+	firstPrompts, _ := completeLine("/prompt ", commandNames, modelNames, promptNames, nil)
+	secondPrompts, _ := completeLine("/prompt ", commandNames, modelNames, promptNames, nil)
 	if strings.Join(firstPrompts, ",") != strings.Join(secondPrompts, ",") {
 		t.Fatalf("prompt suggestions are not deterministic: %v vs %v", firstPrompts, secondPrompts)
 	}
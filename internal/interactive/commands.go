@@ -3,8 +3,13 @@ package interactive
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
 	"github.com/raypaste/raypaste-cli/internal/clipboard"
 	"github.com/raypaste/raypaste-cli/internal/config"
 	"github.com/raypaste/raypaste-cli/internal/output"
@@ -60,6 +65,41 @@ var interactiveSlashCommands = []slashCommandSpec{
 			{Usage: "/prompt [name]", Description: "Switch prompt template to provided prompt"},
 		},
 	},
+	{
+		Primary: "/set",
+		HelpEntries: []slashCommandHelpEntry{
+			{Usage: "/set", Description: "Show template variables for the session and current prompt"},
+			{Usage: "/set [key] [value]", Description: "Set a template variable"},
+		},
+	},
+	{
+		Primary: "/unset",
+		HelpEntries: []slashCommandHelpEntry{
+			{Usage: "/unset [key]", Description: "Remove a template variable"},
+		},
+	},
+	{
+		Primary: "/refine",
+		HelpEntries: []slashCommandHelpEntry{
+			{Usage: "/refine", Description: "Show the number of refinement rounds"},
+			{Usage: "/refine [n]", Description: "Critique and rewrite each response for up to n rounds (0 turns it off)"},
+		},
+	},
+	{
+		Primary: "/reasoning",
+		HelpEntries: []slashCommandHelpEntry{
+			{Usage: "/reasoning", Description: "Show whether the model's reasoning is displayed"},
+			{Usage: "/reasoning [on|off]", Description: "Show or hide the model's reasoning, dimmed, before each response"},
+		},
+	},
+	{
+		Primary: "/attach",
+		HelpEntries: []slashCommandHelpEntry{
+			{Usage: "/attach", Description: "List the files attached to the next message"},
+			{Usage: "/attach [path]", Description: "Attach an image, PDF or text file to the next message"},
+			{Usage: "/attach clear", Description: "Remove the attachments"},
+		},
+	},
 	{
 		Primary: "/help",
 		HelpEntries: []slashCommandHelpEntry{
@@ -102,10 +142,11 @@ func handleSlashCommand(line string, state *State, models map[string]config.Mode
 	case "/length":
 		if len(args) == 0 {
 			fmt.Printf("Current length: %s\n", output.Bold(output.Yellow(string(state.Length))))
-			fmt.Printf("Usage: %s\n", output.Cyan("/length <short|medium|long>"))
+			fmt.Printf("Available lengths: %s\n", output.Yellow(strings.Join(supportedLengthNames(state), ", ")))
+			fmt.Printf("Usage: %s\n", output.Cyan("/length <name>"))
 			return false
 		}
-		length, err := config.ValidateOutputLength(args[0])
+		length, err := state.Store.Lengths().Validate(args[0])
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", output.Red(err.Error()))
 			return false
@@ -115,7 +156,7 @@ func handleSlashCommand(line string, state *State, models map[string]config.Mode
 
 	case "/model":
 		if len(args) == 0 {
-			fmt.Printf("Current model: %s\n", output.Bold(output.Blue(state.Model)))
+			fmt.Printf("Current model: %s\n", output.Bold(output.Blue(state.CurrentModel())))
 			availableModels := config.ListModels(models)
 			coloredModels := make([]string, len(availableModels))
 			for i, m := range availableModels {
@@ -148,6 +189,81 @@ func handleSlashCommand(line string, state *State, models map[string]config.Mode
 		state.PromptName = args[0]
 		fmt.Printf(output.White("Prompt set to: %s\n"), output.Bold(output.Green(state.PromptName)))
 
+	case "/set":
+		if len(args) < 2 {
+			printVars(state)
+			fmt.Printf("Usage: %s\n", output.Cyan("/set <key> <value>"))
+			return false
+		}
+		if state.Vars == nil {
+			state.Vars = make(map[string]string)
+		}
+		state.Vars[args[0]] = strings.Join(args[1:], " ")
+		fmt.Printf("Variable %s set to: %s\n", output.Bold(output.Magenta(args[0])), state.Vars[args[0]])
+
+	case "/unset":
+		if len(args) == 0 {
+			fmt.Printf("Usage: %s\n", output.Cyan("/unset <key>"))
+			return false
+		}
+		delete(state.Vars, args[0])
+		fmt.Printf("Variable %s unset\n", output.Bold(output.Magenta(args[0])))
+
+	case "/refine":
+		if len(args) == 0 {
+			fmt.Printf("Refinement rounds: %s\n", output.Bold(output.Yellow(strconv.Itoa(state.Refine))))
+			fmt.Printf("Usage: %s\n", output.Cyan("/refine <n>"))
+			return false
+		}
+		rounds, err := strconv.Atoi(args[0])
+		if err != nil || rounds < 0 {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", output.Red(fmt.Sprintf("invalid number of rounds %q", args[0])))
+			return false
+		}
+		state.Refine = rounds
+		fmt.Printf("Refinement rounds set to: %s\n", output.Bold(output.Yellow(strconv.Itoa(rounds))))
+
+	case "/reasoning":
+		if len(args) == 0 {
+			fmt.Printf("Reasoning display: %s\n", output.Bold(output.Yellow(onOff(state.ShowReasoning))))
+			fmt.Printf("Usage: %s\n", output.Cyan("/reasoning <on|off>"))
+			return false
+		}
+		switch strings.ToLower(args[0]) {
+		case "on":
+			state.ShowReasoning = true
+		case "off":
+			state.ShowReasoning = false
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %v\n", output.Red(fmt.Sprintf("expected on or off, got %q", args[0])))
+			return false
+		}
+		fmt.Printf("Reasoning display: %s\n", output.Bold(output.Yellow(onOff(state.ShowReasoning))))
+
+	case "/attach":
+		// Paths may contain spaces, so take the rest of the line
+		path := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), parts[0]))
+		switch path {
+		case "":
+			printAttachments(state)
+			fmt.Printf("Usage: %s\n", output.Cyan("/attach <path>"))
+			return false
+		case "clear":
+			state.Attachments = nil
+			fmt.Println(output.Yellow("Attachments removed"))
+			return false
+		}
+		a, err := attachment.Load(unquotePath(path))
+		if err == nil {
+			err = attachment.CheckTotal(append(slices.Clip(state.Attachments), a))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", output.Red(err.Error()))
+			return false
+		}
+		state.Attachments = append(state.Attachments, a)
+		fmt.Println(output.AttachmentMessage(a.Name, a.MIMEType, attachment.FormatSize(a.Size)))
+
 	case "/copy":
 		if state.LastResponse == "" {
 			fmt.Println(output.Yellow("No response to copy"))
@@ -166,6 +282,79 @@ func handleSlashCommand(line string, state *State, models map[string]config.Mode
 	return false
 }
 
+// printVars lists the session's variables followed by any variables the current
+// prompt declares that have not been set.
+func printVars(state *State) {
+	keys := make([]string, 0, len(state.Vars))
+	for k := range state.Vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	if len(keys) == 0 {
+		fmt.Println(output.Yellow("No variables set"))
+	}
+	for _, k := range keys {
+		fmt.Printf("  %s = %s\n", output.Magenta(k), state.Vars[k])
+	}
+
+	prompt, err := state.Store.Get(state.PromptName)
+	if err != nil {
+		return
+	}
+	for _, v := range prompt.Variables {
+		if _, ok := state.Vars[v.Name]; ok {
+			continue
+		}
+		note := "optional"
+		switch {
+		case v.Default != "":
+			note = fmt.Sprintf("default %q", v.Default)
+		case v.Required:
+			note = output.Red("required")
+		}
+		line := fmt.Sprintf("  %s (%s)", output.Magenta(v.Name), note)
+		if v.Description != "" {
+			line += " - " + v.Description
+		}
+		fmt.Println(line)
+	}
+}
+
+// onOff returns "on" or "off" for b.
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// printAttachments lists the files attached to the next message.
+func printAttachments(state *State) {
+	if len(state.Attachments) == 0 {
+		fmt.Println(output.Yellow("No attachments"))
+	}
+	for _, a := range state.Attachments {
+		fmt.Println(output.AttachmentMessage(a.Name, a.MIMEType, attachment.FormatSize(a.Size)))
+	}
+}
+
+// unquotePath undoes the quoting terminals add to a path dropped into them: it
+// removes surrounding quotes or backslash-escaped spaces, and expands a leading ~.
+func unquotePath(path string) string {
+	if len(path) >= 2 && (path[0] == '\'' || path[0] == '"') && path[len(path)-1] == path[0] {
+		path = path[1 : len(path)-1]
+	} else {
+		path = strings.ReplaceAll(path, `\ `, " ")
+	}
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
 func printHelp() {
 	// Calculate max usage length for description text right-alignment
 	maxUsageLen := 0
@@ -1,6 +1,9 @@
 package interactive
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/raypaste/raypaste-cli/internal/config"
@@ -144,3 +147,67 @@ func TestHandleSlashCommandCopy(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleSlashCommandSet(t *testing.T) {
+	state := newTestState(t)
+
+	handleSlashCommand("/set audience senior engineers", state, map[string]config.Model{})
+	if got := state.Vars["audience"]; got != "senior engineers" {
+		t.Errorf("state.Vars[audience] = %q, want %q", got, "senior engineers")
+	}
+
+	// Without a value the command only lists variables.
+	handleSlashCommand("/set tone", state, map[string]config.Model{})
+	if _, ok := state.Vars["tone"]; ok {
+		t.Error("/set without a value should not set a variable")
+	}
+
+	handleSlashCommand("/unset audience", state, map[string]config.Model{})
+	if _, ok := state.Vars["audience"]; ok {
+		t.Error("/unset should remove the variable")
+	}
+}
+
+func TestHandleSlashCommandAttach(t *testing.T) {
+	state := newTestState(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ui spec.md")
+	if err := os.WriteFile(path, []byte("# Checkout\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	handleSlashCommand("/attach "+strings.ReplaceAll(path, " ", `\ `), state, map[string]config.Model{})
+	handleSlashCommand("/attach '"+path+"'", state, map[string]config.Model{})
+	if len(state.Attachments) != 2 || state.Attachments[0].Name != "ui spec.md" {
+		t.Fatalf("state.Attachments = %+v, want the file attached twice", state.Attachments)
+	}
+
+	handleSlashCommand("/attach "+filepath.Join(dir, "missing.png"), state, map[string]config.Model{})
+	if len(state.Attachments) != 2 {
+		t.Error("/attach should not add a file it can't load")
+	}
+
+	handleSlashCommand("/attach clear", state, map[string]config.Model{})
+	if len(state.Attachments) != 0 {
+		t.Error("/attach clear should remove the attachments")
+	}
+}
+
+func TestHandleSlashCommandReasoning(t *testing.T) {
+	state := newTestState(t)
+
+	handleSlashCommand("/reasoning on", state, map[string]config.Model{})
+	if !state.ShowReasoning {
+		t.Error("/reasoning on should show reasoning")
+	}
+
+	handleSlashCommand("/reasoning maybe", state, map[string]config.Model{})
+	if !state.ShowReasoning {
+		t.Error("/reasoning with an invalid argument should not change the setting")
+	}
+
+	handleSlashCommand("/reasoning OFF", state, map[string]config.Model{})
+	if state.ShowReasoning {
+		t.Error("/reasoning off should hide reasoning")
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
 	"github.com/raypaste/raypaste-cli/internal/config"
 	"github.com/raypaste/raypaste-cli/internal/llm"
 	"github.com/raypaste/raypaste-cli/internal/output"
@@ -19,21 +20,55 @@ import (
 )
 
 // State holds the REPL session state.
+// Model is the model chosen with --model or /model; when empty the current
+// prompt's preferred model, then DefaultModel, is used.
 type State struct {
 	Model        string
+	DefaultModel string
 	Length       types.OutputLength
 	PromptName   string
 	LastResponse string
 	ProjCtx      projectcontext.Result
 	Store        *prompts.Store
 	Client       *llm.Client
+	Vars         map[string]string
+	// Refine is the number of critique and rewrite rounds run after each generation.
+	Refine int
+	// CriticModel critiques each draft when Refine is set; the generating model is
+	// used when empty.
+	CriticModel string
+	// Attachments are sent with the next message and cleared once it is sent, even if
+	// it fails.
+	Attachments []attachment.Attachment
+	// ShowReasoning prints the reasoning a model streams, dimmed, before its response.
+	ShowReasoning bool
 }
 
 // Options holds REPL configuration options.
 type Options struct {
-	Temperature float64
-	Models      map[string]config.Model
-	AutoCopy    bool
+	Temperature         float64
+	TemperatureOverride *float64
+	Models              map[string]config.Model
+	AutoCopy            bool
+	// ShowSteps prints the output of each intermediate step of a pipeline prompt.
+	ShowSteps bool
+	// ReasoningEffort and ReasoningMaxTokens, when either is set, replace the
+	// reasoning settings of the prompt and the model.
+	ReasoningEffort    string
+	ReasoningMaxTokens int
+}
+
+// CurrentModel returns the model the next generation will use.
+func (s *State) CurrentModel() string {
+	if s.Model != "" {
+		return s.Model
+	}
+	if s.Store != nil {
+		if prompt, err := s.Store.Get(s.PromptName); err == nil && prompt.Model != "" {
+			return prompt.Model
+		}
+	}
+	return s.DefaultModel
 }
 
 // readResult holds a single line read from readline.
@@ -247,7 +282,7 @@ func formatWelcomeLines(state *State) []string {
 		"\n",
 		fmt.Sprintf(
 			"%s%s%s %s %s%s%s %s %s%s%s\n",
-			output.White("Model:"), output.White(" "), output.BoldBlue(state.Model),
+			output.White("Model:"), output.White(" "), output.BoldBlue(state.CurrentModel()),
 			output.White("|"),
 			output.White(" Length:"), output.White(" "), output.BoldYellow(string(state.Length)),
 			output.White("|"),
@@ -1,10 +1,13 @@
 package interactive
 
 import (
+	"context"
 	"io"
 	"strings"
 	"testing"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
+	"github.com/raypaste/raypaste-cli/internal/llm"
 	"github.com/raypaste/raypaste-cli/internal/prompts"
 	"github.com/raypaste/raypaste-cli/pkg/types"
 )
@@ -130,3 +133,34 @@ func TestDrainLines(t *testing.T) {
 		drainLines(ch) // reads the item then returns on closed channel
 	})
 }
+
+func TestStateCurrentModel(t *testing.T) {
+	state := newTestState(t)
+	state.DefaultModel = "default-model"
+
+	state.Model = ""
+	if got := state.CurrentModel(); got != "default-model" {
+		t.Errorf("CurrentModel() = %q, want the default model when no model or prompt model is set", got)
+	}
+
+	state.Model = "chosen-model"
+	if got := state.CurrentModel(); got != "chosen-model" {
+		t.Errorf("CurrentModel() = %q, want the explicitly chosen model", got)
+	}
+}
+
+func TestGenerateStreamingClearsAttachmentsOnFailure(t *testing.T) {
+	state := newTestState(t)
+	state.Client = llm.NewClient("test-key")
+	state.Attachments = []attachment.Attachment{{Name: "spec.md", MIMEType: "text/markdown", Size: 10}}
+
+	// A cancelled context fails the request before it reaches the network
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := generateStreaming(ctx, "hello", state, Options{}); err == nil {
+		t.Fatal("generateStreaming() should fail with a cancelled context")
+	}
+	if len(state.Attachments) != 0 {
+		t.Errorf("state.Attachments = %+v, want them cleared after a failed message", state.Attachments)
+	}
+}
@@ -7,55 +7,104 @@ import (
 	"strings"
 	"time"
 
+	"github.com/raypaste/raypaste-cli/internal/attachment"
 	"github.com/raypaste/raypaste-cli/internal/clipboard"
-	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/generate"
 	"github.com/raypaste/raypaste-cli/internal/output"
+	"github.com/raypaste/raypaste-cli/internal/refine"
+	"github.com/raypaste/raypaste-cli/pkg/types"
 )
 
 // generateStreaming generates a streaming response using the LLM client.
 func generateStreaming(ctx context.Context, input string, state *State, opts Options) error {
-	systemPrompt, err := state.Store.Render(state.PromptName, state.Length, state.ProjCtx.Content)
-	if err != nil {
-		return fmt.Errorf("failed to render prompt: %w", err)
-	}
-
-	maxTokensOverride := state.Store.GetMaxTokensOverride(state.PromptName, state.Length)
-
-	req, err := llm.BuildRequest(
-		state.Model,
-		systemPrompt,
-		input,
-		state.Length,
-		opts.Temperature,
-		true, // streaming enabled
-		opts.Models,
-		maxTokensOverride,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to build request: %w", err)
+	env := &generate.Env{
+		Store:               state.Store,
+		ProjCtx:             state.ProjCtx,
+		Temperature:         opts.Temperature,
+		Models:              opts.Models,
+		DefaultModel:        state.DefaultModel,
+		TemperatureOverride: opts.TemperatureOverride,
+		ReasoningEffort:     opts.ReasoningEffort,
+		ReasoningMaxTokens:  opts.ReasoningMaxTokens,
 	}
 
 	// Reset last response
 	state.LastResponse = ""
-	var responseBuilder strings.Builder
 	colorizer := output.NewStreamingColorizer()
 
 	// Show progress indicator
-	fmt.Fprintln(os.Stderr, output.GeneratingMessage(state.Model, string(state.Length), state.ProjCtx.Filename))
+	fmt.Fprintln(os.Stderr, output.GeneratingMessage(state.CurrentModel(), string(state.Length), state.ProjCtx.Filename))
+	for _, a := range state.Attachments {
+		fmt.Fprintln(os.Stderr, output.AttachmentMessage(a.Name, a.MIMEType, attachment.FormatSize(a.Size)))
+	}
 
 	// Stream response
 	fmt.Println() // New line before output
 	startTime := time.Now()
-	usage, err := state.Client.StreamComplete(ctx, req, func(token string) error {
-		colorizedToken := colorizer.ProcessToken(token)
-		fmt.Print(colorizedToken)
-		responseBuilder.WriteString(token)
-		return nil
+	step := 0
+	params := generate.Params{
+		Input:       input,
+		PromptName:  state.PromptName,
+		Model:       state.Model,
+		Length:      state.Length,
+		Vars:        state.Vars,
+		Attachments: attachment.Parts(state.Attachments),
+	}
+	// Reasoning is shown dimmed on stderr, so it is never part of the response or
+	// copied with it
+	var onReasoning func(string) error
+	reasoning := false
+	if state.ShowReasoning {
+		onReasoning = func(token string) error {
+			reasoning = true
+			fmt.Fprint(os.Stderr, output.Dim(token))
+			return nil
+		}
+	}
+
+	// Structured output arrives as one token of indented JSON, printed as is
+	structured := env.Structured(params.PromptName)
+	var streamed strings.Builder
+	result, err := env.Run(ctx, state.Client, params, generate.RunOptions{
+		OnReasoning: onReasoning,
+		OnToken: func(token string) error {
+			if reasoning {
+				reasoning = false
+				fmt.Fprint(os.Stderr, "\n\n")
+			}
+			streamed.WriteString(token)
+			if structured {
+				fmt.Print(token)
+				return nil
+			}
+			colorizedToken := colorizer.ProcessToken(token)
+			fmt.Print(colorizedToken)
+			return nil
+		},
+		OnToolCall: func(call types.ToolCall, _ string) {
+			fmt.Fprintln(os.Stderr, output.ToolCallMessage(call.Function.Name, call.Function.Arguments))
+		},
+		OnStep: func(result generate.StepResult) {
+			step++
+			if opts.ShowSteps {
+				fmt.Fprintln(os.Stderr, output.StepMessage(step, result.Prompt, result.Model))
+				fmt.Fprintln(os.Stderr, result.Output)
+				fmt.Fprintln(os.Stderr)
+			}
+		},
 	})
-	durationMs := time.Since(startTime).Milliseconds()
+	usage := result.Usage
+
+	// Attachments go with one message only, whether or not it was answered, so a
+	// failed message doesn't leave them silently pending for the next one
+	attached := len(state.Attachments)
+	state.Attachments = nil
 
 	if err != nil {
 		fmt.Println() // Ensure newline after error
+		if attached > 0 {
+			fmt.Fprintln(os.Stderr, output.Yellow("Attachments removed; use /attach to add them again"))
+		}
 		return fmt.Errorf("streaming failed: %w", err)
 	}
 
@@ -63,12 +112,60 @@ func generateStreaming(ctx context.Context, input string, state *State, opts Opt
 		fmt.Print(trailing)
 	}
 
-	// Store response
-	state.LastResponse = responseBuilder.String()
+	// Store the response with every postprocess rule applied; only a leading
+	// preamble is stripped while streaming
+	state.LastResponse = result.Output
 
 	fmt.Println() // New line after output
 	fmt.Println() // Extra line for spacing
 
+	// Postprocess rules other than preamble stripping need the whole output; when they
+	// change it, show the final text, which is what gets copied
+	if strings.TrimSpace(streamed.String()) != strings.TrimSpace(result.Output) {
+		fmt.Fprintln(os.Stderr, output.Bold("Cleaned up response:"))
+		fmt.Println(output.ColorizeMarkdown(result.Output))
+		fmt.Println()
+	}
+
+	if state.Refine > 0 {
+		refined, err := refine.Run(ctx, env, state.Client, params, result, refine.Options{
+			Rounds:      state.Refine,
+			CriticModel: state.CriticModel,
+			OnRound: func(round refine.Round) {
+				fmt.Fprintln(os.Stderr, output.RefineRoundMessage(round.Number, state.Refine, round.Approved))
+				if !round.Approved {
+					fmt.Fprintln(os.Stderr, output.Cyan(round.Critique))
+					if diff := round.Diff(); diff != "" {
+						for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+							fmt.Fprintln(os.Stderr, output.ColorDiffLine(line))
+						}
+					}
+				}
+				fmt.Fprintln(os.Stderr)
+			},
+		})
+		// A failed round keeps the last version that finished
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: refinement failed, keeping the last finished version: %v\n", err)
+		}
+		usage.PromptTokens += refined.Usage.PromptTokens
+		usage.CompletionTokens += refined.Usage.CompletionTokens
+		usage.TotalTokens += refined.Usage.TotalTokens
+
+		// Print the final version once the last round has changed it
+		if refined.Output != state.LastResponse {
+			state.LastResponse = refined.Output
+			fmt.Fprintln(os.Stderr, output.Bold("Refined response:"))
+			if result.Structured {
+				fmt.Println(refined.Output)
+			} else {
+				fmt.Println(output.ColorizeMarkdown(refined.Output))
+			}
+			fmt.Println()
+		}
+	}
+	durationMs := time.Since(startTime).Milliseconds()
+
 	if opts.AutoCopy {
 		if warning := clipboard.CopyWithWarning(state.LastResponse); warning != "" {
 			fmt.Fprintln(os.Stderr, warning)
@@ -38,19 +38,27 @@ func NewClient(apiKey string) *Client {
 
 // Complete sends a completion request to OpenRouter and returns the full response with token usage.
 func (c *Client) Complete(ctx context.Context, req types.CompletionRequest) (string, types.TokenUsage, error) {
+	message, usage, err := c.CompleteMessage(ctx, req)
+	return message.Content, usage, err
+}
+
+// CompleteMessage sends a completion request to OpenRouter and returns the assistant's
+// message, including any tool calls, with token usage.
+func (c *Client) CompleteMessage(ctx context.Context, req types.CompletionRequest) (types.Message, types.TokenUsage, error) {
 	// Ensure stream is false for non-streaming
 	req.Stream = false
+	req.StreamOptions = nil
 
 	// Marshal request
 	body, err := json.Marshal(req)
 	if err != nil {
-		return "", types.TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", openRouterBaseURL, bytes.NewReader(body))
 	if err != nil {
-		return "", types.TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set GetBody for retry support
@@ -64,7 +72,7 @@ func (c *Client) Complete(ctx context.Context, req types.CompletionRequest) (str
 	// Send request with retry
 	resp, err := c.doWithRetry(httpReq)
 	if err != nil {
-		return "", types.TokenUsage{}, err
+		return types.Message{}, types.TokenUsage{}, err
 	}
 	defer func() {
 		_ = resp.Body.Close()
@@ -72,21 +80,21 @@ func (c *Client) Complete(ctx context.Context, req types.CompletionRequest) (str
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return "", types.TokenUsage{}, c.handleErrorResponse(resp)
+		return types.Message{}, types.TokenUsage{}, c.handleErrorResponse(resp)
 	}
 
 	// Parse response
 	var completionResp types.CompletionResponse
 	if err := json.NewDecoder(resp.Body).Decode(&completionResp); err != nil {
-		return "", types.TokenUsage{}, fmt.Errorf("failed to decode response: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Extract content
 	if len(completionResp.Choices) == 0 {
-		return "", types.TokenUsage{}, fmt.Errorf("no choices in response")
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("no choices in response")
 	}
 
-	return completionResp.Choices[0].Message.Content, completionResp.Usage, nil
+	return completionResp.Choices[0].Message, completionResp.Usage, nil
 }
 
 // StreamComplete sends a streaming completion request and calls the callback for each token.
@@ -96,19 +104,32 @@ func (c *Client) Complete(ctx context.Context, req types.CompletionRequest) (str
 //
 // See: https://openrouter.ai/docs/api/reference/streaming#stream-cancellation
 func (c *Client) StreamComplete(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.TokenUsage, error) {
+	_, usage, err := c.StreamCompleteMessage(ctx, req, callback)
+	return usage, err
+}
+
+// StreamCompleteMessage is StreamComplete, also returning the assembled assistant
+// message with any tool calls streamed alongside the content.
+func (c *Client) StreamCompleteMessage(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.Message, types.TokenUsage, error) {
+	return c.StreamCompleteReasoning(ctx, req, callback, nil)
+}
+
+// StreamCompleteReasoning is StreamCompleteMessage, also calling onReasoning with each
+// reasoning token a reasoning model streams. Reasoning is never passed to callback.
+func (c *Client) StreamCompleteReasoning(ctx context.Context, req types.CompletionRequest, callback, onReasoning func(string) error) (types.Message, types.TokenUsage, error) {
 	// Ensure stream is true
 	req.Stream = true
 
 	// Marshal request
 	body, err := json.Marshal(req)
 	if err != nil {
-		return types.TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", openRouterBaseURL, bytes.NewReader(body))
 	if err != nil {
-		return types.TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set GetBody for potential retry support
@@ -127,7 +148,7 @@ func (c *Client) StreamComplete(ctx context.Context, req types.CompletionRequest
 	// Send request (no retry for streaming)
 	resp, err := streamClient.Do(httpReq)
 	if err != nil {
-		return types.TokenUsage{}, fmt.Errorf("request failed: %w", err)
+		return types.Message{}, types.TokenUsage{}, fmt.Errorf("request failed: %w", err)
 	}
 
 	// Close the response body promptly on context cancellation.
@@ -148,11 +169,11 @@ func (c *Client) StreamComplete(ctx context.Context, req types.CompletionRequest
 
 	// Check status code
 	if resp.StatusCode != http.StatusOK {
-		return types.TokenUsage{}, c.handleErrorResponse(resp)
+		return types.Message{}, types.TokenUsage{}, c.handleErrorResponse(resp)
 	}
 
 	// Process streaming response and capture usage
-	return processStreamingResponseWithUsage(resp.Body, callback)
+	return processStreamingMessage(resp.Body, callback, onReasoning)
 }
 
 // setHeaders sets the required headers for OpenRouter API
@@ -0,0 +1,220 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxToolFileBytes caps how much of a file read_file returns.
+	maxToolFileBytes = 64 * 1024
+	// maxToolListEntries caps how many entries list_directory returns.
+	maxToolListEntries = 500
+)
+
+// secretFilePatterns match the names of files and directories that commonly hold
+// credentials. The project tools refuse to read them and leave them out of listings,
+// as whatever a tool returns is sent to the model provider. Names are matched
+// case-insensitively, against every element of a path.
+var secretFilePatterns = []string{
+	".env", ".env.*", ".envrc",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore",
+	"id_rsa*", "id_dsa*", "id_ecdsa*", "id_ed25519*",
+	".ssh", ".aws", ".gnupg", ".docker",
+	"credentials", "credentials.*", ".git-credentials",
+	".npmrc", ".pypirc", ".netrc", "_netrc", ".pgpass",
+}
+
+// secretFileExceptions are names secretFilePatterns match that are conventionally
+// templates without real values.
+var secretFileExceptions = []string{".env.example", ".env.sample", ".env.template"}
+
+// ProjectToolNames lists the tools ProjectTools provides, for prompts to opt into.
+var ProjectToolNames = []string{"read_file", "list_directory"}
+
+// pathArguments are the arguments of the project tools.
+type pathArguments struct {
+	Path string `json:"path"`
+}
+
+// pathParameters is the JSON Schema of pathArguments.
+func pathParameters(description string) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string", "description": description},
+		},
+		"required": []string{"path"},
+	}
+}
+
+// ProjectTools returns the named tools, which read files and list directories
+// under root. Paths are relative to root and can't escape it, even through
+// symlinks, and files that commonly hold secrets are refused. Unknown names are an
+// error.
+func ProjectTools(root string, names []string) ([]Tool, error) {
+	all := map[string]Tool{
+		"read_file": {
+			Name:        "read_file",
+			Description: "Read a text file from the user's project. Long files are truncated.",
+			Parameters:  pathParameters("File path relative to the project root"),
+			Handler: func(ctx context.Context, arguments json.RawMessage) (string, error) {
+				return readProjectFile(root, arguments)
+			},
+		},
+		"list_directory": {
+			Name:        "list_directory",
+			Description: "List the files and directories in a directory of the user's project. Directories end in /.",
+			Parameters:  pathParameters("Directory path relative to the project root; . for the root"),
+			Handler: func(ctx context.Context, arguments json.RawMessage) (string, error) {
+				return listProjectDirectory(root, arguments)
+			},
+		},
+	}
+
+	tools := make([]Tool, 0, len(names))
+	for _, name := range names {
+		tool, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown tool '%s' (available: %s)", name, strings.Join(ProjectToolNames, ", "))
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// readProjectFile returns the contents of the file named by arguments.
+func readProjectFile(root string, arguments json.RawMessage) (string, error) {
+	var args pathArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	if err := checkSecretPath(root, args.Path); err != nil {
+		return "", err
+	}
+
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+
+	f, err := r.Open(filepath.Clean(args.Path))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxToolFileBytes+1))
+	if err != nil {
+		return "", err
+	}
+	truncated := len(data) > maxToolFileBytes
+	if truncated {
+		data = data[:maxToolFileBytes]
+	}
+	if !utf8.Valid(data) && !truncated {
+		return "", fmt.Errorf("%s is not a text file", args.Path)
+	}
+
+	text := strings.ToValidUTF8(string(data), "")
+	if truncated {
+		text += fmt.Sprintf("\n[truncated after %d bytes]", maxToolFileBytes)
+	}
+	return text, nil
+}
+
+// listProjectDirectory returns the entries of the directory named by arguments, one
+// per line.
+func listProjectDirectory(root string, arguments json.RawMessage) (string, error) {
+	var args pathArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if args.Path == "" {
+		args.Path = "."
+	}
+	if err := checkSecretPath(root, args.Path); err != nil {
+		return "", err
+	}
+
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = r.Close() }()
+
+	f, err := r.Open(filepath.Clean(args.Path))
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	entries, err := f.ReadDir(-1)
+	if err != nil {
+		return "", err
+	}
+	entries = slices.DeleteFunc(entries, func(entry os.DirEntry) bool { return isSecretName(entry.Name()) })
+	slices.SortFunc(entries, func(a, b os.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+
+	var b strings.Builder
+	for i, entry := range entries {
+		if i == maxToolListEntries {
+			fmt.Fprintf(&b, "[%d more entries]\n", len(entries)-i)
+			break
+		}
+		b.WriteString(entry.Name())
+		if entry.IsDir() {
+			b.WriteString("/")
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+// checkSecretPath returns an error if path, or the path it resolves to through
+// symlinks, has an element matching secretFilePatterns.
+func checkSecretPath(root, path string) error {
+	paths := []string{filepath.Clean(path)}
+	if resolvedRoot, err := filepath.EvalSymlinks(root); err == nil {
+		if resolved, err := filepath.EvalSymlinks(filepath.Join(root, path)); err == nil {
+			if rel, err := filepath.Rel(resolvedRoot, resolved); err == nil {
+				paths = append(paths, rel)
+			}
+		}
+	}
+
+	for _, p := range paths {
+		for _, name := range strings.Split(filepath.ToSlash(p), "/") {
+			if isSecretName(name) {
+				return fmt.Errorf("%s may contain secrets, so tools can't read it", path)
+			}
+		}
+	}
+	return nil
+}
+
+// isSecretName reports whether a file or directory name matches secretFilePatterns.
+func isSecretName(name string) bool {
+	name = strings.ToLower(name)
+	if slices.Contains(secretFileExceptions, name) {
+		return false
+	}
+	for _, pattern := range secretFilePatterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -4,8 +4,10 @@ Copyright © 2026 Raypaste
 package llm
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/raypaste/raypaste-cli/internal/config"
 	"github.com/raypaste/raypaste-cli/pkg/types"
@@ -27,22 +29,61 @@ var LengthParams = map[types.OutputLength]types.LengthParams{
 	},
 }
 
+// GetLengthParams returns the parameters for a built-in length or one defined in lengths.
+func GetLengthParams(length types.OutputLength, lengths *config.Lengths) (types.LengthParams, bool) {
+	if params, ok := LengthParams[length]; ok {
+		return params, true
+	}
+	return lengths.Get(length)
+}
+
 // BuildRequest builds a completion request with the given parameters.
-// maxTokensOverride, if > 0, replaces the default max_tokens for the given length.
-func BuildRequest(modelAlias, systemPrompt, userPrompt string, length types.OutputLength, temperature float64, stream bool, customModels map[string]config.Model, maxTokensOverride int) (types.CompletionRequest, error) {
-	modelID, err := config.GetModelID(modelAlias, customModels)
+// Non-zero fields of opts replace the defaults for the given length and temperature.
+func BuildRequest(modelAlias, systemPrompt, userPrompt string, length types.OutputLength, temperature float64, stream bool, customModels map[string]config.Model, lengths *config.Lengths, opts types.RequestOptions) (types.CompletionRequest, error) {
+	model, err := config.ResolveModel(modelAlias, customModels)
 	if err != nil {
 		return types.CompletionRequest{}, fmt.Errorf("failed to resolve model: %w", err)
 	}
+	modelID := model.ID
+	if modelID == "" {
+		return types.CompletionRequest{}, fmt.Errorf("failed to resolve model: model %s has no ID", modelAlias)
+	}
 
-	lengthParams, ok := LengthParams[length]
+	lengthParams, ok := GetLengthParams(length, lengths)
 	if !ok {
 		return types.CompletionRequest{}, fmt.Errorf("invalid output length: %s", length)
 	}
 
 	maxTokens := lengthParams.MaxTokens
-	if maxTokensOverride > 0 {
-		maxTokens = maxTokensOverride
+	if opts.MaxTokens > 0 {
+		maxTokens = opts.MaxTokens
+	}
+	if model.MaxOutputTokens > 0 {
+		maxTokens = min(maxTokens, model.MaxOutputTokens)
+	}
+	if opts.Temperature != nil {
+		temperature = *opts.Temperature
+	}
+
+	// Models without structured outputs are asked for the schema in the system prompt
+	var responseFormat *types.ResponseFormat
+	if opts.Schema != nil {
+		if config.Enabled(model.StructuredOutputs) {
+			responseFormat = &types.ResponseFormat{
+				Type:       "json_schema",
+				JSONSchema: &types.JSONSchemaSpec{Name: "response", Schema: opts.Schema},
+			}
+		} else {
+			instructions, err := schemaInstructions(opts.Schema)
+			if err != nil {
+				return types.CompletionRequest{}, err
+			}
+			systemPrompt = strings.TrimRight(systemPrompt, "\n") + "\n\n" + instructions
+		}
+	}
+
+	if config.Disabled(model.Images) && hasImages(opts.Attachments) {
+		return types.CompletionRequest{}, fmt.Errorf("model %s doesn't accept images; use one that does, such as openai-gpt5-nano", modelAlias)
 	}
 
 	messages := []types.Message{
@@ -50,40 +91,104 @@ func BuildRequest(modelAlias, systemPrompt, userPrompt string, length types.Outp
 			Role:    "system",
 			Content: systemPrompt,
 		},
-		{
-			Role:    "user",
-			Content: userPrompt,
-		},
+	}
+	for _, example := range opts.Examples {
+		messages = append(messages,
+			types.Message{Role: "user", Content: example.Input},
+			types.Message{Role: "assistant", Content: example.Output},
+		)
+	}
+	messages = append(messages, types.Message{
+		Role:    "user",
+		Content: userPrompt,
+		Parts:   opts.Attachments,
+	})
+
+	// Leave room in the context window for the reply
+	if model.ContextWindow > 0 {
+		input := estimateTokens(messages)
+		if input >= model.ContextWindow {
+			return types.CompletionRequest{}, fmt.Errorf("the request is about %d tokens, more than the %d-token context window of %s", input, model.ContextWindow, modelAlias)
+		}
+		maxTokens = min(maxTokens, model.ContextWindow-input)
 	}
 
 	req := types.CompletionRequest{
-		Model:       modelID,
-		Messages:    messages,
-		MaxTokens:   maxTokens,
-		Temperature: temperature,
-		Stream:      stream,
+		Model:          modelID,
+		Messages:       messages,
+		MaxTokens:      maxTokens,
+		Temperature:    temperature,
+		Stop:           opts.Stop,
+		Stream:         stream,
+		ResponseFormat: responseFormat,
+	}
+	if stream && config.Enabled(model.StreamingUsage) {
+		req.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+	}
+
+	// Reasoning settings come as a pair from the options (a prompt or flag), else
+	// the model's configuration. Models known not to reason get none, so a prompt's
+	// settings don't break them; callers warn about flags once, not per request.
+	effort, reasoningTokens := opts.ReasoningEffort, opts.ReasoningMaxTokens
+	if effort == "" && reasoningTokens == 0 {
+		effort, reasoningTokens = model.ReasoningEffort, model.ReasoningMaxTokens
+	}
+	if config.Disabled(model.Reasoning) {
+		effort, reasoningTokens = "", 0
 	}
 
-	// GPT-5 models account for reasoning tokens inside completion tokens.
-	// Using max_completion_tokens and lower reasoning effort avoids empty
-	// visible outputs caused by reasoning consuming the full budget.
-	if isGPT5Model(modelID) {
+	// Some models, such as GPT-5, account for reasoning tokens inside
+	// max_completion_tokens, so a reasoning budget is added to the output's.
+	if config.Enabled(model.UsesMaxCompletionTokens) {
 		req.MaxTokens = 0
-		req.MaxCompletionTokens = maxTokens
-		req.ReasoningEffort = "minimal"
+		req.MaxCompletionTokens = maxTokens + reasoningTokens
+	}
+
+	// A token budget replaces the effort; OpenRouter accepts only one of them
+	if reasoningTokens > 0 {
+		req.Reasoning = &types.Reasoning{MaxTokens: reasoningTokens}
+	} else {
+		req.ReasoningEffort = effort
 	}
 
 	return req, nil
 }
 
-func isGPT5Model(modelID string) bool {
-	modelID = strings.ToLower(modelID)
-	return strings.HasPrefix(modelID, "openai/gpt-5") || strings.HasPrefix(modelID, "gpt-5")
+// hasImages reports whether parts include an image.
+func hasImages(parts []types.ContentPart) bool {
+	for _, part := range parts {
+		if part.Type == "image_url" {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateTokens estimates the tokens of the text in messages at four characters
+// each.
+func estimateTokens(messages []types.Message) int {
+	chars := 0
+	for _, message := range messages {
+		chars += utf8.RuneCountInString(message.Content)
+		for _, part := range message.Parts {
+			chars += utf8.RuneCountInString(part.Text)
+		}
+	}
+	return chars / 4
+}
+
+// schemaInstructions asks for JSON matching schema in the system prompt.
+func schemaInstructions(schema map[string]any) (string, error) {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return "Respond with ONLY a JSON value that matches this JSON Schema. Do not wrap it in a code block or add any other text.\n\n" + string(data), nil
 }
 
 // GetLengthDirective returns the directive for a given output length
-func GetLengthDirective(length types.OutputLength) (string, error) {
-	params, ok := LengthParams[length]
+func GetLengthDirective(length types.OutputLength, lengths *config.Lengths) (string, error) {
+	params, ok := GetLengthParams(length, lengths)
 	if !ok {
 		return "", fmt.Errorf("invalid output length: %s", length)
 	}
@@ -4,6 +4,9 @@ Copyright © 2026 Raypaste
 package llm
 
 import (
+	"encoding/json"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/raypaste/raypaste-cli/internal/config"
@@ -24,7 +27,7 @@ func TestGetLengthDirective(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := GetLengthDirective(tt.length)
+			got, err := GetLengthDirective(tt.length, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetLengthDirective() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -44,7 +47,7 @@ func TestBuildRequest(t *testing.T) {
 			Tier:     "fast",
 		},
 		"test-gpt5": {
-			ID:       "openai/gpt-5-nano",
+			ID:       "openai/gpt-5",
 			Provider: "openai",
 			Tier:     "fast",
 		},
@@ -64,10 +67,12 @@ func TestBuildRequest(t *testing.T) {
 		{"short length", "test-model", "test/model", types.OutputLengthShort, 0, 550, 0, "", false},
 		{"medium length", "test-model", "test/model", types.OutputLengthMedium, 0, 850, 0, "", false},
 		{"long length", "test-model", "test/model", types.OutputLengthLong, 0, 1600, 0, "", false},
-		{"gpt5 medium length", "test-gpt5", "openai/gpt-5-nano", types.OutputLengthMedium, 0, 0, 850, "minimal", false},
+		{"gpt5 medium length", "test-gpt5", "openai/gpt-5", types.OutputLengthMedium, 0, 0, 850, "minimal", false},
+		{"direct gpt5 id", "openai/gpt-5-mini", "openai/gpt-5-mini", types.OutputLengthMedium, 0, 0, 850, "minimal", false},
+		{"direct gpt5 id without a provider", "gpt-5-nano", "gpt-5-nano", types.OutputLengthMedium, 0, 0, 850, "minimal", false},
 		{"invalid length", "test-model", "", types.OutputLength("invalid"), 0, 0, 0, "", true},
 		{"numeric override short", "test-model", "test/model", types.OutputLengthShort, 200, 200, 0, "", false},
-		{"numeric override gpt5", "test-gpt5", "openai/gpt-5-nano", types.OutputLengthMedium, 500, 0, 500, "minimal", false},
+		{"numeric override gpt5", "test-gpt5", "openai/gpt-5", types.OutputLengthMedium, 500, 0, 500, "minimal", false},
 	}
 
 	for _, tt := range tests {
@@ -80,7 +85,8 @@ func TestBuildRequest(t *testing.T) {
 				0.7,
 				false,
 				customModels,
-				tt.maxTokensOverride,
+				nil,
+				types.RequestOptions{MaxTokens: tt.maxTokensOverride},
 			)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("BuildRequest() error = %v, wantErr %v", err, tt.wantErr)
@@ -107,22 +113,244 @@ func TestBuildRequest(t *testing.T) {
 	}
 }
 
-func TestIsGPT5Model(t *testing.T) {
+func TestBuildRequestZeroTemperature(t *testing.T) {
+	zero := 0.0
 	tests := []struct {
-		modelID string
-		want    bool
+		name        string
+		temperature float64
+		opts        types.RequestOptions
 	}{
-		{"openai/gpt-5-nano", true},
-		{"openai/gpt-5", true},
-		{"gpt-5-nano", true},
-		{"openai/gpt-4.1-nano", false},
-		{"meta-llama/llama-3.1-8b-instruct", false},
+		{"configured", 0, types.RequestOptions{}},
+		{"option", 0.7, types.RequestOptions{Temperature: &zero}},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.modelID, func(t *testing.T) {
-			if got := isGPT5Model(tt.modelID); got != tt.want {
-				t.Errorf("isGPT5Model() = %v, want %v", got, tt.want)
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := BuildRequest("cerebras-llama-8b", "system", "user", types.OutputLengthShort, tt.temperature, false, nil, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			data, err := json.Marshal(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sent map[string]any
+			if err := json.Unmarshal(data, &sent); err != nil {
+				t.Fatal(err)
+			}
+			if temperature, ok := sent["temperature"]; !ok || temperature != 0.0 {
+				t.Errorf("marshalled request = %s, want temperature 0", data)
+			}
+		})
+	}
+}
+
+func TestBuildRequestOptions(t *testing.T) {
+	temperature := 0.2
+	tests := []struct {
+		name       string
+		model      string
+		opts       types.RequestOptions
+		wantTemp   float64
+		wantEffort string
+		wantStop   int
+	}{
+		{"no options", "cerebras-llama-8b", types.RequestOptions{}, 0.7, "", 0},
+		{"temperature and stop", "cerebras-llama-8b", types.RequestOptions{Temperature: &temperature, Stop: []string{"\n\n", "END"}}, 0.2, "", 2},
+		{"reasoning effort", "cerebras-gpt-oss-120b", types.RequestOptions{ReasoningEffort: "high"}, 0.7, "high", 0},
+		{"reasoning effort replaces gpt5 default", "openai-gpt5-nano", types.RequestOptions{ReasoningEffort: "low"}, 0.7, "low", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := BuildRequest(tt.model, "system", "user", types.OutputLengthMedium, 0.7, false, nil, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			if req.Temperature != tt.wantTemp {
+				t.Errorf("Temperature = %v, want %v", req.Temperature, tt.wantTemp)
+			}
+			if req.ReasoningEffort != tt.wantEffort {
+				t.Errorf("ReasoningEffort = %q, want %q", req.ReasoningEffort, tt.wantEffort)
+			}
+			if len(req.Stop) != tt.wantStop {
+				t.Errorf("Stop = %v, want %d entries", req.Stop, tt.wantStop)
+			}
+		})
+	}
+}
+
+func TestBuildRequestReasoning(t *testing.T) {
+	customModels := map[string]config.Model{
+		"thinker":        {ID: "test/thinker", ReasoningEffort: "high"},
+		"budget":         {ID: "test/budget", ReasoningMaxTokens: 2000},
+		"gpt5-budget":    {ID: "openai/gpt-5-mini", ReasoningMaxTokens: 1000},
+		"openai-gpt5-lo": {ID: "openai/gpt-5-nano", ReasoningEffort: "low"},
+		"plain":          {ID: "test/plain"},
+		"no-reasoning":   {ID: "test/no-reasoning", Reasoning: config.Bool(false)},
+	}
+
+	tests := []struct {
+		name          string
+		model         string
+		opts          types.RequestOptions
+		wantEffort    string
+		wantBudget    int
+		wantMaxTokens int
+	}{
+		{"model effort", "thinker", types.RequestOptions{}, "high", 0, 850},
+		{"model budget", "budget", types.RequestOptions{}, "", 2000, 850},
+		{"options replace the model's effort", "thinker", types.RequestOptions{ReasoningEffort: "low"}, "low", 0, 850},
+		{"options budget replaces the model's effort", "thinker", types.RequestOptions{ReasoningMaxTokens: 500}, "", 500, 850},
+		{"options effort replaces the model's budget", "budget", types.RequestOptions{ReasoningEffort: "medium"}, "medium", 0, 850},
+		{"built-in gpt5 default", "openai-gpt5-nano", types.RequestOptions{}, "minimal", 0, 850},
+		{"configured gpt5 effort", "openai-gpt5-lo", types.RequestOptions{}, "low", 0, 850},
+		{"gpt5 budget adds to the completion tokens", "gpt5-budget", types.RequestOptions{}, "", 1000, 1850},
+		{"no reasoning", "cerebras-llama-8b", types.RequestOptions{}, "", 0, 850},
+		{"options ignored without reasoning", "cerebras-llama-8b", types.RequestOptions{ReasoningEffort: "high"}, "", 0, 850},
+		{"options sent to an unknown model", "some/model", types.RequestOptions{ReasoningEffort: "high"}, "high", 0, 850},
+		{"options sent to a custom model without capabilities", "plain", types.RequestOptions{ReasoningEffort: "high"}, "high", 0, 850},
+		{"options ignored by a custom model without reasoning", "no-reasoning", types.RequestOptions{ReasoningMaxTokens: 500}, "", 0, 850},
+		{"direct id has a configured model's capabilities", "openai/gpt-5-nano", types.RequestOptions{}, "low", 0, 850},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := BuildRequest(tt.model, "system", "user", types.OutputLengthMedium, 0.7, false, customModels, nil, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			if req.ReasoningEffort != tt.wantEffort {
+				t.Errorf("ReasoningEffort = %q, want %q", req.ReasoningEffort, tt.wantEffort)
+			}
+			budget := 0
+			if req.Reasoning != nil {
+				budget = req.Reasoning.MaxTokens
+			}
+			if budget != tt.wantBudget {
+				t.Errorf("reasoning max tokens = %d, want %d", budget, tt.wantBudget)
+			}
+			if got := req.MaxTokens + req.MaxCompletionTokens; got != tt.wantMaxTokens {
+				t.Errorf("max tokens = %d, want %d", got, tt.wantMaxTokens)
+			}
+		})
+	}
+}
+
+func TestBuildRequestExamples(t *testing.T) {
+	opts := types.RequestOptions{Examples: []types.Example{
+		{Input: "in 1", Output: "out 1"},
+		{Input: "in 2", Output: "out 2"},
+	}}
+	req, err := BuildRequest("cerebras-llama-8b", "system", "real input", types.OutputLengthShort, 0.7, false, nil, nil, opts)
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+
+	want := []types.Message{
+		{Role: "system", Content: "system"},
+		{Role: "user", Content: "in 1"},
+		{Role: "assistant", Content: "out 1"},
+		{Role: "user", Content: "in 2"},
+		{Role: "assistant", Content: "out 2"},
+		{Role: "user", Content: "real input"},
+	}
+	if len(req.Messages) != len(want) {
+		t.Fatalf("Messages = %+v, want %d messages", req.Messages, len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(req.Messages[i], want[i]) {
+			t.Errorf("Messages[%d] = %+v, want %+v", i, req.Messages[i], want[i])
+		}
+	}
+}
+
+func TestBuildRequestSchema(t *testing.T) {
+	schema := map[string]any{"type": "object", "required": []any{"tags"}}
+	tests := []struct {
+		name               string
+		model              string
+		wantResponseFormat bool
+	}{
+		{"structured outputs", "openai-gpt5-nano", true},
+		{"system prompt instructions", "cerebras-llama-8b", false},
+		{"unknown model", "some/model", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := BuildRequest(tt.model, "system", "input", types.OutputLengthShort, 0.7, false, nil, nil, types.RequestOptions{Schema: schema})
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			system := req.Messages[0].Content
+			if tt.wantResponseFormat {
+				if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.JSONSchema.Schema["type"] != "object" {
+					t.Errorf("ResponseFormat = %+v, want the schema", req.ResponseFormat)
+				}
+				if system != "system" {
+					t.Errorf("system prompt = %q, want it unchanged", system)
+				}
+				return
+			}
+			if req.ResponseFormat != nil {
+				t.Errorf("ResponseFormat = %+v, want nil", req.ResponseFormat)
+			}
+			if !strings.HasPrefix(system, "system\n\n") || !strings.Contains(system, `"required": [`) {
+				t.Errorf("system prompt = %q, want the schema appended", system)
+			}
+		})
+	}
+}
+
+func TestBuildRequestCapabilities(t *testing.T) {
+	image := types.ContentPart{Type: "image_url", ImageURL: &types.ImageURL{URL: "data:image/png;base64,AA=="}}
+	customModels := map[string]config.Model{
+		"tiny": {ID: "test/tiny", ContextWindow: 1000, MaxOutputTokens: 600},
+	}
+
+	tests := []struct {
+		name          string
+		model         string
+		input         string
+		stream        bool
+		opts          types.RequestOptions
+		wantMaxTokens int
+		wantUsage     bool
+		wantErr       string
+	}{
+		{"output capped by the model", "tiny", "input", false, types.RequestOptions{MaxTokens: 900}, 600, false, ""},
+		{"output capped by the context window", "tiny", strings.Repeat("word ", 640), false, types.RequestOptions{}, 199, false, ""},
+		{"input over the context window", "tiny", strings.Repeat("word ", 800), false, types.RequestOptions{}, 0, false, "1000-token context window of tiny"},
+		{"streaming usage", "cerebras-llama-8b", "input", true, types.RequestOptions{}, 850, true, ""},
+		{"no streaming usage without streaming", "cerebras-llama-8b", "input", false, types.RequestOptions{}, 850, false, ""},
+		{"no streaming usage for an unknown model", "some/model", "input", true, types.RequestOptions{}, 850, false, ""},
+		{"images", "openai-gpt5-nano", "input", false, types.RequestOptions{Attachments: []types.ContentPart{image}}, 850, false, ""},
+		{"images to an unknown model", "some/model", "input", false, types.RequestOptions{Attachments: []types.ContentPart{image}}, 850, false, ""},
+		{"images to a custom model without capabilities", "tiny", "input", false, types.RequestOptions{Attachments: []types.ContentPart{image}}, 600, false, ""},
+		{"images to a model without them", "cerebras-llama-8b", "input", false, types.RequestOptions{Attachments: []types.ContentPart{image}}, 0, false, "doesn't accept images"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := BuildRequest(tt.model, "system", tt.input, types.OutputLengthMedium, 0.7, tt.stream, customModels, nil, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("BuildRequest() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildRequest() error = %v", err)
+			}
+			if got := req.MaxTokens + req.MaxCompletionTokens; got != tt.wantMaxTokens {
+				t.Errorf("max tokens = %d, want %d", got, tt.wantMaxTokens)
+			}
+			if got := req.StreamOptions != nil && req.StreamOptions.IncludeUsage; got != tt.wantUsage {
+				t.Errorf("stream usage = %v, want %v", got, tt.wantUsage)
+			}
+			if last := req.Messages[len(req.Messages)-1]; len(last.Parts) != len(tt.opts.Attachments) {
+				t.Errorf("user message parts = %+v, want the attachments", last.Parts)
 			}
 		})
 	}
@@ -152,3 +380,27 @@ func TestLengthParams(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLengthParamsCustom(t *testing.T) {
+	haiku := types.LengthParams{MaxTokens: 60, Directive: "Answer as a haiku."}
+	lengths := config.NewLengths()
+	if err := lengths.Define("", map[string]types.LengthParams{"haiku": haiku}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := GetLengthParams("haiku", lengths); !ok || got != haiku {
+		t.Errorf("GetLengthParams() = %v, %v, want %v, true", got, ok, haiku)
+	}
+	if _, ok := GetLengthParams("unknown", lengths); ok {
+		t.Error("GetLengthParams() should not find an unregistered length")
+	}
+
+	customModels := map[string]config.Model{"test-model": {ID: "test/model", Provider: "test", Tier: "fast"}}
+	req, err := BuildRequest("test-model", "sys", "user", "haiku", 0.7, false, customModels, lengths, types.RequestOptions{})
+	if err != nil {
+		t.Fatalf("BuildRequest() error = %v", err)
+	}
+	if req.MaxTokens != haiku.MaxTokens {
+		t.Errorf("BuildRequest() MaxTokens = %d, want %d", req.MaxTokens, haiku.MaxTokens)
+	}
+}
@@ -26,7 +26,20 @@ type streamChoiceCompat struct {
 }
 
 type streamMessageCompat struct {
-	Content json.RawMessage `json:"content,omitempty"`
+	Content   json.RawMessage       `json:"content,omitempty"`
+	ToolCalls []types.ToolCallDelta `json:"tool_calls,omitempty"`
+	// Reasoning is OpenRouter's field for reasoning tokens; some providers use
+	// ReasoningContent instead.
+	Reasoning        json.RawMessage `json:"reasoning,omitempty"`
+	ReasoningContent json.RawMessage `json:"reasoning_content,omitempty"`
+}
+
+// reasoning returns the reasoning text of m.
+func (m streamMessageCompat) reasoning() string {
+	if text := extractStreamContent(m.Reasoning); text != "" {
+		return text
+	}
+	return extractStreamContent(m.ReasoningContent)
 }
 
 // processStreamingResponse processes Server-Sent Events (SSE) from the streaming response.
@@ -151,8 +164,19 @@ func extractStreamContentFromValue(value interface{}) string {
 // processStreamingResponseWithUsage processes Server-Sent Events (SSE) from the streaming response
 // and captures token usage from the final chunk.
 func processStreamingResponseWithUsage(body io.Reader, callback func(string) error) (types.TokenUsage, error) {
+	_, usage, err := processStreamingMessage(body, callback, nil)
+	return usage, err
+}
+
+// processStreamingMessage processes Server-Sent Events (SSE) from the streaming response,
+// calling callback for each content token and, when set, onReasoning for each reasoning
+// token. It returns the assembled assistant message, including any tool calls and
+// reasoning, with the token usage from the final chunk.
+func processStreamingMessage(body io.Reader, callback, onReasoning func(string) error) (types.Message, types.TokenUsage, error) {
 	scanner := bufio.NewScanner(body)
 	var usage types.TokenUsage
+	var content, reasoning strings.Builder
+	var toolCalls []types.ToolCall
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -185,7 +209,7 @@ func processStreamingResponseWithUsage(body io.Reader, callback func(string) err
 
 		// Check for mid-stream error from OpenRouter.
 		if chunk.Error != nil {
-			return usage, fmt.Errorf("stream error from API: %s", chunk.Error.Message)
+			return types.Message{}, usage, fmt.Errorf("stream error from API: %s", chunk.Error.Message)
 		}
 
 		// Capture usage data if present (usually in final chunk)
@@ -196,26 +220,67 @@ func processStreamingResponseWithUsage(body io.Reader, callback func(string) err
 		for _, choice := range chunk.Choices {
 			// Check for error termination via finish_reason
 			if choice.FinishReason == "error" {
-				return usage, fmt.Errorf("stream terminated with error finish_reason")
+				return types.Message{}, usage, fmt.Errorf("stream terminated with error finish_reason")
+			}
+
+			thought := choice.Delta.reasoning()
+			if thought == "" {
+				thought = choice.Message.reasoning()
+			}
+			if thought != "" {
+				reasoning.WriteString(thought)
+				if onReasoning != nil {
+					if err := onReasoning(thought); err != nil {
+						return types.Message{}, usage, fmt.Errorf("callback error: %w", err)
+					}
+				}
 			}
 
 			// Prefer delta content. Some providers send content in message.content.
-			content := extractStreamContent(choice.Delta.Content)
-			if content == "" {
-				content = extractStreamContent(choice.Message.Content)
+			token := extractStreamContent(choice.Delta.Content)
+			if token == "" {
+				token = extractStreamContent(choice.Message.Content)
 			}
 
-			if content != "" {
-				if err := callback(content); err != nil {
-					return usage, fmt.Errorf("callback error: %w", err)
+			if token != "" {
+				content.WriteString(token)
+				if err := callback(token); err != nil {
+					return types.Message{}, usage, fmt.Errorf("callback error: %w", err)
 				}
 			}
+
+			for _, delta := range choice.Delta.ToolCalls {
+				toolCalls = mergeToolCallDelta(toolCalls, delta)
+			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return usage, fmt.Errorf("error reading stream: %w", err)
+		return types.Message{}, usage, fmt.Errorf("error reading stream: %w", err)
 	}
 
-	return usage, nil
+	return types.Message{Role: "assistant", Content: content.String(), ToolCalls: toolCalls, Reasoning: reasoning.String()}, usage, nil
+}
+
+// mergeToolCallDelta adds a streamed tool call fragment to calls. The first fragment
+// of a call carries its ID and name; later ones append to its arguments.
+func mergeToolCallDelta(calls []types.ToolCall, delta types.ToolCallDelta) []types.ToolCall {
+	if delta.Index < 0 {
+		return calls
+	}
+	for len(calls) <= delta.Index {
+		calls = append(calls, types.ToolCall{Type: "function"})
+	}
+	call := &calls[delta.Index]
+	if delta.ID != "" {
+		call.ID = delta.ID
+	}
+	if delta.Type != "" {
+		call.Type = delta.Type
+	}
+	if delta.Function.Name != "" {
+		call.Function.Name = delta.Function.Name
+	}
+	call.Function.Arguments += delta.Function.Arguments
+	return calls
 }
@@ -85,3 +85,69 @@ func TestProcessStreamingResponse_StreamError(t *testing.T) {
 		t.Fatalf("processStreamingResponse() error = %q, want contains %q", err.Error(), "provider failed")
 	}
 }
+
+func TestProcessStreamingMessage_ToolCallDeltas(t *testing.T) {
+	stream := strings.NewReader(strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant","content":"Checking."}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"read_file","arguments":""}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"path\":"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":1,"id":"call_2","type":"function","function":{"name":"list_directory","arguments":"{}"}}]}}]}`,
+		`data: {"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"go.mod\"}"}}]}}]}`,
+		`data: {"choices":[{"delta":{},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+		`data: [DONE]`,
+	}, "\n"))
+
+	var got strings.Builder
+	message, usage, err := processStreamingMessage(stream, func(token string) error {
+		got.WriteString(token)
+		return nil
+	}, nil)
+	if err != nil {
+		t.Fatalf("processStreamingMessage() error = %v", err)
+	}
+
+	if got.String() != "Checking." || message.Content != "Checking." {
+		t.Errorf("content = %q (streamed %q), want %q", message.Content, got.String(), "Checking.")
+	}
+	if usage.TotalTokens != 8 {
+		t.Errorf("usage = %+v, want 8 total tokens", usage)
+	}
+	if len(message.ToolCalls) != 2 {
+		t.Fatalf("ToolCalls = %+v, want 2 calls", message.ToolCalls)
+	}
+	first, second := message.ToolCalls[0], message.ToolCalls[1]
+	if first.ID != "call_1" || first.Function.Name != "read_file" || first.Function.Arguments != `{"path":"go.mod"}` {
+		t.Errorf("ToolCalls[0] = %+v", first)
+	}
+	if second.ID != "call_2" || second.Function.Name != "list_directory" || second.Function.Arguments != "{}" {
+		t.Errorf("ToolCalls[1] = %+v", second)
+	}
+}
+
+func TestProcessStreamingMessage_Reasoning(t *testing.T) {
+	stream := strings.NewReader(strings.Join([]string{
+		`data: {"choices":[{"delta":{"role":"assistant","content":"","reasoning":"The user wants "}}]}`,
+		`data: {"choices":[{"delta":{"reasoning_content":"a haiku."}}]}`,
+		`data: {"choices":[{"delta":{"content":"Autumn moon"}}]}`,
+		`data: [DONE]`,
+	}, "\n"))
+
+	var content, reasoning strings.Builder
+	message, _, err := processStreamingMessage(stream, func(token string) error {
+		content.WriteString(token)
+		return nil
+	}, func(token string) error {
+		reasoning.WriteString(token)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("processStreamingMessage() error = %v", err)
+	}
+
+	if content.String() != "Autumn moon" || message.Content != "Autumn moon" {
+		t.Errorf("content = %q (streamed %q), want only the answer", message.Content, content.String())
+	}
+	if reasoning.String() != "The user wants a haiku." || message.Reasoning != reasoning.String() {
+		t.Errorf("reasoning = %q (streamed %q), want it kept apart from the content", message.Reasoning, reasoning.String())
+	}
+}
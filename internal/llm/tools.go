@@ -0,0 +1,175 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// DefaultMaxToolRounds is how many rounds of tool calls RunTools allows before it
+// asks the model to answer without tools.
+const DefaultMaxToolRounds = 8
+
+// ToolHandler runs a tool with the JSON arguments the model sent and returns the
+// result to send back. An error is reported to the model, which can try again.
+type ToolHandler func(ctx context.Context, arguments json.RawMessage) (string, error)
+
+// Tool is a Go function the model may call.
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON Schema of the tool's arguments.
+	Parameters map[string]any
+	Handler    ToolHandler
+}
+
+// Toolbox holds the tools available to a conversation.
+type Toolbox struct {
+	tools []Tool
+}
+
+// NewToolbox returns a toolbox holding tools.
+func NewToolbox(tools ...Tool) *Toolbox {
+	t := &Toolbox{}
+	for _, tool := range tools {
+		t.Register(tool)
+	}
+	return t
+}
+
+// Register adds tool, replacing any tool with the same name.
+func (t *Toolbox) Register(tool Tool) {
+	i := slices.IndexFunc(t.tools, func(existing Tool) bool { return existing.Name == tool.Name })
+	if i >= 0 {
+		t.tools[i] = tool
+		return
+	}
+	t.tools = append(t.tools, tool)
+}
+
+// Definitions returns the tools in the form sent with a completion request.
+func (t *Toolbox) Definitions() []types.Tool {
+	defs := make([]types.Tool, 0, len(t.tools))
+	for _, tool := range t.tools {
+		defs = append(defs, types.Tool{
+			Type: "function",
+			Function: types.ToolFunction{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// call runs the tool call asks for and returns the result to send back.
+func (t *Toolbox) call(ctx context.Context, call types.ToolCall) string {
+	i := slices.IndexFunc(t.tools, func(tool Tool) bool { return tool.Name == call.Function.Name })
+	if i < 0 {
+		return fmt.Sprintf("error: unknown tool %q", call.Function.Name)
+	}
+
+	arguments := json.RawMessage(call.Function.Arguments)
+	if len(arguments) == 0 {
+		arguments = json.RawMessage("{}")
+	}
+	if !json.Valid(arguments) {
+		return "error: arguments are not valid JSON"
+	}
+
+	result, err := t.tools[i].Handler(ctx, arguments)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return result
+}
+
+// MessageCompleter sends completion requests and returns the whole assistant message,
+// including tool calls. Client implements it.
+type MessageCompleter interface {
+	CompleteMessage(ctx context.Context, req types.CompletionRequest) (types.Message, types.TokenUsage, error)
+	StreamCompleteMessage(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.Message, types.TokenUsage, error)
+}
+
+// ReasoningStreamer streams a completion's reasoning separately from its content.
+// Client implements it.
+type ReasoningStreamer interface {
+	StreamCompleteReasoning(ctx context.Context, req types.CompletionRequest, callback, onReasoning func(string) error) (types.Message, types.TokenUsage, error)
+}
+
+// ToolLoopOptions controls RunTools.
+type ToolLoopOptions struct {
+	// MaxRounds caps the rounds of tool calls; DefaultMaxToolRounds is used when 0.
+	MaxRounds int
+	// OnToken, when set, streams the content of every response, including any text
+	// the model sends alongside its tool calls.
+	OnToken func(string) error
+	// OnReasoning, when set with OnToken, receives the reasoning tokens of every
+	// response if the client is a ReasoningStreamer.
+	OnReasoning func(string) error
+	// OnToolCall, when set, is called after each tool runs with the call and the
+	// result sent back to the model.
+	OnToolCall func(call types.ToolCall, result string)
+}
+
+// RunTools sends req with the toolbox's tools. Each time the model replies with tool
+// calls, their handlers run and the results are added to the conversation, which is
+// sent again. It returns the content of the first reply without tool calls and the
+// usage of every request. Once MaxRounds rounds have run, the model is asked to
+// answer without calling more tools.
+func RunTools(ctx context.Context, client MessageCompleter, req types.CompletionRequest, tools *Toolbox, opts ToolLoopOptions) (string, types.TokenUsage, error) {
+	maxRounds := opts.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = DefaultMaxToolRounds
+	}
+
+	req.Tools = tools.Definitions()
+	req.Messages = slices.Clip(req.Messages)
+
+	reasoningClient, streamsReasoning := client.(ReasoningStreamer)
+	var total types.TokenUsage
+	for round := 0; ; round++ {
+		if round == maxRounds {
+			req.ToolChoice = "none"
+		}
+
+		var message types.Message
+		var usage types.TokenUsage
+		var err error
+		switch {
+		case opts.OnToken != nil && opts.OnReasoning != nil && streamsReasoning:
+			message, usage, err = reasoningClient.StreamCompleteReasoning(ctx, req, opts.OnToken, opts.OnReasoning)
+		case opts.OnToken != nil:
+			message, usage, err = client.StreamCompleteMessage(ctx, req, opts.OnToken)
+		default:
+			message, usage, err = client.CompleteMessage(ctx, req)
+		}
+		total.PromptTokens += usage.PromptTokens
+		total.CompletionTokens += usage.CompletionTokens
+		total.TotalTokens += usage.TotalTokens
+		if err != nil {
+			return "", total, err
+		}
+
+		if len(message.ToolCalls) == 0 || round == maxRounds {
+			return message.Content, total, nil
+		}
+
+		message.Role = "assistant"
+		req.Messages = append(req.Messages, message)
+		for _, call := range message.ToolCalls {
+			result := tools.call(ctx, call)
+			if opts.OnToolCall != nil {
+				opts.OnToolCall(call, result)
+			}
+			req.Messages = append(req.Messages, types.Message{Role: "tool", ToolCallID: call.ID, Content: result})
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// scriptedMessages replies with its messages in order and records every request.
+type scriptedMessages struct {
+	replies  []types.Message
+	requests []types.CompletionRequest
+}
+
+func (s *scriptedMessages) CompleteMessage(_ context.Context, req types.CompletionRequest) (types.Message, types.TokenUsage, error) {
+	s.requests = append(s.requests, req)
+	if len(s.replies) == 0 {
+		return types.Message{}, types.TokenUsage{}, errors.New("no reply scripted")
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return reply, types.TokenUsage{PromptTokens: 4, CompletionTokens: 1, TotalTokens: 5}, nil
+}
+
+func (s *scriptedMessages) StreamCompleteMessage(ctx context.Context, req types.CompletionRequest, callback func(string) error) (types.Message, types.TokenUsage, error) {
+	message, usage, err := s.CompleteMessage(ctx, req)
+	if err == nil && message.Content != "" {
+		err = callback(message.Content)
+	}
+	return message, usage, err
+}
+
+func toolCall(id, name, arguments string) types.ToolCall {
+	return types.ToolCall{ID: id, Type: "function", Function: types.ToolCallFunction{Name: name, Arguments: arguments}}
+}
+
+func TestRunTools(t *testing.T) {
+	echo := Tool{
+		Name:       "echo",
+		Parameters: map[string]any{"type": "object"},
+		Handler: func(_ context.Context, arguments json.RawMessage) (string, error) {
+			var args struct{ Text string }
+			if err := json.Unmarshal(arguments, &args); err != nil {
+				return "", err
+			}
+			if args.Text == "" {
+				return "", errors.New("text is required")
+			}
+			return "echo: " + args.Text, nil
+		},
+	}
+
+	client := &scriptedMessages{replies: []types.Message{
+		{Role: "assistant", ToolCalls: []types.ToolCall{
+			toolCall("1", "echo", `{"text":"hi"}`),
+			toolCall("2", "missing", `{}`),
+			toolCall("3", "echo", `{}`),
+		}},
+		{Role: "assistant", Content: "done"},
+	}}
+	req := types.CompletionRequest{Model: "m", Messages: []types.Message{{Role: "user", Content: "go"}}}
+
+	var calls []string
+	var streamed strings.Builder
+	output, usage, err := RunTools(context.Background(), client, req, NewToolbox(echo), ToolLoopOptions{
+		OnToken: func(token string) error {
+			streamed.WriteString(token)
+			return nil
+		},
+		OnToolCall: func(call types.ToolCall, result string) { calls = append(calls, result) },
+	})
+	if err != nil {
+		t.Fatalf("RunTools() error = %v", err)
+	}
+
+	if output != "done" || streamed.String() != "done" {
+		t.Errorf("output = %q (streamed %q), want %q", output, streamed.String(), "done")
+	}
+	if usage.TotalTokens != 10 {
+		t.Errorf("usage = %+v, want the total of both requests", usage)
+	}
+	wantResults := []string{"echo: hi", `error: unknown tool "missing"`, "error: text is required"}
+	if strings.Join(calls, "|") != strings.Join(wantResults, "|") {
+		t.Errorf("tool results = %q, want %q", calls, wantResults)
+	}
+
+	first, second := client.requests[0], client.requests[1]
+	if len(first.Tools) != 1 || first.Tools[0].Function.Name != "echo" {
+		t.Errorf("first request tools = %+v, want echo", first.Tools)
+	}
+	if len(second.Messages) != 5 || second.Messages[1].Role != "assistant" || second.Messages[2].Role != "tool" || second.Messages[2].ToolCallID != "1" || second.Messages[2].Content != "echo: hi" {
+		t.Errorf("second request messages = %+v, want the tool calls and their results", second.Messages)
+	}
+	if len(req.Messages) != 1 {
+		t.Errorf("RunTools() modified the caller's messages: %+v", req.Messages)
+	}
+}
+
+func TestRunToolsMaxRounds(t *testing.T) {
+	noop := Tool{Name: "noop", Handler: func(context.Context, json.RawMessage) (string, error) { return "ok", nil }}
+	calling := types.Message{Role: "assistant", ToolCalls: []types.ToolCall{toolCall("1", "noop", "")}}
+	client := &scriptedMessages{replies: []types.Message{calling, calling, {Content: "answer"}}}
+
+	output, _, err := RunTools(context.Background(), client, types.CompletionRequest{}, NewToolbox(noop), ToolLoopOptions{MaxRounds: 2})
+	if err != nil {
+		t.Fatalf("RunTools() error = %v", err)
+	}
+	if output != "answer" || len(client.requests) != 3 {
+		t.Fatalf("output = %q after %d requests, want %q after 3", output, len(client.requests), "answer")
+	}
+	if client.requests[1].ToolChoice != "" || client.requests[2].ToolChoice != "none" {
+		t.Errorf("tool_choice = %q, %q, want only the last request to disable tools", client.requests[1].ToolChoice, client.requests[2].ToolChoice)
+	}
+}
+
+func TestProjectTools(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	for _, dir := range []string{"docs", ".ssh"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := map[string]string{
+		filepath.Join(root, "go.mod"):             "module example\n",
+		filepath.Join(root, "docs", "guide.md"):   "# Guide\n",
+		filepath.Join(root, "big.txt"):            strings.Repeat("a", maxToolFileBytes+10),
+		filepath.Join(outside, "secret"):          "secret",
+		filepath.Join(root, ".env"):               "secret",
+		filepath.Join(root, ".env.example"):       "API_KEY=\n",
+		filepath.Join(root, "docs", "Server.PEM"): "secret",
+		filepath.Join(root, ".ssh", "config"):     "secret",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(".env", filepath.Join(root, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	tools, err := ProjectTools(root, ProjectToolNames)
+	if err != nil {
+		t.Fatalf("ProjectTools() error = %v", err)
+	}
+	box := NewToolbox(tools...)
+
+	tests := []struct {
+		name string
+		call types.ToolCall
+		want string
+	}{
+		{"read file", toolCall("1", "read_file", `{"path":"go.mod"}`), "module example\n"},
+		{"read nested file", toolCall("2", "read_file", `{"path":"docs/guide.md"}`), "# Guide\n"},
+		{"truncate long file", toolCall("3", "read_file", `{"path":"big.txt"}`), "[truncated after 65536 bytes]"},
+		{"refuse parent directory", toolCall("4", "read_file", `{"path":"../secret"}`), "error: "},
+		{"refuse absolute path", toolCall("5", "read_file", `{"path":"`+filepath.Join(outside, "secret")+`"}`), "error: "},
+		{"refuse symlink out of root", toolCall("6", "read_file", `{"path":"link"}`), "error: "},
+		{"refuse env file", toolCall("7", "read_file", `{"path":".env"}`), "may contain secrets"},
+		{"refuse key regardless of case", toolCall("8", "read_file", `{"path":"docs/Server.PEM"}`), "may contain secrets"},
+		{"refuse file in secret directory", toolCall("9", "read_file", `{"path":".ssh/config"}`), "may contain secrets"},
+		{"refuse symlink to secret", toolCall("10", "read_file", `{"path":"notes.txt"}`), "may contain secrets"},
+		{"read env template", toolCall("11", "read_file", `{"path":".env.example"}`), "API_KEY=\n"},
+		{"list root", toolCall("12", "list_directory", `{"path":"."}`), ".env.example\nbig.txt\ndocs/\ngo.mod\nlink\nnotes.txt\n"},
+		{"list subdirectory", toolCall("13", "list_directory", `{"path":"docs"}`), "guide.md\n"},
+		{"refuse secret directory", toolCall("14", "list_directory", `{"path":".ssh"}`), "may contain secrets"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := box.call(context.Background(), tt.call)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("call() = %q, want containing %q", got, tt.want)
+			}
+			if strings.Contains(got, "secret") && !strings.HasPrefix(got, "error: ") {
+				t.Errorf("call() = %q read outside the root", got)
+			}
+		})
+	}
+
+	for path, want := range map[string]string{".": ".env.example\nbig.txt\ndocs/\ngo.mod\nlink\nnotes.txt\n", "docs": "guide.md\n"} {
+		if got := box.call(context.Background(), toolCall("15", "list_directory", `{"path":"`+path+`"}`)); got != want {
+			t.Errorf("list_directory(%s) = %q, want %q without secret files", path, got, want)
+		}
+	}
+
+	if _, err := ProjectTools(root, []string{"run_shell"}); err == nil {
+		t.Error("ProjectTools() should reject unknown tools")
+	}
+}
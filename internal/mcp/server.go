@@ -0,0 +1,678 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/prompts/defaults"
+)
+
+// ProtocolVersion is the latest MCP protocol revision implemented by this server.
+const ProtocolVersion = "2025-06-18"
+
+// supportedProtocolVersions lists revisions we accept when echoed by a client.
+var supportedProtocolVersions = []string{"2024-11-05", "2025-03-26", ProtocolVersion}
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// toolTimeout bounds a single tool call, matching the REPL.
+const toolTimeout = 120 * time.Second
+
+// maxMessageSize bounds a single newline-delimited JSON-RPC message.
+const maxMessageSize = 10 * 1024 * 1024
+
+// maxToolNameLength is the longest tool name clients accept.
+const maxToolNameLength = 64
+
+// invalidToolNameChars matches the characters a tool name can't contain.
+var invalidToolNameChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// Resource URIs exposed by the server.
+const (
+	promptsResourceURI      = "raypaste://prompts"
+	modelsResourceURI       = "raypaste://models"
+	promptResourceURIPrefix = "raypaste://prompts/"
+)
+
+// builtInToolNames gives the built-in prompts descriptive tool names.
+// Every other prompt is exposed as "generate_<name>".
+var builtInToolNames = map[string]string{
+	defaults.MetaPromptName: "generate_metaprompt",
+	defaults.BulletListName: "organize_bullets",
+}
+
+// Server implements a Model Context Protocol server over newline-delimited
+// JSON-RPC 2.0, exposing every prompt in the store as a tool.
+type Server struct {
+	env      *generate.Env
+	client   generate.Completer
+	defaults generate.Defaults
+	version  string
+
+	mu sync.Mutex
+	// toolPrompts maps each tool name to its prompt, as of the last tools/list.
+	toolPrompts map[string]string
+	// running holds the cancel function of each tool call in progress, by request ID.
+	running map[string]context.CancelFunc
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type cancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+}
+
+type initializeParams struct {
+	ProtocolVersion string `json:"protocolVersion"`
+}
+
+type initializeResult struct {
+	ProtocolVersion string             `json:"protocolVersion"`
+	Capabilities    serverCapabilities `json:"capabilities"`
+	ServerInfo      implementation     `json:"serverInfo"`
+}
+
+type serverCapabilities struct {
+	Tools     map[string]interface{} `json:"tools"`
+	Resources map[string]interface{} `json:"resources"`
+}
+
+type implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// Tool describes an MCP tool generated from a prompt.
+type Tool struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	InputSchema inputSchema `json:"inputSchema"`
+}
+
+type inputSchema struct {
+	Type       string                    `json:"type"`
+	Properties map[string]schemaProperty `json:"properties"`
+	Required   []string                  `json:"required"`
+}
+
+type schemaProperty struct {
+	Type        string                    `json:"type"`
+	Description string                    `json:"description,omitempty"`
+	Enum        []string                  `json:"enum,omitempty"`
+	Default     string                    `json:"default,omitempty"`
+	Properties  map[string]schemaProperty `json:"properties,omitempty"`
+	Required    []string                  `json:"required,omitempty"`
+}
+
+type toolsListResult struct {
+	Tools []Tool `json:"tools"`
+}
+
+type toolCallParams struct {
+	Name      string        `json:"name"`
+	Arguments toolArguments `json:"arguments"`
+}
+
+type toolArguments struct {
+	Input  string            `json:"input"`
+	Length string            `json:"length,omitempty"`
+	Model  string            `json:"model,omitempty"`
+	Vars   map[string]string `json:"vars,omitempty"`
+}
+
+type toolCallResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Resource describes an MCP resource.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType"`
+}
+
+type resourcesListResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+type resourceReadParams struct {
+	URI string `json:"uri"`
+}
+
+type resourceReadResult struct {
+	Contents []resourceContents `json:"contents"`
+}
+
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// New creates an MCP server backed by env and client. version is reported to clients.
+func New(env *generate.Env, client generate.Completer, defaults generate.Defaults, version string) *Server {
+	return &Server{
+		env:      env,
+		client:   client,
+		defaults: defaults,
+		version:  version,
+	}
+}
+
+// Serve reads JSON-RPC messages from r, one per line, and writes responses to w
+// until r is exhausted or ctx is cancelled. Tool calls run concurrently, each until
+// it finishes or the client cancels it with notifications/cancelled; Serve waits for
+// those still running before it returns.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageSize)
+
+	var (
+		writeMu  sync.Mutex
+		writeErr error
+		calls    sync.WaitGroup
+	)
+	defer calls.Wait()
+
+	write := func(resp rpcResponse) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		if writeErr != nil {
+			return writeErr
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			writeErr = fmt.Errorf("failed to marshal response: %w", err)
+		} else if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+			writeErr = fmt.Errorf("failed to write response: %w", err)
+		}
+		return writeErr
+	}
+
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		req, errResp, ok := parseMessage([]byte(line))
+		if errResp != nil {
+			if err := write(*errResp); err != nil {
+				return err
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		if req.Method == "tools/call" && len(req.ID) > 0 {
+			callCtx, finish := s.startCall(ctx, req.ID)
+			calls.Add(1)
+			go func() {
+				defer calls.Done()
+				resp, _ := s.handleRequest(callCtx, req)
+				// A cancelled call gets no response
+				if finish() {
+					_ = write(resp)
+				}
+			}()
+			continue
+		}
+
+		resp, ok := s.handleRequest(ctx, req)
+		if !ok {
+			continue // notification — no response
+		}
+		if err := write(resp); err != nil {
+			return err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading input: %w", err)
+	}
+	calls.Wait()
+	writeMu.Lock()
+	defer writeMu.Unlock()
+	return writeErr
+}
+
+// startCall registers a tool call so notifications/cancelled can stop it. The
+// returned finish function unregisters it and reports whether it wasn't cancelled.
+func (s *Server) startCall(ctx context.Context, id json.RawMessage) (context.Context, func() bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	key := string(id)
+
+	s.mu.Lock()
+	if s.running == nil {
+		s.running = make(map[string]context.CancelFunc)
+	}
+	s.running[key] = cancel
+	s.mu.Unlock()
+
+	return ctx, func() bool {
+		defer cancel()
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.running[key]; !ok {
+			return false
+		}
+		delete(s.running, key)
+		return true
+	}
+}
+
+// cancelCall stops the tool call with the given request ID, if it is running.
+func (s *Server) cancelCall(id json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cancel, ok := s.running[string(id)]; ok {
+		cancel()
+		delete(s.running, string(id))
+	}
+}
+
+// parseMessage decodes a single JSON-RPC message. It returns false when the message
+// can't be handled, with the error response to write unless the message is a
+// notification.
+func parseMessage(data []byte) (rpcRequest, *rpcResponse, bool) {
+	var req rpcRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		resp := errorResponse(json.RawMessage("null"), codeParseError, "parse error: "+err.Error())
+		return req, &resp, false
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		if len(req.ID) == 0 {
+			return req, nil, false
+		}
+		resp := errorResponse(req.ID, codeInvalidRequest, "invalid request")
+		return req, &resp, false
+	}
+	return req, nil, true
+}
+
+// handleRequest dispatches a single JSON-RPC request. It returns false when the
+// request is a notification and no response should be written.
+func (s *Server) handleRequest(ctx context.Context, req rpcRequest) (rpcResponse, bool) {
+	isNotification := len(req.ID) == 0
+	result, rpcErr := s.dispatch(ctx, req)
+	if isNotification {
+		return rpcResponse{}, false
+	}
+	if rpcErr != nil {
+		return rpcResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}, true
+	}
+	return rpcResponse{JSONRPC: "2.0", ID: req.ID, Result: result}, true
+}
+
+func (s *Server) dispatch(ctx context.Context, req rpcRequest) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		_ = json.Unmarshal(req.Params, &params)
+		return s.initialize(params), nil
+
+	case "notifications/initialized":
+		return nil, nil
+
+	case "notifications/cancelled":
+		var params cancelledParams
+		if err := json.Unmarshal(req.Params, &params); err == nil && len(params.RequestID) > 0 {
+			s.cancelCall(params.RequestID)
+		}
+		return nil, nil
+
+	case "ping":
+		return map[string]interface{}{}, nil
+
+	case "tools/list":
+		return toolsListResult{Tools: s.Tools()}, nil
+
+	case "tools/call":
+		var params toolCallParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		return s.callTool(ctx, params)
+
+	case "resources/list":
+		return resourcesListResult{Resources: s.Resources()}, nil
+
+	case "resources/read":
+		var params resourceReadParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		return s.readResource(params.URI)
+
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params initializeParams) initializeResult {
+	version := ProtocolVersion
+	for _, supported := range supportedProtocolVersions {
+		if params.ProtocolVersion == supported {
+			version = supported
+			break
+		}
+	}
+
+	return initializeResult{
+		ProtocolVersion: version,
+		Capabilities: serverCapabilities{
+			Tools:     map[string]interface{}{},
+			Resources: map[string]interface{}{},
+		},
+		ServerInfo: implementation{Name: "raypaste", Version: s.version},
+	}
+}
+
+// Tools returns one tool per prompt in the store, sorted by prompt name.
+func (s *Server) Tools() []Tool {
+	infos := s.env.ListPrompts()
+	promptNames := make([]string, len(infos))
+	for i, info := range infos {
+		promptNames[i] = info.Name
+	}
+	names := toolNames(promptNames)
+	s.setToolPrompts(names)
+
+	tools := make([]Tool, 0, len(infos))
+	for _, info := range infos {
+		description := info.Description
+		if description == "" {
+			description = fmt.Sprintf("Run the raypaste %q prompt", info.Name)
+		}
+
+		properties := map[string]schemaProperty{
+			"input": {
+				Type:        "string",
+				Description: "The text to run the prompt on",
+			},
+			"length": {
+				Type:        "string",
+				Description: "Output length",
+				Enum:        info.Lengths,
+			},
+			"model": {
+				Type:        "string",
+				Description: "Model alias or OpenRouter ID (defaults to the server's model)",
+			},
+		}
+		if vars := varsSchema(info.Variables); vars != nil {
+			properties["vars"] = *vars
+		}
+
+		tools = append(tools, Tool{
+			Name:        names[info.Name],
+			Description: description,
+			InputSchema: inputSchema{
+				Type:       "object",
+				Properties: properties,
+				Required:   []string{"input"},
+			},
+		})
+	}
+	return tools
+}
+
+// varsSchema describes a prompt's template variables as an object property,
+// or returns nil when the prompt declares none.
+func varsSchema(variables []prompts.Variable) *schemaProperty {
+	if len(variables) == 0 {
+		return nil
+	}
+
+	schema := &schemaProperty{
+		Type:        "object",
+		Description: "Template variables",
+		Properties:  make(map[string]schemaProperty, len(variables)),
+	}
+	for _, v := range variables {
+		schema.Properties[v.Name] = schemaProperty{
+			Type:        "string",
+			Description: v.Description,
+			Default:     v.Default,
+		}
+		if v.Required && v.Default == "" {
+			schema.Required = append(schema.Required, v.Name)
+		}
+	}
+	return schema
+}
+
+// ToolName returns the MCP tool name for a prompt. Characters other than letters,
+// digits and underscores become underscores, and the name is cut to 64 characters.
+// Distinct prompts can share a tool name, such as code-review and code_review;
+// toolNames tells them apart.
+func ToolName(promptName string) string {
+	if name, ok := builtInToolNames[promptName]; ok {
+		return name
+	}
+	name := "generate_" + invalidToolNameChars.ReplaceAllString(strings.ReplaceAll(promptName, "-", "_"), "_")
+	return truncateToolName(name, maxToolNameLength)
+}
+
+// toolNames returns a unique tool name for each prompt. Each name goes to the first
+// prompt, in sorted order, whose ToolName it is; any other prompt with the same
+// ToolName gets a numeric suffix, such as generate_code_review_2.
+func toolNames(promptNames []string) map[string]string {
+	sorted := slices.Sorted(slices.Values(promptNames))
+	names := make(map[string]string, len(sorted))
+	taken := make(map[string]bool, len(sorted))
+	var clashes []string
+	for _, prompt := range sorted {
+		name := ToolName(prompt)
+		if taken[name] {
+			clashes = append(clashes, prompt)
+			continue
+		}
+		names[prompt] = name
+		taken[name] = true
+	}
+
+	for _, prompt := range clashes {
+		base := ToolName(prompt)
+		for n := 2; ; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			name := truncateToolName(base, maxToolNameLength-len(suffix)) + suffix
+			if !taken[name] {
+				names[prompt] = name
+				taken[name] = true
+				break
+			}
+		}
+	}
+	return names
+}
+
+// truncateToolName cuts name to at most n bytes; tool names are ASCII.
+func truncateToolName(name string, n int) string {
+	if len(name) > n {
+		return name[:n]
+	}
+	return name
+}
+
+// setToolPrompts records the prompt behind each tool name, from names by prompt.
+func (s *Server) setToolPrompts(names map[string]string) {
+	toolPrompts := make(map[string]string, len(names))
+	for prompt, name := range names {
+		toolPrompts[name] = prompt
+	}
+	s.mu.Lock()
+	s.toolPrompts = toolPrompts
+	s.mu.Unlock()
+}
+
+// promptForTool maps a tool name back to the prompt it was generated from, using the
+// names from the last tools/list, or from the store if the client never listed them.
+func (s *Server) promptForTool(toolName string) (string, bool) {
+	s.mu.Lock()
+	listed := s.toolPrompts != nil
+	s.mu.Unlock()
+	if !listed {
+		s.setToolPrompts(toolNames(s.env.Store.List()))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prompt, ok := s.toolPrompts[toolName]
+	return prompt, ok
+}
+
+func (s *Server) callTool(ctx context.Context, params toolCallParams) (interface{}, *rpcError) {
+	promptName, ok := s.promptForTool(params.Name)
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+	}
+
+	if strings.TrimSpace(params.Arguments.Input) == "" {
+		return toolError(fmt.Errorf("no input provided")), nil
+	}
+
+	promptName, length, err := s.env.ResolvePromptAndLength(s.defaults, promptName, params.Arguments.Length)
+	if err != nil {
+		return toolError(err), nil
+	}
+
+	model := params.Arguments.Model
+	if model == "" {
+		model = s.defaults.Model
+	}
+
+	genParams := generate.Params{
+		Input:      params.Arguments.Input,
+		PromptName: promptName,
+		Model:      model,
+		Length:     length,
+		Vars:       params.Arguments.Vars,
+	}
+	if err := s.env.Validate(genParams); err != nil {
+		return toolError(err), nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+
+	result, err := s.env.Run(ctx, s.client, genParams, generate.RunOptions{})
+	if err != nil {
+		return toolError(fmt.Errorf("generation failed: %w", err)), nil
+	}
+
+	return toolCallResult{Content: []textContent{{Type: "text", Text: result.Output}}}, nil
+}
+
+// Resources returns the prompt and model listings plus one resource per prompt template.
+func (s *Server) Resources() []Resource {
+	resources := []Resource{
+		{
+			URI:         promptsResourceURI,
+			Name:        "prompts",
+			Description: "All raypaste prompts with descriptions and supported lengths",
+			MimeType:    "application/json",
+		},
+		{
+			URI:         modelsResourceURI,
+			Name:        "models",
+			Description: "All raypaste model aliases with their OpenRouter IDs",
+			MimeType:    "application/json",
+		},
+	}
+
+	for _, info := range s.env.ListPrompts() {
+		resources = append(resources, Resource{
+			URI:         promptResourceURIPrefix + info.Name,
+			Name:        "prompt: " + info.Name,
+			Description: info.Description,
+			MimeType:    "text/plain",
+		})
+	}
+	return resources
+}
+
+func (s *Server) readResource(uri string) (interface{}, *rpcError) {
+	var (
+		mimeType = "application/json"
+		payload  interface{}
+	)
+
+	switch {
+	case uri == promptsResourceURI:
+		payload = s.env.ListPrompts()
+	case uri == modelsResourceURI:
+		payload = s.env.ListModels()
+	case strings.HasPrefix(uri, promptResourceURIPrefix):
+		prompt, err := s.env.Store.Get(strings.TrimPrefix(uri, promptResourceURIPrefix))
+		if err != nil {
+			return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return resourceReadResult{Contents: []resourceContents{{URI: uri, MimeType: "text/plain", Text: prompt.System}}}, nil
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown resource: " + uri}
+	}
+
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return resourceReadResult{Contents: []resourceContents{{URI: uri, MimeType: mimeType, Text: string(data)}}}, nil
+}
+
+func toolError(err error) toolCallResult {
+	return toolCallResult{Content: []textContent{{Type: "text", Text: err.Error()}}, IsError: true}
+}
+
+func errorResponse(id json.RawMessage, code int, message string) rpcResponse {
+	return rpcResponse{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: message}}
+}
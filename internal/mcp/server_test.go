@@ -0,0 +1,251 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package mcp
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func newTestServer(t *testing.T) (*Server, *generatetest.Completer) {
+	t.Helper()
+	client := &generatetest.Completer{Reply: "generated output"}
+	return New(generatetest.NewEnv(t, nil), client, generate.Defaults{
+		PromptName: "metaprompt",
+		Model:      "cerebras-llama-8b",
+		Length:     types.OutputLengthMedium,
+	}, "test"), client
+}
+
+// exchange sends each message on its own line and returns the decoded responses,
+// ordered by ID as tool calls may finish in any order. Responses without an ID come
+// last.
+func exchange(t *testing.T, s *Server, messages ...string) []map[string]interface{} {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(context.Background(), strings.NewReader(strings.Join(messages, "\n")), &out); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+
+	var responses []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var resp map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("invalid response line %q: %v", line, err)
+		}
+		responses = append(responses, resp)
+	}
+	slices.SortStableFunc(responses, func(a, b map[string]interface{}) int {
+		idA, okA := a["id"].(float64)
+		idB, okB := b["id"].(float64)
+		switch {
+		case okA && okB:
+			return cmp.Compare(idA, idB)
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return 0
+	})
+	return responses
+}
+
+// blockingCompleter answers only once its request's context is done, or after a
+// few seconds if it never is.
+type blockingCompleter struct {
+	generatetest.Completer
+}
+
+func (b *blockingCompleter) Complete(ctx context.Context, req types.CompletionRequest) (string, types.TokenUsage, error) {
+	select {
+	case <-ctx.Done():
+		return "", types.TokenUsage{}, ctx.Err()
+	case <-time.After(5 * time.Second):
+		return "not cancelled", types.TokenUsage{}, nil
+	}
+}
+
+func TestToolsCallCancelled(t *testing.T) {
+	s, _ := newTestServer(t)
+	s.client = &blockingCompleter{}
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"organize_bullets","arguments":{"input":"notes"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/cancelled","params":{"requestId":7,"reason":"user aborted"}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"ping"}`,
+	)
+
+	if len(responses) != 1 || responses[0]["id"] != 8.0 {
+		t.Errorf("responses = %v, want only the ping's, as a cancelled call gets no response", responses)
+	}
+}
+
+func TestToolName(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+	}{
+		{"metaprompt", "generate_metaprompt"},
+		{"bulletlist", "organize_bullets"},
+		{"code-review", "generate_code_review"},
+		{"sql_expert", "generate_sql_expert"},
+		{"review v2.1", "generate_review_v2_1"},
+		{"résumé", "generate_r_sum_"},
+		{strings.Repeat("a", 80), "generate_" + strings.Repeat("a", 55)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prompt, func(t *testing.T) {
+			if got := ToolName(tt.prompt); got != tt.want {
+				t.Errorf("ToolName(%q) = %q, want %q", tt.prompt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolNames(t *testing.T) {
+	long := strings.Repeat("a", 70)
+	got := toolNames([]string{"code_review", "code-review", "code_review_2", "metaprompt", long, long + "-b"})
+	want := map[string]string{
+		"code-review":   "generate_code_review",
+		"code_review":   "generate_code_review_3",
+		"code_review_2": "generate_code_review_2",
+		"metaprompt":    "generate_metaprompt",
+		long:            "generate_" + strings.Repeat("a", 55),
+		long + "-b":     "generate_" + strings.Repeat("a", 53) + "_2",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("toolNames() = %v, want %v", got, want)
+	}
+}
+
+func TestInitializeAndNotifications(t *testing.T) {
+	s, _ := newTestServer(t)
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26"}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"ping"}`,
+	)
+
+	if len(responses) != 2 {
+		t.Fatalf("got %d responses, want 2 (notifications must not be answered)", len(responses))
+	}
+
+	result := responses[0]["result"].(map[string]interface{})
+	if result["protocolVersion"] != "2025-03-26" {
+		t.Errorf("protocolVersion = %v, want echoed 2025-03-26", result["protocolVersion"])
+	}
+	serverInfo := result["serverInfo"].(map[string]interface{})
+	if serverInfo["name"] != "raypaste" {
+		t.Errorf("serverInfo.name = %v, want raypaste", serverInfo["name"])
+	}
+}
+
+func TestToolsList(t *testing.T) {
+	s, _ := newTestServer(t)
+	responses := exchange(t, s, `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+
+	tools := responses[0]["result"].(map[string]interface{})["tools"].([]interface{})
+	names := make(map[string]bool)
+	for _, tool := range tools {
+		names[tool.(map[string]interface{})["name"].(string)] = true
+	}
+	for _, want := range []string{"generate_metaprompt", "organize_bullets"} {
+		if !names[want] {
+			t.Errorf("tools/list missing %q", want)
+		}
+	}
+}
+
+func TestToolsCall(t *testing.T) {
+	s, client := newTestServer(t)
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"organize_bullets","arguments":{"input":"notes","length":"short"}}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"organize_bullets","arguments":{"input":"notes","length":"long"}}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"no_such_tool","arguments":{"input":"notes"}}}`,
+	)
+
+	ok := responses[0]["result"].(map[string]interface{})
+	if ok["isError"] != false {
+		t.Errorf("organize_bullets short should succeed, got %v", ok)
+	}
+	text := ok["content"].([]interface{})[0].(map[string]interface{})["text"]
+	if text != "generated output" {
+		t.Errorf("tool text = %v, want generated output", text)
+	}
+	if !strings.Contains(client.LastRequest().Messages[0].Content, "text organizer") {
+		t.Error("organize_bullets should render the bulletlist system prompt")
+	}
+
+	unsupported := responses[1]["result"].(map[string]interface{})
+	if unsupported["isError"] != true {
+		t.Errorf("unsupported length should be reported as a tool error, got %v", unsupported)
+	}
+
+	if responses[2]["error"] == nil {
+		t.Error("unknown tool should return a JSON-RPC error")
+	}
+}
+
+func TestResources(t *testing.T) {
+	s, _ := newTestServer(t)
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"resources/read","params":{"uri":"raypaste://models"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"resources/read","params":{"uri":"raypaste://prompts/metaprompt"}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/read","params":{"uri":"raypaste://unknown"}}`,
+	)
+
+	resources := responses[0]["result"].(map[string]interface{})["resources"].([]interface{})
+	if len(resources) < 2 {
+		t.Fatalf("resources/list returned %d resources, want at least 2", len(resources))
+	}
+
+	models := responses[1]["result"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	var infos []generate.ModelInfo
+	if err := json.Unmarshal([]byte(models["text"].(string)), &infos); err != nil {
+		t.Fatalf("models resource is not JSON: %v", err)
+	}
+	if want := len(config.DefaultModels) + len(generatetest.Models); len(infos) != want {
+		t.Errorf("models resource has %d entries, want %d", len(infos), want)
+	}
+
+	prompt := responses[2]["result"].(map[string]interface{})["contents"].([]interface{})[0].(map[string]interface{})
+	if !strings.Contains(prompt["text"].(string), "{{.LengthDirective}}") {
+		t.Error("prompt resource should return the raw template")
+	}
+
+	if responses[3]["error"] == nil {
+		t.Error("unknown resource should return a JSON-RPC error")
+	}
+}
+
+func TestUnknownMethodAndParseError(t *testing.T) {
+	s, _ := newTestServer(t)
+	responses := exchange(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"sampling/createMessage"}`,
+		`{not json`,
+	)
+
+	if code := responses[0]["error"].(map[string]interface{})["code"].(float64); int(code) != codeMethodNotFound {
+		t.Errorf("unknown method code = %v, want %d", code, codeMethodNotFound)
+	}
+	if code := responses[1]["error"].(map[string]interface{})["code"].(float64); int(code) != codeParseError {
+		t.Errorf("parse error code = %v, want %d", code, codeParseError)
+	}
+}
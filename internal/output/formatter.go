@@ -69,6 +69,9 @@ var (
 	// Underline
 	Underline = color.New(color.Underline).SprintFunc()
 
+	// Dim is faint text, such as a model's reasoning
+	Dim = color.New(color.Faint).SprintFunc()
+
 	// Suggestion preview: dim/faint text for inline completion hints
 	suggestionStyle = color.New(color.Faint).SprintFunc()
 	headerRE        = regexp.MustCompile(`^#{1,6}\s`)
@@ -206,6 +209,41 @@ func GeneratingMessage(model string, length string, contextFile string) string {
 	return msg
 }
 
+// StepMessage returns a colored header for an intermediate pipeline step's output.
+func StepMessage(n int, prompt string, model string) string {
+	return BoldYellow(fmt.Sprintf("Step %d: ", n)) + Cyan(prompt) + White(" with ") + BoldBlue(model)
+}
+
+// ToolCallMessage returns a colored line for a tool the model called.
+func ToolCallMessage(name, arguments string) string {
+	return BoldYellow("Tool: ") + Cyan(name) + " " + White(arguments)
+}
+
+// AttachmentMessage returns a colored line for a file attached to the input.
+func AttachmentMessage(name, mimeType, size string) string {
+	return BoldYellow("Attached: ") + Cyan(name) + White(" ("+mimeType+", "+size+")")
+}
+
+// RefineRoundMessage returns a colored header for a refinement round.
+func RefineRoundMessage(n, total int, approved bool) string {
+	msg := BoldYellow(fmt.Sprintf("Refine round %d/%d", n, total))
+	if approved {
+		msg += White(": ") + Green("no issues found")
+	}
+	return msg
+}
+
+// ColorDiffLine colors a line of diff output: removals red, additions green.
+func ColorDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "-"):
+		return Red(line)
+	case strings.HasPrefix(line, "+"):
+		return Green(line)
+	}
+	return line
+}
+
 // CopiedMessage returns a colored "✓ Output copied to clipboard" message
 func CopiedMessage() string {
 	return green("✓ Output copied to clipboard")
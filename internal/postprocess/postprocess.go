@@ -0,0 +1,172 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package postprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Rules clean up a model's output before it is printed or copied. A nil *Rules
+// leaves output unchanged.
+type Rules struct {
+	// StripPreamble removes leading lines such as "Sure!" or "Here is the prompt:".
+	StripPreamble bool `yaml:"strip_preamble,omitempty"`
+	// TrimTrailingCommentary removes a closing paragraph such as "Let me know if you
+	// need changes."
+	TrimTrailingCommentary bool `yaml:"trim_trailing_commentary,omitempty"`
+	// UnwrapCodeBlock returns the contents of the output when it is a single fenced
+	// code block.
+	UnwrapCodeBlock bool `yaml:"unwrap_code_block,omitempty"`
+	// CollapseWhitespace removes trailing spaces, collapses runs of blank lines into
+	// one and trims the output.
+	CollapseWhitespace bool `yaml:"collapse_whitespace,omitempty"`
+	// Replace applies regular expression replacements in order, after the other rules
+	// and before CollapseWhitespace.
+	Replace []Replacement `yaml:"replace,omitempty"`
+}
+
+// Replacement replaces every match of Pattern (Go regexp syntax) with With, which
+// may refer to capture groups as $1 or ${name}.
+type Replacement struct {
+	Pattern string `yaml:"pattern"`
+	With    string `yaml:"with"`
+
+	re *regexp.Regexp
+}
+
+var (
+	// preamblePatterns match a whole leading line that introduces the output.
+	preamblePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)^(here|below)('s| is| are)\b.{0,100}:$`),
+		regexp.MustCompile(`(?i)^(sure|certainly|of course|absolutely|okay|ok|great|alright)\b.{0,100}:$`),
+		regexp.MustCompile(`(?i)^(sure|certainly|of course|absolutely|okay|ok|great|alright)[!.]?$`),
+	}
+
+	// commentaryPattern matches the start of a closing paragraph addressed to the user.
+	commentaryPattern = regexp.MustCompile(`(?i)^(let me know|i hope|hope this|feel free|if you('d| would) like|would you like|this prompt (will|should)|note:)`)
+
+	// fencePattern matches an opening or closing code fence line.
+	fencePattern = regexp.MustCompile("^(```|~~~)")
+
+	// blankLinesPattern matches two or more blank lines.
+	blankLinesPattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// maxPreambleLen is the longest line a preamble pattern can match.
+const maxPreambleLen = 160
+
+// Validate compiles the replacement patterns.
+func (r *Rules) Validate() error {
+	if r == nil {
+		return nil
+	}
+	for i := range r.Replace {
+		if r.Replace[i].Pattern == "" {
+			return fmt.Errorf("postprocess.replace[%d]: pattern is required", i)
+		}
+		re, err := regexp.Compile(r.Replace[i].Pattern)
+		if err != nil {
+			return fmt.Errorf("postprocess.replace[%d]: invalid pattern: %w", i, err)
+		}
+		r.Replace[i].re = re
+	}
+	return nil
+}
+
+// Apply returns text with the rules applied.
+func (r *Rules) Apply(text string) string {
+	if r == nil {
+		return text
+	}
+
+	if r.StripPreamble {
+		text = stripPreamble(text)
+	}
+	if r.TrimTrailingCommentary {
+		text = trimTrailingCommentary(text)
+	}
+	if r.UnwrapCodeBlock {
+		text = unwrapCodeBlock(text)
+	}
+	for _, replacement := range r.Replace {
+		re := replacement.re
+		if re == nil {
+			var err error
+			if re, err = regexp.Compile(replacement.Pattern); err != nil {
+				continue
+			}
+		}
+		text = re.ReplaceAllString(text, replacement.With)
+	}
+	if r.CollapseWhitespace {
+		text = collapseWhitespace(text)
+	}
+	return text
+}
+
+// isPreamble reports whether line, without its newline, introduces the output.
+func isPreamble(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, pattern := range preamblePatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripPreamble removes leading preamble lines and the blank lines around them.
+func stripPreamble(text string) string {
+	for {
+		trimmed := strings.TrimLeft(text, " \t\r\n")
+		line, rest, _ := strings.Cut(trimmed, "\n")
+		if !isPreamble(line) {
+			return trimmed
+		}
+		text = rest
+	}
+}
+
+// unwrapCodeBlock returns the contents of text when it is exactly one fenced code
+// block, and text unchanged otherwise.
+func unwrapCodeBlock(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) < 2 || !fencePattern.MatchString(lines[0]) || strings.TrimSpace(lines[len(lines)-1]) != lines[0][:3] {
+		return text
+	}
+	for _, line := range lines[1 : len(lines)-1] {
+		if fencePattern.MatchString(strings.TrimSpace(line)) {
+			return text // More than one block
+		}
+	}
+	return strings.Join(lines[1:len(lines)-1], "\n")
+}
+
+// trimTrailingCommentary removes the last paragraph when it speaks to the user
+// about the output rather than being part of it.
+func trimTrailingCommentary(text string) string {
+	trimmed := strings.TrimRight(text, " \t\r\n")
+	i := strings.LastIndex(trimmed, "\n\n")
+	if i < 0 {
+		return text
+	}
+	last := strings.TrimSpace(trimmed[i:])
+	if !commentaryPattern.MatchString(last) || strings.Contains(last, "```") || strings.Contains(last, "~~~") {
+		return text // Not commentary, or it closes a code block
+	}
+	return strings.TrimRight(trimmed[:i], " \t\r\n")
+}
+
+// collapseWhitespace removes trailing spaces from each line, collapses runs of
+// blank lines into one and trims the text.
+func collapseWhitespace(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	text = blankLinesPattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(text)
+}
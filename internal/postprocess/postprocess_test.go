@@ -0,0 +1,115 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package postprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules *Rules
+		input string
+		want  string
+	}{
+		{"nil rules", nil, "Sure!\nText", "Sure!\nText"},
+		{"strip preamble", &Rules{StripPreamble: true}, "Sure! Here is the prompt:\n\nWrite a poem.", "Write a poem."},
+		{"strip several preamble lines", &Rules{StripPreamble: true}, "Certainly.\nHere's the rewritten prompt:\nWrite a poem.", "Write a poem."},
+		{"keep non-preamble first line", &Rules{StripPreamble: true}, "Here is why it fails: the loop never ends.", "Here is why it fails: the loop never ends."},
+		{"unwrap code block", &Rules{UnwrapCodeBlock: true}, "```markdown\n# Title\nBody\n```\n", "# Title\nBody"},
+		{"keep several code blocks", &Rules{UnwrapCodeBlock: true}, "```\na\n```\n```\nb\n```", "```\na\n```\n```\nb\n```"},
+		{"keep text around code block", &Rules{UnwrapCodeBlock: true}, "Intro\n```\na\n```", "Intro\n```\na\n```"},
+		{"trim trailing commentary", &Rules{TrimTrailingCommentary: true}, "Write a poem.\n\nLet me know if you need changes.", "Write a poem."},
+		{"keep trailing paragraph", &Rules{TrimTrailingCommentary: true}, "Write a poem.\n\nUse four stanzas.", "Write a poem.\n\nUse four stanzas."},
+		{"collapse whitespace", &Rules{CollapseWhitespace: true}, "\n a  \n\n\n\nb\t\n", "a\n\nb"},
+		{"replace", &Rules{Replace: []Replacement{{Pattern: `(?m)^#+\s*`, With: ""}, {Pattern: `(\w+)@example\.com`, With: "<$1>"}}}, "## Title\nmail bob@example.com", "Title\nmail <bob>"},
+		{
+			"rules combine",
+			&Rules{StripPreamble: true, UnwrapCodeBlock: true, TrimTrailingCommentary: true, CollapseWhitespace: true},
+			"Here is the prompt:\n```\nWrite a poem.\n```\n\nI hope this helps!",
+			"Write a poem.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.rules.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got := tt.rules.Apply(tt.input); got != tt.want {
+				t.Errorf("Apply() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   *Rules
+		wantErr string
+	}{
+		{"nil rules", nil, ""},
+		{"valid pattern", &Rules{Replace: []Replacement{{Pattern: `\s+$`}}}, ""},
+		{"missing pattern", &Rules{Replace: []Replacement{{With: "x"}}}, "pattern is required"},
+		{"invalid pattern", &Rules{Replace: []Replacement{{Pattern: "("}}}, "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rules.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  *Rules
+		tokens []string
+		want   string
+	}{
+		{"no rules", nil, []string{"Sure!", "\nText"}, "Sure!\nText"},
+		{"preamble split across tokens", &Rules{StripPreamble: true}, []string{"He", "re is the pro", "mpt:\n", "\nWrite", " a poem."}, "Write a poem."},
+		{"no preamble", &Rules{StripPreamble: true}, []string{"Write", " a poem.\n", "Use rhyme."}, "Write a poem.\nUse rhyme."},
+		{"preamble only", &Rules{StripPreamble: true}, []string{"Sure!"}, ""},
+		{"single unfinished line", &Rules{StripPreamble: true}, []string{"Write a poem."}, "Write a poem."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewStream(tt.rules)
+			var got strings.Builder
+			for _, token := range tt.tokens {
+				got.WriteString(s.Process(token))
+			}
+			got.WriteString(s.Flush())
+			if got.String() != tt.want {
+				t.Errorf("streamed %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamDoesNotHoldBackLongLines(t *testing.T) {
+	s := NewStream(&Rules{StripPreamble: true})
+	line := strings.Repeat("word ", maxPreambleLen/4)
+	if got := s.Process(line); got != line {
+		t.Errorf("Process() = %q, want the line passed through", got)
+	}
+	if got := s.Process("more"); got != "more" {
+		t.Errorf("Process() after start = %q, want token passed through", got)
+	}
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package postprocess
+
+import "strings"
+
+// Stream strips a leading preamble from streamed output as it arrives. Text is held
+// back only until the first line that isn't a preamble begins, so output that
+// doesn't start with one streams without delay once its first line is complete.
+// Rules other than StripPreamble need the whole output and are left to Apply.
+type Stream struct {
+	strip   bool
+	pending strings.Builder
+	started bool
+}
+
+// NewStream returns a filter that strips a leading preamble when any of rules asks
+// for it, and passes tokens through unchanged otherwise.
+func NewStream(rules ...*Rules) *Stream {
+	s := &Stream{}
+	for _, r := range rules {
+		if r != nil && r.StripPreamble {
+			s.strip = true
+		}
+	}
+	return s
+}
+
+// Process takes the next token and returns the text that can be shown now.
+func (s *Stream) Process(token string) string {
+	if !s.strip || s.started {
+		return token
+	}
+
+	s.pending.WriteString(token)
+	for {
+		pending := s.pending.String()
+		trimmed := strings.TrimLeft(pending, " \t\r\n")
+		line, rest, complete := strings.Cut(trimmed, "\n")
+		if !complete {
+			// A line longer than any preamble can't be one
+			if len(line) > maxPreambleLen {
+				return s.start(trimmed)
+			}
+			s.pending.Reset()
+			s.pending.WriteString(trimmed)
+			return ""
+		}
+		if !isPreamble(line) {
+			return s.start(trimmed)
+		}
+		s.pending.Reset()
+		s.pending.WriteString(rest)
+	}
+}
+
+// Flush returns any text still held back at the end of the stream.
+func (s *Stream) Flush() string {
+	if !s.strip || s.started {
+		return ""
+	}
+	return s.start(stripPreamble(s.pending.String()))
+}
+
+// start ends preamble detection and returns text to show.
+func (s *Stream) start(text string) string {
+	s.started = true
+	s.pending.Reset()
+	return text
+}
@@ -109,3 +109,43 @@ func findCursorRule(startDir string) (string, error) {
 		dir = parent
 	}
 }
+
+// FindRoot returns the nearest directory at or above startDir containing a .git
+// entry. If none is found, the absolute form of startDir is returned.
+func FindRoot(startDir string) string {
+	if path, err := findUpward(startDir, ".git"); err == nil {
+		return filepath.Dir(path)
+	}
+	if abs, err := filepath.Abs(startDir); err == nil {
+		return abs
+	}
+	return startDir
+}
+
+// IsRoot reports whether dir contains a .git entry, the mark of a project root.
+func IsRoot(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// FindDir searches upward from startDir for a directory at the relative path rel,
+// such as ".raypaste/prompts", and returns its absolute path.
+func FindDir(startDir, rel string) (string, error) {
+	dir, err := filepath.Abs(startDir)
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		candidate := filepath.Join(dir, rel)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			return candidate, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", os.ErrNotExist
+		}
+		dir = parent
+	}
+}
@@ -182,3 +182,50 @@ func writeFile(t *testing.T, path, content string) {
 		t.Fatal(err)
 	}
 }
+
+func TestFindRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	child := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := FindRoot(child); got != root {
+		t.Errorf("FindRoot(child) = %q, want %q", got, root)
+	}
+
+	noRepo := t.TempDir()
+	if got := FindRoot(noRepo); got != noRepo {
+		t.Errorf("FindRoot(no repo) = %q, want %q", got, noRepo)
+	}
+}
+
+func TestFindDir(t *testing.T) {
+	root := t.TempDir()
+	promptsDir := filepath.Join(root, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	child := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(child, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindDir(child, filepath.Join(".raypaste", "prompts"))
+	if err != nil {
+		t.Fatalf("FindDir() error = %v", err)
+	}
+	if got != promptsDir {
+		t.Errorf("FindDir() = %q, want %q", got, promptsDir)
+	}
+
+	// A file at the relative path is not a match
+	other := t.TempDir()
+	writeFile(t, filepath.Join(other, "prompts"), "not a directory")
+	if _, err := FindDir(other, "prompts"); err == nil {
+		t.Error("FindDir() should not match a file")
+	}
+}
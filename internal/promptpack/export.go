@@ -0,0 +1,132 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package promptpack
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/prompttest"
+)
+
+// Export bundles the named prompts from store into a pack described by meta. Prompts
+// they extend or run as steps and partials they include are added too, unless built in. Test suites
+// and recorded fixtures are included for every exported prompt that has them.
+func Export(store *prompts.Store, names []string, meta Manifest) (*Pack, error) {
+	p := newPack()
+	p.Manifest = meta
+	p.Manifest.Prompts = nil
+	p.Manifest.Partials = nil
+
+	for _, name := range names {
+		if err := p.addPrompt(store, name); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range sortedKeys(p.Prompts) {
+		p.Manifest.Prompts = append(p.Manifest.Prompts, name)
+		if err := p.addTests(name, store.Lengths()); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range sortedKeys(p.Partials) {
+		p.Manifest.Partials = append(p.Manifest.Partials, partialName(file))
+	}
+	return p, nil
+}
+
+// addPrompt adds the named prompt's file, its parents, the prompts its steps run and
+// its partials to the pack.
+func (p *Pack) addPrompt(store *prompts.Store, name string) error {
+	if _, ok := p.Prompts[name]; ok {
+		return nil
+	}
+
+	prompt, err := store.Get(name)
+	if err != nil {
+		return err
+	}
+	source := store.Source(name)
+	if source == "" {
+		return fmt.Errorf("cannot export built-in prompt '%s'", name)
+	}
+
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return fmt.Errorf("failed to read prompt '%s': %w", name, err)
+	}
+	p.Prompts[name] = data
+
+	if prompt.Extends != "" && store.Source(prompt.Extends) != "" {
+		if err := p.addPrompt(store, prompt.Extends); err != nil {
+			return err
+		}
+	}
+	for _, step := range prompt.Steps {
+		if store.Source(step.Prompt) == "" {
+			continue // Built-in prompts ship with raypaste
+		}
+		if err := p.addPrompt(store, step.Prompt); err != nil {
+			return err
+		}
+	}
+
+	partials, err := store.PartialsUsed(name)
+	if err != nil {
+		return err
+	}
+	for _, partial := range partials {
+		source := store.PartialSource(partial)
+		if source == "" {
+			continue // Built-in partials ship with raypaste
+		}
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read partial '%s': %w", partial, err)
+		}
+		p.Partials[filepath.Base(source)] = data
+	}
+	return nil
+}
+
+// addTests adds the named prompt's test suite and fixtures, if it has any. Case
+// lengths must be built in or defined in lengths.
+func (p *Pack) addTests(name string, lengths *config.Lengths) error {
+	path, err := prompttest.SuitePath(name)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	suite, err := prompttest.LoadSuite(path, lengths)
+	if err != nil {
+		return err
+	}
+	p.Tests[name] = suite
+
+	fixtures, err := prompttest.LoadFixtures(name)
+	if err != nil {
+		return err
+	}
+	if len(fixtures) > 0 {
+		p.Fixtures[name] = fixtures
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,310 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package promptpack
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/prompttest"
+	"gopkg.in/yaml.v3"
+)
+
+// Conflict is a prompt or partial in the pack whose name is already in use.
+type Conflict struct {
+	// Kind is "prompt" or "partial".
+	Kind   string
+	Name   string
+	Origin prompts.Origin
+}
+
+// Rename changes the name of a packed prompt, along with the prompts in the pack
+// that extend it and its tests.
+func (p *Pack) Rename(from, to string) error {
+	data, ok := p.Prompts[from]
+	if !ok {
+		return fmt.Errorf("pack has no prompt named '%s'", from)
+	}
+	if _, ok := p.Prompts[to]; ok {
+		return fmt.Errorf("pack already has a prompt named '%s'", to)
+	}
+
+	renamed, err := setField(data, "name", from, to)
+	if err != nil {
+		return fmt.Errorf("failed to rename '%s': %w", from, err)
+	}
+	delete(p.Prompts, from)
+	p.Prompts[to] = renamed
+
+	for name, data := range p.Prompts {
+		updated, err := setReferences(data, from, to)
+		if err != nil {
+			return fmt.Errorf("failed to update '%s': %w", name, err)
+		}
+		p.Prompts[name] = updated
+	}
+
+	if suite, ok := p.Tests[from]; ok {
+		suite.Prompt = to
+		delete(p.Tests, from)
+		p.Tests[to] = suite
+	}
+	if fixtures, ok := p.Fixtures[from]; ok {
+		delete(p.Fixtures, from)
+		p.Fixtures[to] = fixtures
+	}
+
+	for i, name := range p.Manifest.Prompts {
+		if name == from {
+			p.Manifest.Prompts[i] = to
+		}
+	}
+	return nil
+}
+
+// setField replaces the top-level key in a YAML document when its value is from.
+// Comments and the order of keys are preserved.
+func setField(data []byte, key, from, to string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("prompt file is not a mapping")
+	}
+
+	if !replaceValue(doc.Content[0], key, from, to) {
+		return data, nil
+	}
+	return encodeNode(&doc)
+}
+
+// setReferences renames the prompt from to to wherever a prompt file refers to it:
+// its extends field and the prompt of each of its steps.
+func setReferences(data []byte, from, to string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("prompt file is not a mapping")
+	}
+
+	root := doc.Content[0]
+	changed := replaceValue(root, "extends", from, to)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "steps" || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, step := range root.Content[i+1].Content {
+			if step.Kind == yaml.MappingNode && replaceValue(step, "prompt", from, to) {
+				changed = true
+			}
+		}
+	}
+	if !changed {
+		return data, nil
+	}
+	return encodeNode(&doc)
+}
+
+// replaceValue sets key in mapping to to when its value is from, and reports
+// whether it did.
+func replaceValue(mapping *yaml.Node, key, from, to string) bool {
+	changed := false
+	fields := mapping.Content
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i].Value == key && fields[i+1].Value == from {
+			fields[i+1].Value = to
+			changed = true
+		}
+	}
+	return changed
+}
+
+// encodeNode writes doc back out as YAML.
+func encodeNode(doc *yaml.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Conflicts returns the prompts and partials in the pack whose names store already
+// uses. Partials identical to the installed ones are not conflicts.
+func (p *Pack) Conflicts(store *prompts.Store) []Conflict {
+	var conflicts []Conflict
+	for _, name := range sortedKeys(p.Prompts) {
+		if _, err := store.Get(name); err == nil {
+			conflicts = append(conflicts, Conflict{Kind: "prompt", Name: name, Origin: store.Origin(name)})
+		}
+	}
+
+	installed := make(map[string]bool)
+	for _, name := range store.Partials() {
+		installed[name] = true
+	}
+	for _, file := range sortedKeys(p.Partials) {
+		name := partialName(file)
+		if !installed[name] {
+			continue
+		}
+		source := store.PartialSource(name)
+		if source == "" {
+			conflicts = append(conflicts, Conflict{Kind: "partial", Name: name, Origin: prompts.OriginBuiltIn})
+			continue
+		}
+		if existing, err := os.ReadFile(source); err == nil && bytes.Equal(existing, p.Partials[file]) {
+			continue
+		}
+		origin := prompts.OriginUser
+		if dir := store.ProjectDir(); dir != "" && strings.HasPrefix(source, dir+string(filepath.Separator)) {
+			origin = prompts.OriginProject
+		}
+		conflicts = append(conflicts, Conflict{Kind: "partial", Name: name, Origin: origin})
+	}
+	return conflicts
+}
+
+// Validate checks that every prompt in the pack loads and lints without errors
+// against store, as if the pack were installed. It returns all lint issues found,
+// and an error if the pack must not be installed.
+func (p *Pack) Validate(store *prompts.Store, models map[string]config.Model, defaultModel string) ([]prompts.LintIssue, error) {
+	// Each prompt can use the lengths defined by the pack's prompts before it, in name order
+	var added []*prompts.Prompt
+	lengths := store.Lengths().Clone()
+	for _, name := range sortedKeys(p.Prompts) {
+		prompt, err := prompts.ParsePrompt(p.Prompts[name], lengths)
+		if err != nil {
+			return nil, fmt.Errorf("prompt '%s': %w", name, err)
+		}
+		if prompt.Name != name {
+			return nil, fmt.Errorf("prompt file %s.yaml defines '%s'", name, prompt.Name)
+		}
+		if err := lengths.Define(prompt.Name, prompt.Lengths); err != nil {
+			return nil, fmt.Errorf("prompt '%s': %w", name, err)
+		}
+		added = append(added, prompt)
+	}
+
+	partials := make(map[string]string, len(p.Partials))
+	for file, data := range p.Partials {
+		partials[partialName(file)] = string(data)
+	}
+
+	preview, err := store.WithPrompts(added, partials)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []prompts.LintIssue
+	errorCount := 0
+	for _, prompt := range added {
+		found, err := preview.Lint(prompt.Name, models, defaultModel)
+		if err != nil {
+			return nil, err
+		}
+		for _, issue := range found {
+			if issue.Severity == prompts.LintError {
+				errorCount++
+			}
+		}
+		issues = append(issues, found...)
+	}
+	if errorCount > 0 {
+		return issues, fmt.Errorf("pack has %d error(s)", errorCount)
+	}
+	return issues, nil
+}
+
+// Install writes the pack's prompts, partials, tests and fixtures into the user's
+// prompts directory and returns the paths written. Unless overwrite is set, it
+// refuses to install a pack with conflicts. Call Validate first.
+func (p *Pack) Install(store *prompts.Store, overwrite bool) ([]string, error) {
+	conflicts := p.Conflicts(store)
+	if len(conflicts) > 0 && !overwrite {
+		return nil, fmt.Errorf("%s '%s' already exists", conflicts[0].Kind, conflicts[0].Name)
+	}
+
+	promptsDir, err := config.GetPromptsDir()
+	if err != nil {
+		return nil, err
+	}
+	partialsDir := filepath.Join(promptsDir, "partials")
+
+	var written []string
+	write := func(path string, data []byte) error {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+		}
+		written = append(written, path)
+		return nil
+	}
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create prompts directory: %w", err)
+	}
+
+	for _, file := range sortedKeys(p.Partials) {
+		path := filepath.Join(partialsDir, file)
+		// Replace a user partial of the same name even if its extension differs
+		if source := store.PartialSource(partialName(file)); filepath.Dir(source) == partialsDir {
+			path = source
+		}
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, p.Partials[file]) {
+			continue
+		}
+		if err := write(path, p.Partials[file]); err != nil {
+			return written, err
+		}
+	}
+
+	for _, name := range sortedKeys(p.Prompts) {
+		path := filepath.Join(promptsDir, name+".yaml")
+		// Replace the user's own file for the prompt so the name isn't defined twice
+		if store.Origin(name) == prompts.OriginUser {
+			path = store.Source(name)
+		}
+		// Prompt files go through the prompt history so an overwrite can be rolled back
+		if err := prompts.WritePromptFile(path, name, p.Prompts[name]); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	for _, name := range sortedKeys(p.Tests) {
+		path, err := prompttest.SuitePath(name)
+		if err != nil {
+			return written, err
+		}
+		data, err := yaml.Marshal(p.Tests[name])
+		if err != nil {
+			return written, fmt.Errorf("failed to marshal tests for %s: %w", name, err)
+		}
+		if err := write(path, data); err != nil {
+			return written, err
+		}
+	}
+
+	for _, name := range sortedKeys(p.Fixtures) {
+		if err := prompttest.SaveFixtures(name, p.Fixtures[name]); err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
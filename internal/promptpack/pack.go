@@ -0,0 +1,274 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package promptpack
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/prompttest"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// manifestName is the archive path of the pack's manifest.
+	manifestName = "pack.yaml"
+	// maxFileSize bounds each file read from an archive.
+	maxFileSize = 1 << 20
+)
+
+// Manifest describes a pack and lists its contents.
+type Manifest struct {
+	Name        string    `yaml:"name,omitempty"`
+	Description string    `yaml:"description,omitempty"`
+	Author      string    `yaml:"author,omitempty"`
+	Version     string    `yaml:"version,omitempty"`
+	Created     time.Time `yaml:"created"`
+	Prompts     []string  `yaml:"prompts"`
+	Partials    []string  `yaml:"partials,omitempty"`
+}
+
+// Pack is a bundle of prompts with the partials they include and their test suites.
+//
+// The archive layout mirrors the prompts directory:
+//
+//	pack.yaml
+//	prompts/<name>.yaml
+//	partials/<file>
+//	tests/<name>.yaml
+//	tests/fixtures/<name>.yaml
+type Pack struct {
+	Manifest Manifest
+	// Prompts maps each prompt name to the contents of its prompt file.
+	Prompts map[string][]byte
+	// Partials maps each partial file name, such as house-style.md, to its contents.
+	Partials map[string][]byte
+	// Tests maps prompt names to their test suites.
+	Tests map[string]*prompttest.Suite
+	// Fixtures maps prompt names to their recorded fixtures, keyed by case name.
+	Fixtures map[string]map[string]prompttest.Fixture
+}
+
+// fixtureFile is the layout of a fixtures file, matching prompttest's.
+type fixtureFile struct {
+	Cases map[string]prompttest.Fixture `yaml:"cases"`
+}
+
+func newPack() *Pack {
+	return &Pack{
+		Prompts:  make(map[string][]byte),
+		Partials: make(map[string][]byte),
+		Tests:    make(map[string]*prompttest.Suite),
+		Fixtures: make(map[string]map[string]prompttest.Fixture),
+	}
+}
+
+// Write writes the pack to w as a gzipped tar archive.
+func (p *Pack) Write(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: p.Manifest.Created,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+		return nil
+	}
+	addYAML := func(name string, v interface{}) error {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal %s: %w", name, err)
+		}
+		return add(name, data)
+	}
+
+	if err := addYAML(manifestName, p.Manifest); err != nil {
+		return err
+	}
+	for _, name := range sortedKeys(p.Prompts) {
+		if err := add("prompts/"+name+".yaml", p.Prompts[name]); err != nil {
+			return err
+		}
+	}
+	for _, file := range sortedKeys(p.Partials) {
+		if err := add("partials/"+file, p.Partials[file]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(p.Tests) {
+		if err := addYAML("tests/"+name+".yaml", p.Tests[name]); err != nil {
+			return err
+		}
+	}
+	for _, name := range sortedKeys(p.Fixtures) {
+		if err := addYAML("tests/fixtures/"+name+".yaml", fixtureFile{Cases: p.Fixtures[name]}); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to write pack: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to write pack: %w", err)
+	}
+	return nil
+}
+
+// Read reads a pack written by Write. It rejects archives with files outside the
+// pack layout and checks that the manifest matches the prompts and partials present.
+// Prompt files are not validated here; see Validate.
+func Read(r io.Reader) (*Pack, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("not a prompt pack: %w", err)
+	}
+	defer gz.Close()
+
+	p := newPack()
+	var manifest []byte
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pack: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("pack entry %s is not a regular file", hdr.Name)
+		}
+		if hdr.Size > maxFileSize {
+			return nil, fmt.Errorf("pack entry %s is larger than %d bytes", hdr.Name, maxFileSize)
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, maxFileSize))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+		if err := p.addEntry(hdr.Name, data, &manifest); err != nil {
+			return nil, err
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("not a prompt pack: %s is missing", manifestName)
+	}
+	if err := yaml.Unmarshal(manifest, &p.Manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", manifestName, err)
+	}
+	if err := p.checkManifest(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// addEntry stores one archive file in the pack according to its path.
+func (p *Pack) addEntry(name string, data []byte, manifest *[]byte) error {
+	clean := path.Clean(name)
+	if clean != name || path.IsAbs(clean) || strings.HasPrefix(clean, "../") {
+		return fmt.Errorf("pack entry %s has an invalid path", name)
+	}
+
+	dir, file := path.Split(clean)
+	base := strings.TrimSuffix(file, path.Ext(file))
+	switch {
+	case clean == manifestName:
+		*manifest = data
+	case dir == "prompts/" && path.Ext(file) == ".yaml":
+		p.Prompts[base] = data
+	case dir == "partials/" && !strings.HasPrefix(file, "."):
+		p.Partials[file] = data
+	case dir == "tests/" && path.Ext(file) == ".yaml":
+		// Case lengths can be defined by the pack's prompts, which aren't loaded yet
+		suite, err := prompttest.ParseSuite(data, file, nil)
+		if err != nil {
+			return err
+		}
+		p.Tests[base] = suite
+	case dir == "tests/fixtures/" && path.Ext(file) == ".yaml":
+		var fixtures fixtureFile
+		if err := yaml.Unmarshal(data, &fixtures); err != nil {
+			return fmt.Errorf("failed to parse fixtures for %s: %w", base, err)
+		}
+		p.Fixtures[base] = fixtures.Cases
+	default:
+		return fmt.Errorf("unexpected file in pack: %s", name)
+	}
+	return nil
+}
+
+// checkManifest verifies the manifest lists exactly the prompts and partials in the
+// pack, and that tests belong to packed prompts.
+func (p *Pack) checkManifest() error {
+	if len(p.Manifest.Prompts) == 0 {
+		return fmt.Errorf("pack contains no prompts")
+	}
+	if !sameNames(p.Manifest.Prompts, sortedKeys(p.Prompts)) {
+		return fmt.Errorf("pack manifest lists prompts %s but the pack contains %s",
+			strings.Join(p.Manifest.Prompts, ", "), strings.Join(sortedKeys(p.Prompts), ", "))
+	}
+
+	var partials []string
+	for _, file := range sortedKeys(p.Partials) {
+		partials = append(partials, partialName(file))
+	}
+	if !sameNames(p.Manifest.Partials, partials) {
+		return fmt.Errorf("pack manifest lists partials %s but the pack contains %s",
+			strings.Join(p.Manifest.Partials, ", "), strings.Join(partials, ", "))
+	}
+
+	for name := range p.Tests {
+		if _, ok := p.Prompts[name]; !ok {
+			return fmt.Errorf("pack has tests for unknown prompt '%s'", name)
+		}
+	}
+	for name := range p.Fixtures {
+		if _, ok := p.Tests[name]; !ok {
+			return fmt.Errorf("pack has fixtures for '%s' but no tests", name)
+		}
+	}
+	return nil
+}
+
+// partialName returns the name a partial file is included by.
+func partialName(file string) string {
+	return strings.TrimSuffix(file, path.Ext(file))
+}
+
+// sameNames reports whether a and b hold the same names, ignoring order.
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]bool, len(a))
+	for _, name := range a {
+		seen[name] = true
+	}
+	for _, name := range b {
+		if !seen[name] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package promptpack
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/prompttest"
+)
+
+// writeFiles creates files under the prompts directory of a temporary home and
+// returns the prompts directory.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".raypaste", "prompts")
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func newStore(t *testing.T) *prompts.Store {
+	t.Helper()
+	store, err := prompts.NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	return store
+}
+
+func TestExportAndInstall(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"base.yaml":                  "name: base\nsystem: \"{{.Context}} {{template \\\"house\\\" .}}\"\n",
+		"review.yaml":                "# Team review prompt\nname: review\nextends: base\ndescription: Review code\n",
+		"partials/house.md":          "Be terse. {{template \"strict-rules\"}}",
+		"partials/unused.md":         "not exported",
+		"tests/review.yaml":          "cases:\n  - name: basic\n    input: func main() {}\n    assert:\n      must_contain: [main]\n",
+		"tests/fixtures/review.yaml": "cases:\n  basic:\n    model: m\n    system: s\n    output: main looks fine\n",
+	})
+
+	pack, err := Export(newStore(t), []string{"review"}, Manifest{Author: "Ada", Version: "1.0.0"})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if got := strings.Join(pack.Manifest.Prompts, ","); got != "base,review" {
+		t.Errorf("Manifest.Prompts = %s, want base,review", got)
+	}
+	if got := strings.Join(pack.Manifest.Partials, ","); got != "house" {
+		t.Errorf("Manifest.Partials = %s, want house (built-in and unused partials are skipped)", got)
+	}
+
+	var buf bytes.Buffer
+	if err := pack.Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := Export(newStore(t), []string{"metaprompt"}, Manifest{}); err == nil {
+		t.Error("Export() should refuse built-in prompts")
+	}
+
+	// Install into a fresh home
+	dir := writeFiles(t, nil)
+	read, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if read.Manifest.Author != "Ada" || read.Manifest.Version != "1.0.0" {
+		t.Errorf("Read() manifest = %+v", read.Manifest)
+	}
+	if err := read.Rename("review", "team-review"); err != nil {
+		t.Fatalf("Rename() error = %v", err)
+	}
+
+	store := newStore(t)
+	if conflicts := read.Conflicts(store); len(conflicts) != 0 {
+		t.Errorf("Conflicts() = %v, want none", conflicts)
+	}
+	if _, err := read.Validate(store, nil, ""); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if _, err := read.Install(store, false); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "team-review.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# Team review prompt") || !strings.Contains(string(data), "name: team-review") {
+		t.Errorf("installed prompt = %q, want the renamed file with its comment", data)
+	}
+
+	installed := newStore(t)
+	if _, err := installed.Render("team-review", "medium", "", nil); err != nil {
+		t.Errorf("Render(team-review) error = %v", err)
+	}
+	suite, err := prompttest.LoadSuite(filepath.Join(dir, "tests", "team-review.yaml"), installed.Lengths())
+	if err != nil || suite.Prompt != "team-review" {
+		t.Errorf("installed suite = %+v, %v", suite, err)
+	}
+	fixtures, err := prompttest.LoadFixtures("team-review")
+	if err != nil || fixtures["basic"].Output != "main looks fine" {
+		t.Errorf("installed fixtures = %v, %v", fixtures, err)
+	}
+
+	// Installing again conflicts with the installed prompts, but not the identical partial
+	conflicts := read.Conflicts(installed)
+	if len(conflicts) != 2 || conflicts[0].Name != "base" || conflicts[1].Name != "team-review" {
+		t.Errorf("Conflicts() = %v, want base and team-review", conflicts)
+	}
+	if _, err := read.Install(installed, false); err == nil {
+		t.Error("Install() should refuse to overwrite without overwrite")
+	}
+	if _, err := read.Install(installed, true); err != nil {
+		t.Errorf("Install(overwrite) error = %v", err)
+	}
+}
+
+func TestValidateRejectsBrokenPrompts(t *testing.T) {
+	writeFiles(t, nil)
+	store := newStore(t)
+
+	tests := []struct {
+		name    string
+		prompts map[string]string
+		want    string
+	}{
+		{"invalid yaml", map[string]string{"a": "name: [\n"}, "failed to parse YAML"},
+		{"name mismatch", map[string]string{"a": "name: b\nsystem: x\n"}, "defines 'b'"},
+		{"unknown parent", map[string]string{"a": "name: a\nextends: nope\n"}, "extends unknown prompt"},
+		{"lint error", map[string]string{"a": "name: a\nsystem: \"{{.Context}} {{.Input}}\"\n"}, "1 error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pack := newPack()
+			for name, content := range tt.prompts {
+				pack.Prompts[name] = []byte(content)
+			}
+			_, err := pack.Validate(store, nil, "")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRejectsInvalidArchives(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"no manifest", map[string]string{"prompts/a.yaml": "name: a\n"}, "pack.yaml is missing"},
+		{"path traversal", map[string]string{"pack.yaml": "prompts: [a]\n", "../a.yaml": "x"}, "invalid path"},
+		{"unexpected file", map[string]string{"pack.yaml": "prompts: [a]\n", "bin/run.sh": "x"}, "unexpected file"},
+		{"manifest mismatch", map[string]string{"pack.yaml": "prompts: [a, b]\n", "prompts/a.yaml": "name: a\n"}, "manifest lists prompts"},
+		{"orphan tests", map[string]string{
+			"pack.yaml":      "prompts: [a]\n",
+			"prompts/a.yaml": "name: a\n",
+			"tests/b.yaml":   "cases:\n  - name: c\n    input: x\n",
+		}, "tests for unknown prompt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			gz := gzip.NewWriter(&buf)
+			tw := tar.NewWriter(gz)
+			for _, name := range sortedKeys(tt.files) {
+				content := tt.files[name]
+				if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tw.Write([]byte(content)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = tw.Close()
+			_ = gz.Close()
+
+			_, err := Read(&buf)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Read() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package defaults
+
+import "github.com/raypaste/raypaste-cli/pkg/types"
+
+// Prompt describes a prompt that ships with raypaste.
+type Prompt struct {
+	Name        string
+	Description string
+	Template    string
+	// Lengths lists the output lengths the prompt supports, each with the default
+	// directive for that length.
+	Lengths []types.OutputLength
+}
+
+// Prompts lists every built-in prompt. The store loads each one, so a new default
+// only needs an entry here.
+var Prompts = []Prompt{
+	{
+		Name:        MetaPromptName,
+		Description: MetaPromptDescription,
+		Template:    MetaPromptTemplate,
+		Lengths:     []types.OutputLength{types.OutputLengthShort, types.OutputLengthMedium, types.OutputLengthLong},
+	},
+	{
+		Name:        BulletListName,
+		Description: BulletListDescription,
+		Template:    BulletListTemplate,
+		// Note: long mode intentionally not supported for bulletlist
+		Lengths: []types.OutputLength{types.OutputLengthShort, types.OutputLengthMedium},
+	},
+}
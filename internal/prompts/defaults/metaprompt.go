@@ -9,13 +9,7 @@ const MetaPromptTemplate = `You are an expert meta-prompt engineer. Your task is
 Project context: {{.Context}}
 Output length guidance: {{.LengthDirective}}
 
-STRICT OUTPUT RULES:
-1. Output ONLY the optimized prompt content.
-2. Do NOT include any preamble, introduction, or prefix (e.g., "Here is the prompt:", "Sure, here is...", "The optimized prompt is:").
-3. Do NOT include any explanation, reasoning, or post-script.
-4. Do NOT wrap the output in markdown code blocks unless the prompt itself specifically requires code formatting.
-5. DO NOT simply write the project context in the output, ONLY use it to guide the prompt engineering process and not make assumptions about technologies, frameworks, or programming languages.
-6. The response must start directly with the first character of the optimized prompt.
+` + StrictOutputRules + `
 
 TECHNOLOGY & CONTEXT RULES:
 1. Do NOT assume or specify programming languages, frameworks, or technologies unless explicitly mentioned in the user's input.
@@ -25,6 +19,19 @@ TECHNOLOGY & CONTEXT RULES:
 
 If you include any conversational text, the user's workflow will break. Just output the prompt.`
 
+// StrictOutputRules is the metaprompt's output rules block. It is also available to
+// every prompt as the "strict-rules" partial.
+const StrictOutputRules = `STRICT OUTPUT RULES:
+1. Output ONLY the optimized prompt content.
+2. Do NOT include any preamble, introduction, or prefix (e.g., "Here is the prompt:", "Sure, here is...", "The optimized prompt is:").
+3. Do NOT include any explanation, reasoning, or post-script.
+4. Do NOT wrap the output in markdown code blocks unless the prompt itself specifically requires code formatting.
+5. DO NOT simply write the project context in the output, ONLY use it to guide the prompt engineering process and not make assumptions about technologies, frameworks, or programming languages.
+6. The response must start directly with the first character of the optimized prompt.`
+
+// StrictRulesPartialName is the partial name under which StrictOutputRules is registered
+const StrictRulesPartialName = "strict-rules"
+
 // MetaPromptName is the name identifier for the default meta-prompt
 const MetaPromptName = "metaprompt"
 
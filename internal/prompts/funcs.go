@@ -0,0 +1,174 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"github.com/raypaste/raypaste-cli/internal/projectcontext"
+)
+
+// maxIncludeBytes caps how much of a file {{include}} will read.
+const maxIncludeBytes = 256 * 1024
+
+// gitTimeout bounds each git invocation made while rendering a template.
+const gitTimeout = 5 * time.Second
+
+// maxGitDiffBytes caps how much of the working tree's diff {{gitDiff}} returns.
+const maxGitDiffBytes = 32 * 1024
+
+// charsPerToken approximates the number of characters in a token for truncateTokens.
+const charsPerToken = 4
+
+// DefaultEnvAllowlist lists the environment variables templates may read with {{env}}
+// without any configuration. Additional names come from the template_env config key.
+var DefaultEnvAllowlist = []string{"USER", "SHELL", "LANG", "TERM", "EDITOR"}
+
+// funcMap returns the functions available to prompt templates. include and the git
+// helpers resolve paths relative to root, and include only reads files when root is
+// a project root; env only reads variables in allowlist.
+func funcMap(root string, allowlist []string, now func() time.Time) template.FuncMap {
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+	}
+
+	return template.FuncMap{
+		"include": func(path string) (string, error) {
+			return includeFile(root, path)
+		},
+		"env": func(name string) (string, error) {
+			if !allowed[name] {
+				return "", fmt.Errorf("environment variable %q is not in the template allowlist", name)
+			}
+			return os.Getenv(name), nil
+		},
+		"now": now,
+		"date": func(layout ...string) string {
+			if len(layout) > 0 {
+				return now().Format(layout[0])
+			}
+			return now().Format("2006-01-02")
+		},
+		"time": func() string {
+			return now().Format("15:04")
+		},
+		"gitBranch": func() string {
+			return runGit(root, "rev-parse", "--abbrev-ref", "HEAD")
+		},
+		"gitDiff": func() string {
+			return truncateDiff(runGit(root, "--no-pager", "diff", "--no-color", "HEAD"), maxGitDiffBytes)
+		},
+		"gitDiffStat": func() string {
+			return runGit(root, "--no-pager", "diff", "--stat", "HEAD")
+		},
+		"trim":           strings.TrimSpace,
+		"trimPrefix":     func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix":     func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"indent":         indent,
+		"upper":          strings.ToUpper,
+		"lower":          strings.ToLower,
+		"truncateTokens": truncateTokens,
+	}
+}
+
+// includeFile reads path relative to root, refusing paths that escape root, including
+// through symlinks. root must be a project root, so a template can't read whatever
+// directory raypaste happens to run in.
+func includeFile(root, path string) (string, error) {
+	if !projectcontext.IsRoot(root) {
+		return "", fmt.Errorf("include %q: not inside a project (a git repository)", path)
+	}
+	if filepath.IsAbs(path) {
+		return "", fmt.Errorf("include %q: path must be relative to the project root", path)
+	}
+
+	rel := filepath.Clean(path)
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("include %q: path escapes the project root", path)
+	}
+
+	r, err := os.OpenRoot(root)
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", path, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	f, err := r.Open(rel)
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxIncludeBytes+1))
+	if err != nil {
+		return "", fmt.Errorf("include %q: %w", path, err)
+	}
+	if len(data) > maxIncludeBytes {
+		return "", fmt.Errorf("include %q: file exceeds %d bytes", path, maxIncludeBytes)
+	}
+	return string(data), nil
+}
+
+// runGit runs git in dir and returns its trimmed output, or an empty string
+// when git is unavailable or dir is not a repository.
+func runGit(dir string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// truncateDiff shortens diff to at most n bytes, cutting after the last whole line.
+func truncateDiff(diff string, n int) string {
+	if len(diff) <= n {
+		return diff
+	}
+	cut := diff[:n]
+	if i := strings.LastIndexByte(cut, '\n'); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "\n[diff truncated]"
+}
+
+// indent prefixes every non-empty line of s with n spaces.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// truncateTokens shortens s to roughly n tokens, cutting at the last whitespace
+// before the limit. Tokens are estimated at four characters each.
+func truncateTokens(n int, s string) string {
+	limit := n * charsPerToken
+	if n <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	runes := []rune(s)
+	cut := string(runes[:limit])
+	if i := strings.LastIndexAny(cut, " \t\n"); i > 0 {
+		cut = cut[:i]
+	}
+	return cut + "\n[truncated]"
+}
@@ -0,0 +1,201 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func newFuncTestStore(t *testing.T, system string) *Store {
+	t.Helper()
+	store := &Store{
+		prompts:      make(map[string]*Prompt),
+		projectRoot:  newProjectRoot(t),
+		envAllowlist: []string{"RAYPASTE_TEST_ALLOWED"},
+		now:          func() time.Time { return time.Date(2026, 3, 14, 9, 26, 0, 0, time.UTC) },
+	}
+	store.prompts["p"] = &Prompt{Name: "p", System: system}
+	return store
+}
+
+// newProjectRoot returns a temporary directory marked as a project root.
+func newProjectRoot(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestRenderFuncs(t *testing.T) {
+	t.Setenv("RAYPASTE_TEST_ALLOWED", "visible")
+	t.Setenv("RAYPASTE_TEST_SECRET", "hidden")
+
+	tests := []struct {
+		name    string
+		system  string
+		want    string
+		wantErr bool
+	}{
+		{"include", `{{include "notes.md"}}`, "project notes\n", false},
+		{"include nested path", `{{include "docs/guide.md" | trim}}`, "guide", false},
+		{"include missing file", `{{include "missing.md"}}`, "", true},
+		{"include escaping root", `{{include "../outside.md"}}`, "", true},
+		{"include absolute path", `{{include "/etc/hostname"}}`, "", true},
+		{"env allowed", `{{env "RAYPASTE_TEST_ALLOWED"}}`, "visible", false},
+		{"env not allowed", `{{env "RAYPASTE_TEST_SECRET"}}`, "", true},
+		{"date default layout", `{{date}}`, "2026-03-14", false},
+		{"date custom layout", `{{date "Jan 2, 2006"}}`, "Mar 14, 2026", false},
+		{"time", `{{time}}`, "09:26", false},
+		{"now", `{{now.Year}}`, "2026", false},
+		{"trim", `{{trim "  x  "}}`, "x", false},
+		{"trimPrefix", `{{"v1.2" | trimPrefix "v"}}`, "1.2", false},
+		{"indent", `{{indent 2 "a\n\nb"}}`, "  a\n\n  b", false},
+		{"upper lower", `{{upper "a"}}{{lower "B"}}`, "Ab", false},
+		{"truncateTokens", `{{truncateTokens 2 "one two three four"}}`, "one two\n[truncated]", false},
+		{"truncateTokens short input", `{{truncateTokens 10 "short"}}`, "short", false},
+		{"gitBranch outside repo", `[{{gitBranch}}]`, "[]", false},
+		{"gitDiff outside repo", `[{{gitDiff}}{{gitDiffStat}}]`, "[]", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFuncTestStore(t, tt.system)
+			if err := os.WriteFile(filepath.Join(store.projectRoot, "notes.md"), []byte("project notes\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.MkdirAll(filepath.Join(store.projectRoot, "docs"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(store.projectRoot, "docs", "guide.md"), []byte("guide\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := store.Render("p", types.OutputLengthMedium, "", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderGitDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	store := newFuncTestStore(t, "{{gitDiffStat}}\n---\n{{gitDiff}}")
+	root := store.projectRoot
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	if err := os.RemoveAll(filepath.Join(root, ".git")); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	if err := os.WriteFile(filepath.Join(root, "notes.md"), []byte("before\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", "notes.md")
+	git("commit", "-q", "-m", "init")
+	if err := os.WriteFile(filepath.Join(root, "notes.md"), []byte("after\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.Render("p", types.OutputLengthMedium, "", nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	stat, diff, _ := strings.Cut(got, "\n---\n")
+	if !strings.Contains(stat, "1 file changed") {
+		t.Errorf("gitDiffStat = %q, want a summary", stat)
+	}
+	if !strings.Contains(diff, "-before") || !strings.Contains(diff, "+after") {
+		t.Errorf("gitDiff = %q, want the unified diff", diff)
+	}
+}
+
+func TestIncludeFileOutsideProject(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "notes.md"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := includeFile(root, "notes.md"); err == nil || !strings.Contains(err.Error(), "not inside a project") {
+		t.Errorf("includeFile() outside a project error = %v, want a project error", err)
+	}
+}
+
+func TestTruncateDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		n    int
+		want string
+	}{
+		{"fits", "-a\n+b", 10, "-a\n+b"},
+		{"cut at a line", "-a\n+b\n c", 6, "-a\n+b\n[diff truncated]"},
+	}
+	for _, tt := range tests {
+		if got := truncateDiff(tt.diff, tt.n); got != tt.want {
+			t.Errorf("%s: truncateDiff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIncludeFileSizeLimit(t *testing.T) {
+	root := newProjectRoot(t)
+	big := strings.Repeat("x", maxIncludeBytes+1)
+	if err := os.WriteFile(filepath.Join(root, "big.txt"), []byte(big), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := includeFile(root, "big.txt"); err == nil {
+		t.Error("includeFile() should reject files over the size limit")
+	}
+}
+
+func TestIncludeFileSymlinks(t *testing.T) {
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	root := newProjectRoot(t)
+	if err := os.WriteFile(filepath.Join(root, "notes.md"), []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for link, target := range map[string]string{"escape.txt": outside, "inside.md": "notes.md", "dir": filepath.Dir(outside)} {
+		if err := os.Symlink(target, filepath.Join(root, link)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{"inside.md", "notes", false},
+		{"escape.txt", "", true},
+		{"dir/secret.txt", "", true},
+	}
+	for _, tt := range tests {
+		got, err := includeFile(root, tt.path)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("includeFile(%s) = %q, %v, want %q, error %v", tt.path, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
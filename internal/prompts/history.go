@@ -0,0 +1,183 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+)
+
+// historyDirName is the subdirectory of the prompts directory holding prior versions
+// of user prompts, as .history/<name>/<version>.yaml.
+const historyDirName = ".history"
+
+// Version is a recorded revision of a user prompt file.
+type Version struct {
+	Number int
+	Time   time.Time
+	Path   string
+}
+
+// historyDir returns the directory holding the named prompt's versions.
+func historyDir(name string) (string, error) {
+	if err := ValidateName(name); err != nil {
+		return "", err
+	}
+	promptsDir, err := config.GetPromptsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(promptsDir, historyDirName, name), nil
+}
+
+// ListVersions returns the recorded versions of the named prompt, oldest first.
+func ListVersions(name string) ([]Version, error) {
+	dir, err := historyDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for %s: %w", name, err)
+	}
+
+	var versions []Version
+	for _, entry := range entries {
+		number, err := strconv.Atoi(strings.TrimSuffix(entry.Name(), ".yaml"))
+		if err != nil || entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{Number: number, Time: info.ModTime(), Path: filepath.Join(dir, entry.Name())})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Number < versions[j].Number })
+	return versions, nil
+}
+
+// ReadVersion returns the contents of a recorded version of the named prompt.
+func ReadVersion(name string, number int) ([]byte, error) {
+	versions, err := ListVersions(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range versions {
+		if v.Number == number {
+			data, err := os.ReadFile(v.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read version %d of %s: %w", number, name, err)
+			}
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt '%s' has no version %d", name, number)
+}
+
+// recordVersion appends data to the named prompt's history, unless it matches the
+// latest recorded version.
+func recordVersion(name string, data []byte) error {
+	versions, err := ListVersions(name)
+	if err != nil {
+		return err
+	}
+
+	next := 1
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		if existing, err := os.ReadFile(latest.Path); err == nil && bytes.Equal(existing, data) {
+			return nil
+		}
+		next = latest.Number + 1
+	}
+
+	dir, err := historyDir(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.yaml", next)), data, 0644); err != nil {
+		return fmt.Errorf("failed to record version of %s: %w", name, err)
+	}
+	return nil
+}
+
+// WritePromptFile writes data as the named prompt's file at path and records it in
+// the prompt's history. If the file already exists with contents that were never
+// recorded, such as a hand edit, those contents are recorded first.
+func WritePromptFile(path, name string, data []byte) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(path); err == nil {
+		if err := recordVersion(name, existing); err != nil {
+			return err
+		}
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write prompt file: %w", err)
+	}
+	return recordVersion(name, data)
+}
+
+// Rollback restores version number of the named user prompt, recording the
+// restored contents as a new version. A deleted prompt can be restored too.
+func (s *Store) Rollback(name string, number int) error {
+	data, err := ReadVersion(name, number)
+	if err != nil {
+		return err
+	}
+
+	prompt, err := ParsePrompt(data, s.lengths)
+	if err != nil {
+		return fmt.Errorf("version %d of %s is invalid: %w", number, name, err)
+	}
+	if prompt.Name != name {
+		return fmt.Errorf("version %d of %s defines '%s'", number, name, prompt.Name)
+	}
+
+	path := s.sources[name]
+	if s.Origin(name) != OriginUser {
+		if s.Origin(name) == OriginProject {
+			return fmt.Errorf("prompt '%s' is defined by the project in %s; only user prompts have history", name, path)
+		}
+		promptsDir, err := config.GetPromptsDir()
+		if err != nil {
+			return err
+		}
+		path = filepath.Join(promptsDir, name+".yaml")
+	}
+
+	if err := WritePromptFile(path, name, data); err != nil {
+		return err
+	}
+
+	if err := s.lengths.Define(name, prompt.Lengths); err != nil {
+		return err
+	}
+	s.prompts[name] = prompt
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+		s.origins = make(map[string]Origin)
+	}
+	s.sources[name] = path
+	s.origins[name] = OriginUser
+	return nil
+}
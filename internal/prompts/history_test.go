@@ -0,0 +1,88 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPromptHistory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(home, ".raypaste", "prompts", "demo.yaml")
+
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	for _, system := range []string{"first {{.Context}}", "second {{.Context}}"} {
+		if err := store.SavePrompt(&Prompt{Name: "demo", System: system}); err != nil {
+			t.Fatalf("SavePrompt() error = %v", err)
+		}
+	}
+
+	// A hand edit is recorded before the next write replaces it
+	if err := os.WriteFile(path, []byte("name: demo\nsystem: edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeletePrompt("demo"); err != nil {
+		t.Fatalf("DeletePrompt() error = %v", err)
+	}
+
+	versions, err := ListVersions("demo")
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("ListVersions() = %d versions, want 3", len(versions))
+	}
+	for i, want := range []string{"first", "second", "edited"} {
+		data, err := ReadVersion("demo", versions[i].Number)
+		if err != nil || !strings.Contains(string(data), want) {
+			t.Errorf("version %d = %q, %v, want it to contain %q", versions[i].Number, data, err, want)
+		}
+	}
+
+	// Rolling back restores the deleted prompt and records the restored contents
+	if err := store.Rollback("demo", 1); err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "first") {
+		t.Errorf("prompt file after Rollback() = %q, %v", data, err)
+	}
+	if prompt, err := store.Get("demo"); err != nil || prompt.System != "first {{.Context}}" {
+		t.Errorf("Get() after Rollback() = %+v, %v", prompt, err)
+	}
+	if versions, _ := ListVersions("demo"); len(versions) != 4 {
+		t.Errorf("ListVersions() after Rollback() = %d versions, want 4", len(versions))
+	}
+
+	if _, err := ReadVersion("demo", 9); err == nil {
+		t.Error("ReadVersion() should fail for an unknown version")
+	}
+	if err := store.Rollback("metaprompt", 1); err == nil {
+		t.Error("Rollback() should fail for a prompt without history")
+	}
+}
+
+func TestPromptHistoryRejectsInvalidNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	for _, name := range []string{"../..", "a/b", ".history", ""} {
+		if _, err := ListVersions(name); err == nil {
+			t.Errorf("ListVersions(%q) should fail", name)
+		}
+		if err := WritePromptFile(filepath.Join(home, "out.yaml"), name, []byte("name: x\n")); err == nil {
+			t.Errorf("WritePromptFile(%q) should fail", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, "out.yaml")); !os.IsNotExist(err) {
+		t.Errorf("WritePromptFile() with an invalid name should write nothing, got %v", err)
+	}
+}
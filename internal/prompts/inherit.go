@@ -0,0 +1,164 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"sort"
+	"strings"
+)
+
+// resolveInheritance replaces every prompt that declares `extends` with the result
+// of merging it over its (recursively resolved) parent. Prompts with an unknown
+// parent or an inheritance cycle are dropped with a warning; a dropped prompt that
+// shadowed a built-in falls back to the built-in.
+func (s *Store) resolveInheritance() {
+	names := make([]string, 0, len(s.prompts))
+	for name := range s.prompts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]*Prompt, len(s.prompts))
+	for _, name := range names {
+		prompt, err := s.resolvePrompt(name, resolved, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load prompt %s: %v\n", name, err)
+			s.loadIssues = append(s.loadIssues, LintIssue{Prompt: name, Severity: LintError, Message: err.Error()})
+			if builtIn, ok := s.builtIns[name]; ok {
+				resolved[name] = builtIn
+				delete(s.sources, name)
+				delete(s.origins, name)
+			}
+			continue
+		}
+		resolved[name] = prompt
+	}
+
+	s.prompts = resolved
+}
+
+// resolvePrompt returns the named prompt merged over its ancestors. chain holds the
+// prompts currently being resolved and is used to detect cycles.
+func (s *Store) resolvePrompt(name string, resolved map[string]*Prompt, chain []string) (*Prompt, error) {
+	if prompt, ok := resolved[name]; ok {
+		return prompt, nil
+	}
+
+	for _, seen := range chain {
+		if seen == name {
+			return nil, fmt.Errorf("inheritance cycle: %s", strings.Join(append(chain, name), " -> "))
+		}
+	}
+
+	prompt, ok := s.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("extends unknown prompt '%s'", name)
+	}
+	if prompt.Extends == "" {
+		return prompt, nil
+	}
+
+	// A prompt that shadows a built-in can extend it by its own name
+	if builtIn, ok := s.builtIns[name]; ok && prompt.Extends == name {
+		return mergePrompt(builtIn, prompt), nil
+	}
+
+	parent, err := s.resolvePrompt(prompt.Extends, resolved, append(chain, name))
+	if err != nil {
+		return nil, err
+	}
+
+	return mergePrompt(parent, prompt), nil
+}
+
+// mergePrompt returns child with unset fields inherited from parent. Length directives,
+// max_tokens and variables are merged key by key, with the child's entries taking precedence.
+// A child length directive set to an empty string removes that length.
+func mergePrompt(parent, child *Prompt) *Prompt {
+	merged := *child
+
+	if merged.Description == "" {
+		merged.Description = parent.Description
+	}
+	// A pipeline has no system template, so a child with steps doesn't inherit one
+	if merged.System == "" && len(child.Steps) == 0 {
+		merged.System = parent.System
+	}
+	if merged.Steps == nil && child.System == "" {
+		merged.Steps = parent.Steps
+	}
+	if merged.Model == "" {
+		merged.Model = parent.Model
+	}
+	if merged.Temperature == nil {
+		merged.Temperature = parent.Temperature
+	}
+	if merged.Stop == nil {
+		merged.Stop = parent.Stop
+	}
+	// Reasoning settings are inherited as a pair, like they are chosen
+	if merged.ReasoningEffort == "" && merged.ReasoningMaxTokens == 0 {
+		merged.ReasoningEffort, merged.ReasoningMaxTokens = parent.ReasoningEffort, parent.ReasoningMaxTokens
+	}
+	if merged.Examples == nil {
+		merged.Examples = parent.Examples
+	}
+	if merged.PostProcess == nil {
+		merged.PostProcess = parent.PostProcess
+	}
+	if merged.Schema == nil && child.Steps == nil {
+		merged.Schema = parent.Schema
+	}
+	if merged.Tools == nil && child.Steps == nil {
+		merged.Tools = parent.Tools
+	}
+
+	merged.ExamplesPerLength = mergeMaps(parent.ExamplesPerLength, child.ExamplesPerLength)
+
+	merged.MaxTokens = mergeMaps(parent.MaxTokens, child.MaxTokens)
+
+	merged.LengthDirectives = make(map[string]string, len(parent.LengthDirectives)+len(child.LengthDirectives))
+	for length, directive := range parent.LengthDirectives {
+		merged.LengthDirectives[length] = directive
+	}
+	for length, directive := range child.LengthDirectives {
+		if directive == "" {
+			delete(merged.LengthDirectives, length)
+			continue
+		}
+		merged.LengthDirectives[length] = directive
+	}
+
+	merged.Variables = append([]Variable(nil), parent.Variables...)
+	for _, v := range child.Variables {
+		replaced := false
+		for i := range merged.Variables {
+			if merged.Variables[i].Name == v.Name {
+				merged.Variables[i] = v
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged.Variables = append(merged.Variables, v)
+		}
+	}
+
+	return &merged
+}
+
+// mergeMaps returns parent's entries overridden by child's. The result is a new map
+// unless parent is empty, in which case child is returned as is.
+func mergeMaps[V any](parent, child map[string]V) map[string]V {
+	if len(parent) == 0 {
+		return child
+	}
+	merged := make(map[string]V, len(parent)+len(child))
+	maps.Copy(merged, parent)
+	maps.Copy(merged, child)
+	return merged
+}
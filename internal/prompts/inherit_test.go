@@ -0,0 +1,94 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestResolveInheritance(t *testing.T) {
+	store := &Store{prompts: map[string]*Prompt{
+		"base": {
+			Name:             "base",
+			Description:      "Base prompt",
+			System:           "Base system. {{.LengthDirective}}",
+			LengthDirectives: map[string]string{"short": "Be brief.", "medium": "Be balanced.", "long": "Be thorough."},
+			Variables:        []Variable{{Name: "tone", Default: "neutral"}, {Name: "audience", Default: "anyone"}},
+		},
+		"child": {
+			Name:             "child",
+			Extends:          "base",
+			LengthDirectives: map[string]string{"short": "Tiny.", "long": ""},
+			Variables:        []Variable{{Name: "tone", Default: "warm"}},
+		},
+		"grandchild": {
+			Name:        "grandchild",
+			Description: "Overridden",
+			Extends:     "child",
+			System:      "Own system for {{.Vars.audience}}.",
+		},
+		"orphan": {Name: "orphan", Extends: "missing"},
+		"loop-a": {Name: "loop-a", Extends: "loop-b"},
+		"loop-b": {Name: "loop-b", Extends: "loop-a"},
+		"self":   {Name: "self", Extends: "self"},
+	}}
+
+	store.resolveInheritance()
+
+	for _, name := range []string{"orphan", "loop-a", "loop-b", "self"} {
+		if _, err := store.Get(name); err == nil {
+			t.Errorf("prompt %q should be dropped", name)
+		}
+	}
+
+	child, err := store.Get("child")
+	if err != nil {
+		t.Fatalf("Get(child) error = %v", err)
+	}
+	if child.System != "Base system. {{.LengthDirective}}" || child.Description != "Base prompt" {
+		t.Errorf("child should inherit system and description, got %+v", child)
+	}
+	if child.LengthDirectives["short"] != "Tiny." || child.LengthDirectives["medium"] != "Be balanced." {
+		t.Errorf("child length directives not merged: %v", child.LengthDirectives)
+	}
+	if _, ok := child.LengthDirectives["long"]; ok {
+		t.Error("empty child directive should remove the inherited length")
+	}
+	if len(child.Variables) != 2 || child.Variables[0].Default != "warm" {
+		t.Errorf("child variables not merged: %+v", child.Variables)
+	}
+
+	rendered, err := store.Render("grandchild", types.OutputLengthShort, "", nil)
+	if err != nil {
+		t.Fatalf("Render(grandchild) error = %v", err)
+	}
+	if rendered != "Own system for anyone." {
+		t.Errorf("Render(grandchild) = %q", rendered)
+	}
+	if _, err := store.Render("grandchild", types.OutputLengthLong, "", nil); err == nil {
+		t.Error("grandchild should inherit the removed long length")
+	}
+
+	// The parent itself must be left untouched by merging.
+	base, _ := store.Get("base")
+	if base.LengthDirectives["short"] != "Be brief." {
+		t.Error("merging must not modify the parent prompt")
+	}
+}
+
+func TestResolvePromptCycleError(t *testing.T) {
+	store := &Store{prompts: map[string]*Prompt{
+		"a": {Name: "a", Extends: "b"},
+		"b": {Name: "b", Extends: "c"},
+		"c": {Name: "c", Extends: "a"},
+	}}
+
+	_, err := store.resolvePrompt("a", map[string]*Prompt{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "a -> b -> c -> a") {
+		t.Errorf("resolvePrompt() error = %v, want cycle a -> b -> c -> a", err)
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// LintSeverity classifies a lint issue.
+type LintSeverity string
+
+const (
+	// LintError marks a problem that breaks rendering or silently changes behavior.
+	LintError LintSeverity = "error"
+	// LintWarning marks a likely mistake that still renders.
+	LintWarning LintSeverity = "warning"
+)
+
+// LintIssue is a single problem found in a prompt or prompt file.
+type LintIssue struct {
+	Prompt   string
+	Severity LintSeverity
+	Message  string
+}
+
+// renderFields are the top-level fields Render supplies to prompt templates.
+var renderFields = []string{"LengthDirective", "Context", "Vars"}
+
+// lintPlaceholder is the value given to required variables during a trial render.
+const lintPlaceholder = "example"
+
+// Lint checks the named prompt. Numeric directives and max_tokens are checked against
+// the prompt's model, or defaultModel when it doesn't set one.
+func (s *Store) Lint(name string, models map[string]config.Model, defaultModel string) ([]LintIssue, error) {
+	var issues []LintIssue
+	for _, issue := range s.loadIssues {
+		if issue.Prompt == name {
+			issues = append(issues, issue)
+		}
+	}
+
+	prompt, err := s.Get(name)
+	if err != nil {
+		if len(issues) > 0 {
+			return issues, nil
+		}
+		return nil, err
+	}
+
+	return append(issues, s.lintPrompt(prompt, models, defaultModel)...), nil
+}
+
+// LintAll checks every prompt loaded from a file, and reports files that failed to
+// load and prompt names defined more than once.
+func (s *Store) LintAll(models map[string]config.Model, defaultModel string) []LintIssue {
+	issues := append([]LintIssue(nil), s.loadIssues...)
+
+	names := make([]string, 0, len(s.sources))
+	for name := range s.sources {
+		if _, ok := s.prompts[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		issues = append(issues, s.lintPrompt(s.prompts[name], models, defaultModel)...)
+	}
+	return issues
+}
+
+// LintFile parses the contents of a prompt file and lints it as if it replaced the
+// loaded prompt of the same name. It returns an error if the file doesn't load at
+// all, for example because it isn't valid YAML or extends an unknown prompt.
+func (s *Store) LintFile(data []byte, models map[string]config.Model, defaultModel string) (*Prompt, []LintIssue, error) {
+	prompt, err := ParsePrompt(data, s.lengths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	preview, err := s.WithPrompts([]*Prompt{prompt}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issues, err := preview.Lint(prompt.Name, models, defaultModel)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prompt, issues, nil
+}
+
+func (s *Store) lintPrompt(prompt *Prompt, models map[string]config.Model, defaultModel string) []LintIssue {
+	var issues []LintIssue
+	report := func(severity LintSeverity, format string, args ...interface{}) {
+		issues = append(issues, LintIssue{Prompt: prompt.Name, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// A pipeline has no template of its own; its steps are linted as prompts
+	if len(prompt.Steps) > 0 {
+		if _, err := s.Steps(prompt.Name); err != nil {
+			report(LintError, "%v", err)
+		}
+		return issues
+	}
+
+	s.lintTokenLimits(prompt, models, defaultModel, report)
+
+	tmpl := s.newTemplate("prompt")
+	if err := s.addPartials(tmpl); err != nil {
+		report(LintError, "%v", err)
+		return issues
+	}
+	if _, err := tmpl.Parse(prompt.System); err != nil {
+		report(LintError, "template does not parse: %v", err)
+		return issues
+	}
+
+	refs := make(map[string]bool)
+	collectFields(tmpl, tmpl.Tree.Root, true, refs, make(map[string]bool))
+
+	declared := make(map[string]bool, len(prompt.Variables))
+	for _, v := range prompt.Variables {
+		declared[v.Name] = true
+	}
+
+	fields := make([]string, 0, len(refs))
+	for field := range refs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	usedVars := make(map[string]bool)
+	for _, field := range fields {
+		top, rest, _ := strings.Cut(field, ".")
+		switch {
+		case top == "Vars" && rest != "":
+			name, _, _ := strings.Cut(rest, ".")
+			usedVars[name] = true
+			if !declared[name] {
+				report(LintWarning, "template uses undeclared variable '.Vars.%s'", name)
+			}
+		case !slices.Contains(renderFields, top):
+			report(LintError, "template references unknown field '.%s' (available: .LengthDirective, .Context, .Vars)", field)
+		}
+	}
+
+	// A template that reads .Vars as a whole (e.g. with index or range) may use any of them.
+	for _, v := range prompt.Variables {
+		if !refs["Vars"] && !usedVars[v.Name] {
+			report(LintWarning, "variable '%s' is declared but never used", v.Name)
+		}
+	}
+
+	if !refs["Context"] {
+		report(LintWarning, "template does not use {{.Context}}; project context will be ignored")
+	}
+
+	// A trial render catches problems only visible at execution time, such as a
+	// call to a partial that doesn't exist.
+	if length, ok := firstSupportedLength(prompt, s.lengths); ok {
+		vars := make(map[string]string)
+		for _, v := range prompt.Variables {
+			if v.Required {
+				vars[v.Name] = lintPlaceholder
+			}
+		}
+		if _, err := s.Render(prompt.Name, length, "", vars); err != nil {
+			report(LintError, "%v", err)
+		}
+	}
+
+	return issues
+}
+
+// lintTokenLimits flags numeric directives and max_tokens entries above the model's
+// maximum output.
+func (s *Store) lintTokenLimits(prompt *Prompt, models map[string]config.Model, defaultModel string, report func(LintSeverity, string, ...interface{})) {
+	alias := prompt.Model
+	if alias == "" {
+		alias = defaultModel
+	}
+	model, err := config.ResolveModel(alias, models)
+	if err != nil || model.MaxOutputTokens <= 0 {
+		return
+	}
+
+	for _, length := range sortedKeys(prompt.LengthDirectives) {
+		directive := prompt.LengthDirectives[length]
+		if !isNumericDirective(directive) {
+			continue
+		}
+		if n := parseNumericDirective(directive); n > model.MaxOutputTokens {
+			report(LintError, "length directive '%s' requests %d tokens, more than %s can generate (%d)", length, n, alias, model.MaxOutputTokens)
+		}
+	}
+	for _, length := range sortedKeys(prompt.MaxTokens) {
+		if n := prompt.MaxTokens[length]; n > model.MaxOutputTokens {
+			report(LintError, "max_tokens for %s is %d, more than %s can generate (%d)", length, n, alias, model.MaxOutputTokens)
+		}
+	}
+}
+
+// collectFields records the field chains a template reads from the data Render passes
+// in, such as "Context" or "Vars.audience". atRoot is false inside range and with
+// blocks, where dot refers to something else; $ always refers to the root.
+// Partials invoked with the root as their data are followed.
+func collectFields(tmpl *template.Template, node parse.Node, atRoot bool, refs, visited map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFields(tmpl, child, atRoot, refs, visited)
+		}
+	case *parse.ActionNode:
+		collectFields(tmpl, n.Pipe, atRoot, refs, visited)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFields(tmpl, cmd, atRoot, refs, visited)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFields(tmpl, arg, atRoot, refs, visited)
+		}
+	case *parse.FieldNode:
+		if atRoot {
+			refs[strings.Join(n.Ident, ".")] = true
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			refs[strings.Join(n.Ident[1:], ".")] = true
+		}
+	case *parse.ChainNode:
+		collectFields(tmpl, n.Node, atRoot, refs, visited)
+	case *parse.IfNode:
+		collectFields(tmpl, n.Pipe, atRoot, refs, visited)
+		collectFields(tmpl, n.List, atRoot, refs, visited)
+		collectFields(tmpl, n.ElseList, atRoot, refs, visited)
+	case *parse.RangeNode:
+		collectFields(tmpl, n.Pipe, atRoot, refs, visited)
+		collectFields(tmpl, n.List, false, refs, visited)
+		collectFields(tmpl, n.ElseList, atRoot, refs, visited)
+	case *parse.WithNode:
+		collectFields(tmpl, n.Pipe, atRoot, refs, visited)
+		collectFields(tmpl, n.List, false, refs, visited)
+		collectFields(tmpl, n.ElseList, atRoot, refs, visited)
+	case *parse.TemplateNode:
+		collectFields(tmpl, n.Pipe, atRoot, refs, visited)
+		if atRoot && passesRoot(n.Pipe) && !visited[n.Name] {
+			visited[n.Name] = true
+			if partial := tmpl.Lookup(n.Name); partial != nil && partial.Tree != nil {
+				collectFields(tmpl, partial.Tree.Root, true, refs, visited)
+			}
+		}
+	}
+}
+
+// passesRoot reports whether a {{template}} pipeline is just dot or $.
+func passesRoot(pipe *parse.PipeNode) bool {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return false
+	}
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+		return true
+	case *parse.VariableNode:
+		return len(arg.Ident) == 1 && arg.Ident[0] == "$"
+	}
+	return false
+}
+
+// firstSupportedLength returns the first length, in display order, the prompt can render.
+func firstSupportedLength(prompt *Prompt, lengths *config.Lengths) (types.OutputLength, bool) {
+	for _, length := range lengths.List() {
+		if prompt.SupportsLength(length) {
+			return length, true
+		}
+	}
+	return "", false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
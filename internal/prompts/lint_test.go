@@ -0,0 +1,195 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+)
+
+func TestLint(t *testing.T) {
+	models := map[string]config.Model{"small": {ID: "test/small", MaxOutputTokens: 1000}}
+
+	tests := []struct {
+		name   string
+		prompt *Prompt
+		// want lists the expected issues as "severity: message substring", in order.
+		want []string
+	}{
+		{
+			name:   "clean",
+			prompt: &Prompt{System: "{{.Context}} {{.LengthDirective}}"},
+			want:   nil,
+		},
+		{
+			name:   "parse error",
+			prompt: &Prompt{System: "{{.Context"},
+			want:   []string{"error: does not parse"},
+		},
+		{
+			name:   "unknown field",
+			prompt: &Prompt{System: "{{.Context}} {{.Input}}"},
+			want:   []string{"error: unknown field '.Input'"},
+		},
+		{
+			name:   "missing context",
+			prompt: &Prompt{System: "{{.LengthDirective}}"},
+			want:   []string{"warning: does not use {{.Context}}"},
+		},
+		{
+			name: "undeclared and unused variables",
+			prompt: &Prompt{
+				System:    "{{.Context}} {{.Vars.tone}}",
+				Variables: []Variable{{Name: "audience"}},
+			},
+			want: []string{"warning: undeclared variable '.Vars.tone'", "warning: 'audience' is declared but never used"},
+		},
+		{
+			name: "variables used through the root inside range",
+			prompt: &Prompt{
+				System:    "{{.Context}}{{range .Examples}}{{$.Vars.audience}}{{.Name}}{{end}}",
+				Variables: []Variable{{Name: "audience"}},
+			},
+			want: []string{"error: unknown field '.Examples'"},
+		},
+		{
+			name: "required variable filled for trial render",
+			prompt: &Prompt{
+				System:    "{{.Context}} {{.Vars.audience}}",
+				Variables: []Variable{{Name: "audience", Required: true}},
+			},
+			want: nil,
+		},
+		{
+			name:   "missing partial",
+			prompt: &Prompt{System: `{{.Context}} {{template "nope" .}}`},
+			want:   []string{"error: \"nope\" not defined"},
+		},
+		{
+			name: "token limits above model maximum",
+			prompt: &Prompt{
+				System:           "{{.Context}}",
+				Model:            "small",
+				LengthDirectives: map[string]string{"short": "2000", "medium": "500"},
+				MaxTokens:        map[string]int{"long": 4000},
+			},
+			want: []string{"error: 'short' requests 2000 tokens", "error: max_tokens for long is 4000"},
+		},
+		{
+			name: "model without a known maximum",
+			prompt: &Prompt{
+				System:           "{{.Context}}",
+				Model:            "vendor/unknown",
+				LengthDirectives: map[string]string{"short": "200000"},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prompt.Name = "p"
+			store := &Store{
+				prompts:  map[string]*Prompt{"p": tt.prompt},
+				partials: make(map[string]string),
+				now:      time.Now,
+			}
+
+			issues, err := store.Lint("p", models, "small")
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if len(issues) != len(tt.want) {
+				t.Fatalf("Lint() = %v, want %d issues", issues, len(tt.want))
+			}
+			for i, want := range tt.want {
+				severity, message, _ := strings.Cut(want, ": ")
+				if string(issues[i].Severity) != severity || !strings.Contains(issues[i].Message, message) {
+					t.Errorf("issue %d = %s: %s, want %s", i, issues[i].Severity, issues[i].Message, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLintAllLoadIssues(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.yaml":      "name: dup\nsystem: first\n",
+		"b.yml":       "name: dup\nsystem: \"{{.Context}}\"\n",
+		"broken.yaml": "name: [\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &Store{prompts: make(map[string]*Prompt), partials: make(map[string]string), lengths: config.NewLengths(), now: time.Now}
+	if err := store.loadPromptsDir(dir, OriginUser); err != nil {
+		t.Fatalf("loadPromptsDir() error = %v", err)
+	}
+
+	issues := store.LintAll(nil, "")
+	if len(issues) != 2 {
+		t.Fatalf("LintAll() = %v, want 2 issues", issues)
+	}
+	if issues[0].Prompt != "broken.yaml" && issues[1].Prompt != "broken.yaml" {
+		t.Errorf("LintAll() should report the file that failed to load, got %v", issues)
+	}
+	for _, issue := range issues {
+		if issue.Prompt == "dup" && !strings.Contains(issue.Message, "a.yaml and b.yml") {
+			t.Errorf("duplicate issue = %q, want it to name both files", issue.Message)
+		}
+	}
+
+	if _, err := store.Lint("missing", nil, ""); err == nil {
+		t.Error("Lint() should fail for an unknown prompt")
+	}
+}
+
+func TestLintFile(t *testing.T) {
+	store := &Store{
+		prompts:  map[string]*Prompt{"base": {Name: "base", System: "{{.Context}} base"}},
+		partials: make(map[string]string),
+		now:      time.Now,
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+		// wantIssues is the number of lint issues expected when the file loads.
+		wantIssues int
+	}{
+		{name: "valid", data: "name: p\nsystem: \"{{.Context}}\"\n"},
+		{name: "inherits", data: "name: p\nextends: base\n"},
+		{name: "invalid yaml", data: "name: [\n", wantErr: "failed to parse YAML"},
+		{name: "missing name", data: "system: x\n", wantErr: "name is required"},
+		{name: "unknown parent", data: "name: p\nextends: nope\n", wantErr: "extends unknown prompt"},
+		{name: "lint issues", data: "name: p\nsystem: \"{{.Input}}\"\n", wantIssues: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, issues, err := store.LintFile([]byte(tt.data), nil, "")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LintFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LintFile() error = %v", err)
+			}
+			if len(issues) != tt.wantIssues {
+				t.Errorf("LintFile() = %v, want %d issues", issues, tt.wantIssues)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/prompts/defaults"
+)
+
+// partialsDirName is the subdirectory of the prompts directory holding partials.
+const partialsDirName = "partials"
+
+// templateCallPattern matches {{template "name"}} actions, used to find partial references.
+var templateCallPattern = regexp.MustCompile(`{{-?\s*template\s+"([^"]+)"`)
+
+// loadBuiltInPartials registers the partials shipped with raypaste.
+func (s *Store) loadBuiltInPartials() {
+	s.partials[defaults.StrictRulesPartialName] = defaults.StrictOutputRules
+}
+
+// loadUserPartials loads every file in ~/.raypaste/prompts/partials/ as a partial
+// named after the file without its extension. User partials override built-ins.
+func (s *Store) loadUserPartials() error {
+	promptsDir, err := config.GetPromptsDir()
+	if err != nil {
+		return err
+	}
+	return s.loadPartialsDir(filepath.Join(promptsDir, partialsDirName))
+}
+
+// loadPartialsDir loads the partials in dir, then drops any that take part in a
+// {{template}} cycle.
+func (s *Store) loadPartialsDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to list partials: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load partial %s: %v\n", entry.Name(), err)
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if _, err := template.New(name).Funcs(funcMap(dir, nil, time.Now)).Parse(string(data)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load partial %s: %v\n", entry.Name(), err)
+			continue
+		}
+		s.partials[name] = string(data)
+		if s.partialSources == nil {
+			s.partialSources = make(map[string]string)
+		}
+		s.partialSources[name] = filepath.Join(dir, entry.Name())
+	}
+
+	s.dropPartialCycles()
+	return nil
+}
+
+// dropPartialCycles removes partials that include themselves, directly or through
+// other partials, so rendering fails with a missing template error rather than
+// recursing until text/template's depth limit.
+func (s *Store) dropPartialCycles() {
+	names := make([]string, 0, len(s.partials))
+	for name := range s.partials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if cycle := s.findPartialCycle(name, nil); cycle != nil {
+			fmt.Fprintf(os.Stderr, "Warning: partial cycle: %s\n", strings.Join(cycle, " -> "))
+			for _, member := range cycle {
+				delete(s.partials, member)
+			}
+		}
+	}
+}
+
+// findPartialCycle returns the first cycle reachable from name, or nil.
+func (s *Store) findPartialCycle(name string, chain []string) []string {
+	for i, seen := range chain {
+		if seen == name {
+			return append(append([]string(nil), chain[i:]...), name)
+		}
+	}
+
+	body, ok := s.partials[name]
+	if !ok {
+		return nil
+	}
+
+	chain = append(chain, name)
+	for _, match := range templateCallPattern.FindAllStringSubmatch(body, -1) {
+		if cycle := s.findPartialCycle(match[1], chain); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// addPartials associates every partial with tmpl so templates can call
+// {{template "name" .}}.
+func (s *Store) addPartials(tmpl *template.Template) error {
+	for name, body := range s.partials {
+		if _, err := tmpl.New(name).Parse(body); err != nil {
+			return fmt.Errorf("failed to parse partial '%s': %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Partials returns the names of all available partials, sorted.
+func (s *Store) Partials() []string {
+	names := make([]string, 0, len(s.partials))
+	for name := range s.partials {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PartialSource returns the path of the file the named partial was loaded from, or
+// "" for built-in partials.
+func (s *Store) PartialSource(name string) string {
+	return s.partialSources[name]
+}
+
+// PartialsUsed returns the names of the partials the named prompt includes, directly
+// or through other partials, sorted.
+func (s *Store) PartialsUsed(name string) ([]string, error) {
+	prompt, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	var visit func(body string)
+	visit = func(body string) {
+		for _, match := range templateCallPattern.FindAllStringSubmatch(body, -1) {
+			partial := match[1]
+			if used[partial] {
+				continue
+			}
+			if body, ok := s.partials[partial]; ok {
+				used[partial] = true
+				visit(body)
+			}
+		}
+	}
+	visit(prompt.System)
+
+	return sortedKeys(used), nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/prompts/defaults"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestLoadPartialsDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"signature.md":  "-- {{.Vars.name}}",
+		"greeting.tmpl": `Hello. {{template "signature" .}}`,
+		"loop-a.md":     `{{template "loop-b"}}`,
+		"loop-b.md":     `{{template "loop-a"}}`,
+		"broken.md":     "{{if}}",
+		".hidden":       "ignored",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &Store{prompts: make(map[string]*Prompt), partials: make(map[string]string)}
+	store.loadBuiltInPartials()
+	if err := store.loadPartialsDir(dir); err != nil {
+		t.Fatalf("loadPartialsDir() error = %v", err)
+	}
+
+	got := strings.Join(store.Partials(), ",")
+	if got != "greeting,signature,"+defaults.StrictRulesPartialName {
+		t.Errorf("Partials() = %s", got)
+	}
+
+	store.prompts["email"] = &Prompt{
+		Name:   "email",
+		System: `{{template "greeting" .}}` + "\n" + `{{template "strict-rules"}}`,
+	}
+	rendered, err := store.Render("email", types.OutputLengthMedium, "", map[string]string{"name": "Ray"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.HasPrefix(rendered, "Hello. -- Ray\nSTRICT OUTPUT RULES:") {
+		t.Errorf("Render() = %q", rendered)
+	}
+
+	store.prompts["cyclic"] = &Prompt{Name: "cyclic", System: `{{template "loop-a"}}`}
+	if _, err := store.Render("cyclic", types.OutputLengthMedium, "", nil); err == nil {
+		t.Error("Render() should fail when a partial was dropped for a cycle")
+	}
+}
+
+func TestLoadPartialsDirMissing(t *testing.T) {
+	store := &Store{partials: make(map[string]string)}
+	if err := store.loadPartialsDir(filepath.Join(t.TempDir(), "nope")); err != nil {
+		t.Errorf("loadPartialsDir() on a missing directory error = %v, want nil", err)
+	}
+}
@@ -6,15 +6,22 @@ package prompts
 import (
 	"bytes"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"text/template"
+	"time"
 	"unicode"
 
 	"github.com/raypaste/raypaste-cli/internal/config"
 	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/internal/projectcontext"
 	"github.com/raypaste/raypaste-cli/internal/prompts/defaults"
+	"github.com/raypaste/raypaste-cli/internal/schema"
 	"github.com/raypaste/raypaste-cli/pkg/types"
 
 	"gopkg.in/yaml.v3"
@@ -26,17 +33,120 @@ type Prompt struct {
 	Description      string            `yaml:"description"`
 	System           string            `yaml:"system"`
 	LengthDirectives map[string]string `yaml:"length_directives"`
+	Variables        []Variable        `yaml:"variables,omitempty"`
+	Extends          string            `yaml:"extends,omitempty"`
+	Model            string            `yaml:"model,omitempty"`
+	Temperature      *float64          `yaml:"temperature,omitempty"`
+	MaxTokens        map[string]int    `yaml:"max_tokens,omitempty"`
+	Stop             []string          `yaml:"stop,omitempty"`
+	ReasoningEffort  string            `yaml:"reasoning_effort,omitempty"`
+	// ReasoningMaxTokens caps the model's reasoning tokens; it is sent in place of
+	// ReasoningEffort.
+	ReasoningMaxTokens int `yaml:"reasoning_max_tokens,omitempty"`
+	// Lengths defines additional named output lengths, available to every prompt once loaded.
+	Lengths  map[string]types.LengthParams `yaml:"lengths,omitempty"`
+	Examples []Example                     `yaml:"examples,omitempty"`
+	// ExamplesPerLength limits how many examples are sent for a length; unset lengths send all.
+	ExamplesPerLength map[string]int `yaml:"examples_per_length,omitempty"`
+	// Steps makes the prompt a pipeline: each step's output is the next step's input.
+	// A pipeline has no system template of its own.
+	Steps []Step `yaml:"steps,omitempty"`
+	// PostProcess cleans up the prompt's output before it is printed or copied.
+	PostProcess *postprocess.Rules `yaml:"postprocess,omitempty"`
+	// Schema is a JSON Schema the prompt's output must match. The reply is validated
+	// and, if it doesn't match, retried once with the validation error.
+	Schema *schema.Schema `yaml:"schema,omitempty"`
+	// Tools names the project tools the model may call, such as read_file, to look
+	// up repository content while it answers.
+	Tools []string `yaml:"tools,omitempty"`
 }
 
+// Step is one stage of a pipeline prompt. Model and Length, when set, replace the
+// pipeline's model and length for this step.
+type Step struct {
+	Prompt string `yaml:"prompt" json:"prompt"`
+	Model  string `yaml:"model,omitempty" json:"model,omitempty"`
+	Length string `yaml:"length,omitempty" json:"length,omitempty"`
+}
+
+// Example is a few-shot input/output pair. When Lengths is set, the example is only
+// sent for those output lengths.
+type Example struct {
+	Input   string   `yaml:"input"`
+	Output  string   `yaml:"output"`
+	Lengths []string `yaml:"lengths,omitempty"`
+}
+
+// Variable declares a named value a prompt template reads via {{.Vars.<name>}}.
+type Variable struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Default     string `yaml:"default,omitempty" json:"default,omitempty"`
+	Required    bool   `yaml:"required,omitempty" json:"required,omitempty"`
+}
+
+// Origin identifies where a prompt was loaded from.
+type Origin string
+
+const (
+	OriginBuiltIn Origin = "built-in"
+	OriginUser    Origin = "user"
+	OriginProject Origin = "project"
+)
+
+// projectPromptsDir is the project-local prompts directory, relative to a directory
+// at or above the working directory.
+const projectPromptsDir = ".raypaste/prompts"
+
 // Store manages prompt templates
 type Store struct {
-	prompts map[string]*Prompt
+	prompts      map[string]*Prompt
+	partials     map[string]string
+	projectRoot  string
+	envAllowlist []string
+	now          func() time.Time
+	// sources maps each prompt loaded from a file to that file's path.
+	sources map[string]string
+	// origins records where each prompt loaded from a file came from.
+	origins map[string]Origin
+	// builtIns holds the prompts that ship with raypaste, including any that user or
+	// project prompts of the same name shadow.
+	builtIns map[string]*Prompt
+	// partialSources maps each partial loaded from a file to that file's path.
+	partialSources map[string]string
+	// projectDir is the project's .raypaste/prompts directory, if one was found.
+	projectDir string
+	// loadIssues records files that failed to load and duplicate prompt names,
+	// reported by Lint.
+	loadIssues []LintIssue
+	// lengths holds the user-defined output lengths of config.yaml and the loaded
+	// prompts.
+	lengths *config.Lengths
 }
 
-// NewStore creates a new prompt store and loads all prompts
+// NewStore creates a new prompt store and loads the built-in and user prompts
 func NewStore() (*Store, error) {
+	return NewStoreForDir("")
+}
+
+// NewStoreForDir is like NewStore, and also loads project prompts from the nearest
+// .raypaste/prompts/ directory at or above workingDir. Project prompts take
+// precedence over user prompts, which take precedence over built-ins.
+func NewStoreForDir(workingDir string) (*Store, error) {
+	return NewStoreWithLengths(workingDir, nil)
+}
+
+// NewStoreWithLengths is like NewStoreForDir, with the output lengths defined in
+// config.yaml available to every prompt.
+func NewStoreWithLengths(workingDir string, lengths map[string]types.LengthParams) (*Store, error) {
 	s := &Store{
-		prompts: make(map[string]*Prompt),
+		prompts:      make(map[string]*Prompt),
+		partials:     make(map[string]string),
+		envAllowlist: DefaultEnvAllowlist,
+		lengths:      config.NewLengths(),
+	}
+	if err := s.lengths.Define("", lengths); err != nil {
+		return nil, fmt.Errorf("invalid length in config: %w", err)
 	}
 
 	// Load built-in prompts
@@ -50,37 +160,49 @@ func NewStore() (*Store, error) {
 		fmt.Fprintf(os.Stderr, "Warning: failed to load user prompts: %v\n", err)
 	}
 
+	if workingDir != "" {
+		if err := s.loadProjectPrompts(workingDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load project prompts: %v\n", err)
+		}
+	}
+
+	// Apply `extends` once every prompt a child could inherit from is loaded
+	s.resolveInheritance()
+
+	s.loadBuiltInPartials()
+	if err := s.loadUserPartials(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to load partials: %v\n", err)
+	}
+	if s.projectDir != "" {
+		if err := s.loadPartialsDir(filepath.Join(s.projectDir, partialsDirName)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load project partials: %v\n", err)
+		}
+	}
+
 	return s, nil
 }
 
-// loadBuiltInPrompts loads the default built-in prompts
+// loadBuiltInPrompts loads the prompts listed in defaults.Prompts
 func (s *Store) loadBuiltInPrompts() error {
-	// Create default meta-prompt
-	metaPrompt := &Prompt{
-		Name:        defaults.MetaPromptName,
-		Description: defaults.MetaPromptDescription,
-		System:      defaults.MetaPromptTemplate,
-		LengthDirectives: map[string]string{
-			string(types.OutputLengthShort):  llm.LengthParams[types.OutputLengthShort].Directive,
-			string(types.OutputLengthMedium): llm.LengthParams[types.OutputLengthMedium].Directive,
-			string(types.OutputLengthLong):   llm.LengthParams[types.OutputLengthLong].Directive,
-		},
-	}
-
-	// Create bulletlist prompt (only supports short and medium)
-	bulletListPrompt := &Prompt{
-		Name:        defaults.BulletListName,
-		Description: defaults.BulletListDescription,
-		System:      defaults.BulletListTemplate,
-		LengthDirectives: map[string]string{
-			string(types.OutputLengthShort):  llm.LengthParams[types.OutputLengthShort].Directive,
-			string(types.OutputLengthMedium): llm.LengthParams[types.OutputLengthMedium].Directive,
-			// Note: long mode intentionally not supported for bulletlist
-		},
-	}
-
-	s.prompts[defaults.MetaPromptName] = metaPrompt
-	s.prompts[defaults.BulletListName] = bulletListPrompt
+	s.builtIns = make(map[string]*Prompt, len(defaults.Prompts))
+	for _, def := range defaults.Prompts {
+		prompt := &Prompt{
+			Name:             def.Name,
+			Description:      def.Description,
+			System:           def.Template,
+			LengthDirectives: make(map[string]string, len(def.Lengths)),
+		}
+		for _, length := range def.Lengths {
+			params, ok := llm.LengthParams[length]
+			if !ok {
+				return fmt.Errorf("built-in prompt %s: unknown length %s", def.Name, length)
+			}
+			prompt.LengthDirectives[string(length)] = params.Directive
+		}
+
+		s.builtIns[def.Name] = prompt
+		s.prompts[def.Name] = prompt
+	}
 	return nil
 }
 
@@ -96,6 +218,31 @@ func (s *Store) loadUserPrompts() error {
 		return nil // No user prompts yet
 	}
 
+	return s.loadPromptsDir(promptsDir, OriginUser)
+}
+
+// loadProjectPrompts loads prompts from the nearest .raypaste/prompts/ directory at
+// or above workingDir. The user's own prompts directory is never treated as a
+// project directory, even when the working directory is inside the home directory.
+func (s *Store) loadProjectPrompts(workingDir string) error {
+	dir, err := projectcontext.FindDir(workingDir, projectPromptsDir)
+	if err != nil {
+		return nil // No project prompts
+	}
+
+	userDir, err := config.GetPromptsDir()
+	if err == nil && filepath.Clean(userDir) == filepath.Clean(dir) {
+		return nil
+	}
+
+	s.projectDir = dir
+	return s.loadPromptsDir(dir, OriginProject)
+}
+
+// loadPromptsDir loads every .yaml and .yml prompt file in dir. Files that fail to
+// load are skipped with a warning; when two files in dir define the same name, the
+// one loaded last wins. Both cases are recorded for Lint.
+func (s *Store) loadPromptsDir(promptsDir string, origin Origin) error {
 	// Read all .yaml files
 	files, err := filepath.Glob(filepath.Join(promptsDir, "*.yaml"))
 	if err != nil {
@@ -111,8 +258,13 @@ func (s *Store) loadUserPrompts() error {
 
 	// Load each file
 	for _, file := range files {
-		if err := s.loadPromptFile(file); err != nil {
+		if err := s.loadPromptFile(file, origin); err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: failed to load prompt file %s: %v\n", file, err)
+			s.loadIssues = append(s.loadIssues, LintIssue{
+				Prompt:   filepath.Base(file),
+				Severity: LintError,
+				Message:  fmt.Sprintf("failed to load: %v", err),
+			})
 		}
 	}
 
@@ -120,25 +272,290 @@ func (s *Store) loadUserPrompts() error {
 }
 
 // loadPromptFile loads a single prompt file
-func (s *Store) loadPromptFile(path string) error {
+func (s *Store) loadPromptFile(path string, origin Origin) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("failed to read file: %w", err)
 	}
 
+	prompt, err := ParsePrompt(data, s.lengths)
+	if err != nil {
+		return err
+	}
+	if err := s.lengths.Define(prompt.Name, prompt.Lengths); err != nil {
+		return err
+	}
+
+	if previous, ok := s.sources[prompt.Name]; ok && filepath.Dir(previous) == filepath.Dir(path) {
+		s.loadIssues = append(s.loadIssues, LintIssue{
+			Prompt:   prompt.Name,
+			Severity: LintError,
+			Message:  fmt.Sprintf("defined in both %s and %s; %s takes precedence", filepath.Base(previous), filepath.Base(path), filepath.Base(path)),
+		})
+	}
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+		s.origins = make(map[string]Origin)
+	}
+	s.sources[prompt.Name] = path
+	s.origins[prompt.Name] = origin
+
+	s.prompts[prompt.Name] = prompt
+	return nil
+}
+
+// ParsePrompt parses and validates the contents of a prompt file against lengths, the
+// user-defined lengths already loaded, and the lengths the prompt defines itself,
+// which replace any it defined before. lengths is not modified.
+func ParsePrompt(data []byte, lengths *config.Lengths) (*Prompt, error) {
 	var prompt Prompt
 	if err := yaml.Unmarshal(data, &prompt); err != nil {
-		return fmt.Errorf("failed to parse YAML: %w", err)
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
 	}
 
 	if prompt.Name == "" {
+		return nil, fmt.Errorf("prompt name is required")
+	}
+
+	// The prompt's own lengths are defined first so its directives and max_tokens can use them
+	lengths, err := withOwnLengths(&prompt, lengths)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateVariables(prompt.Variables); err != nil {
+		return nil, err
+	}
+
+	if err := validateParams(&prompt, lengths); err != nil {
+		return nil, err
+	}
+
+	if err := validateSteps(&prompt, lengths); err != nil {
+		return nil, err
+	}
+
+	if err := prompt.PostProcess.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := validateTools(prompt.Tools); err != nil {
+		return nil, err
+	}
+
+	return &prompt, nil
+}
+
+// withOwnLengths returns a copy of lengths with the lengths prompt defines in place of
+// any it defined before.
+func withOwnLengths(prompt *Prompt, lengths *config.Lengths) (*config.Lengths, error) {
+	own := lengths.Clone()
+	if err := own.Define(prompt.Name, prompt.Lengths); err != nil {
+		return nil, err
+	}
+	return own, nil
+}
+
+// validateTools checks that every tool a prompt names exists.
+func validateTools(tools []string) error {
+	for i, name := range tools {
+		if !slices.Contains(llm.ProjectToolNames, name) {
+			return fmt.Errorf("tools[%d]: unknown tool '%s' (available: %s)", i, name, strings.Join(llm.ProjectToolNames, ", "))
+		}
+		if slices.Contains(tools[:i], name) {
+			return fmt.Errorf("tools[%d]: '%s' is listed twice", i, name)
+		}
+	}
+	return nil
+}
+
+// validateSteps checks the steps of a pipeline prompt.
+func validateSteps(prompt *Prompt, lengths *config.Lengths) error {
+	if len(prompt.Steps) > 0 && prompt.System != "" {
+		return fmt.Errorf("a prompt with steps can't have a system template; move it to a prompt of its own and add that as a step")
+	}
+	if len(prompt.Steps) > 0 && prompt.Schema != nil {
+		return fmt.Errorf("a prompt with steps can't have a schema; declare it on the prompt of the final step")
+	}
+	if len(prompt.Steps) > 0 && len(prompt.Tools) > 0 {
+		return fmt.Errorf("a prompt with steps can't have tools; declare them on the prompts of the steps that need them")
+	}
+	for i, step := range prompt.Steps {
+		if step.Prompt == "" {
+			return fmt.Errorf("steps[%d]: prompt is required", i)
+		}
+		if step.Prompt == prompt.Name {
+			return fmt.Errorf("steps[%d]: a pipeline can't run itself", i)
+		}
+		if step.Length != "" {
+			if _, err := lengths.Validate(step.Length); err != nil {
+				return fmt.Errorf("steps[%d]: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateName returns an error unless name is a valid prompt name, made up of
+// letters, numbers, hyphens and underscores. Prompt names become file and history
+// directory names, so anything else could reach outside the prompts directory.
+func ValidateName(name string) error {
+	if name == "" {
 		return fmt.Errorf("prompt name is required")
 	}
+	for _, r := range name {
+		if !isNameChar(r) {
+			return fmt.Errorf("invalid prompt name: %s (must contain only letters, numbers, hyphens, and underscores)", name)
+		}
+	}
+	return nil
+}
+
+// isNameChar reports whether r may appear in a prompt name.
+func isNameChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return true
+	case r >= 'A' && r <= 'Z':
+		return true
+	case r >= '0' && r <= '9':
+		return true
+	case r == '-', r == '_':
+		return true
+	default:
+		return false
+	}
+}
+
+// validateVariables checks that variable declarations are named and unique.
+func validateVariables(variables []Variable) error {
+	seen := make(map[string]bool, len(variables))
+	for _, v := range variables {
+		if v.Name == "" {
+			return fmt.Errorf("variable name is required")
+		}
+		if seen[v.Name] {
+			return fmt.Errorf("duplicate variable: %s", v.Name)
+		}
+		seen[v.Name] = true
+	}
+	return nil
+}
+
+// validateParams checks the request parameters a prompt declares against lengths.
+func validateParams(prompt *Prompt, lengths *config.Lengths) error {
+	if prompt.Temperature != nil && (*prompt.Temperature < 0 || *prompt.Temperature > 2) {
+		return fmt.Errorf("temperature must be between 0 and 2, got %v", *prompt.Temperature)
+	}
+
+	for length, maxTokens := range prompt.MaxTokens {
+		if _, err := lengths.Validate(length); err != nil {
+			return fmt.Errorf("max_tokens: %w", err)
+		}
+		if maxTokens <= 0 {
+			return fmt.Errorf("max_tokens for %s must be positive, got %d", length, maxTokens)
+		}
+	}
+
+	for i, example := range prompt.Examples {
+		if strings.TrimSpace(example.Input) == "" || strings.TrimSpace(example.Output) == "" {
+			return fmt.Errorf("examples[%d]: input and output are required", i)
+		}
+		for _, length := range example.Lengths {
+			if _, err := lengths.Validate(length); err != nil {
+				return fmt.Errorf("examples[%d]: %w", i, err)
+			}
+		}
+	}
+
+	for length, n := range prompt.ExamplesPerLength {
+		if _, err := lengths.Validate(length); err != nil {
+			return fmt.Errorf("examples_per_length: %w", err)
+		}
+		if n < 0 {
+			return fmt.Errorf("examples_per_length for %s must not be negative, got %d", length, n)
+		}
+	}
+
+	if prompt.ReasoningEffort != "" && !slices.Contains(config.ReasoningEfforts, prompt.ReasoningEffort) {
+		return fmt.Errorf("invalid reasoning_effort '%s' (expected one of: %s)", prompt.ReasoningEffort, strings.Join(config.ReasoningEfforts, ", "))
+	}
+	if prompt.ReasoningMaxTokens < 0 {
+		return fmt.Errorf("reasoning_max_tokens must be 0 or more, got %d", prompt.ReasoningMaxTokens)
+	}
 
-	s.prompts[prompt.Name] = &prompt
 	return nil
 }
 
+// SupportsLength reports whether the prompt can be rendered at length. Prompts without
+// length directives support every length. Prompts with directives restrict the built-in
+// lengths to those listed; user-defined lengths are always supported and fall back to
+// their own directive.
+func (p *Prompt) SupportsLength(length types.OutputLength) bool {
+	if _, ok := p.LengthDirectives[string(length)]; ok || len(p.LengthDirectives) == 0 {
+		return true
+	}
+	return !config.IsBuiltInLength(length)
+}
+
+// RequestOptions returns the request parameters the prompt declares for length,
+// including any max_tokens override (see GetMaxTokensOverride).
+func (p *Prompt) RequestOptions(length types.OutputLength) types.RequestOptions {
+	return types.RequestOptions{
+		MaxTokens:          p.maxTokens(length),
+		Temperature:        p.Temperature,
+		Stop:               p.Stop,
+		ReasoningEffort:    p.ReasoningEffort,
+		ReasoningMaxTokens: p.ReasoningMaxTokens,
+		Examples:           p.examplesFor(length),
+		Schema:             p.Schema.Doc(),
+	}
+}
+
+// examplesFor returns the examples to send for length, in declaration order,
+// limited by ExamplesPerLength.
+func (p *Prompt) examplesFor(length types.OutputLength) []types.Example {
+	limit, limited := p.ExamplesPerLength[string(length)]
+
+	var examples []types.Example
+	for _, example := range p.Examples {
+		if limited && len(examples) >= limit {
+			break
+		}
+		if len(example.Lengths) > 0 && !slices.Contains(example.Lengths, string(length)) {
+			continue
+		}
+		examples = append(examples, types.Example{Input: example.Input, Output: example.Output})
+	}
+	return examples
+}
+
+// ResolveVariables merges the provided values with the prompt's declared defaults.
+// Declared variables without a value resolve to their default (or an empty string);
+// undeclared values are passed through unchanged. It returns an error naming the
+// first required variable that has no value.
+func (p *Prompt) ResolveVariables(values map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(values)+len(p.Variables))
+	for k, v := range values {
+		resolved[k] = v
+	}
+
+	for _, v := range p.Variables {
+		if value, ok := resolved[v.Name]; ok && value != "" {
+			continue
+		}
+		if v.Required && v.Default == "" {
+			if v.Description != "" {
+				return nil, fmt.Errorf("prompt '%s' requires variable '%s' (%s)", p.Name, v.Name, v.Description)
+			}
+			return nil, fmt.Errorf("prompt '%s' requires variable '%s'", p.Name, v.Name)
+		}
+		resolved[v.Name] = v.Default
+	}
+
+	return resolved, nil
+}
+
 // Get retrieves a prompt by name
 func (s *Store) Get(name string) (*Prompt, error) {
 	prompt, ok := s.prompts[name]
@@ -148,6 +565,30 @@ func (s *Store) Get(name string) (*Prompt, error) {
 	return prompt, nil
 }
 
+// Steps returns the steps of the named pipeline prompt, or nil if it is a single
+// prompt. It returns an error if a step runs an unknown prompt or another pipeline,
+// or pins a length its prompt doesn't support.
+func (s *Store) Steps(name string) ([]Step, error) {
+	prompt, err := s.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range prompt.Steps {
+		stepPrompt, err := s.Get(step.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("pipeline '%s' step %d: %w", name, i+1, err)
+		}
+		if len(stepPrompt.Steps) > 0 {
+			return nil, fmt.Errorf("pipeline '%s' step %d: '%s' is itself a pipeline; steps must be single prompts", name, i+1, step.Prompt)
+		}
+		if step.Length != "" && !stepPrompt.SupportsLength(types.OutputLength(step.Length)) {
+			return nil, fmt.Errorf("pipeline '%s' step %d: prompt '%s' does not support output length '%s'", name, i+1, step.Prompt, step.Length)
+		}
+	}
+	return prompt.Steps, nil
+}
+
 // isNumericDirective returns true if s is a non-empty string consisting only of digits.
 // Such a directive is treated as a max_tokens override rather than injected text.
 func isNumericDirective(s string) bool {
@@ -172,14 +613,23 @@ func parseNumericDirective(s string) int {
 	return n
 }
 
-// GetMaxTokensOverride returns a custom max_tokens value if the prompt's length directive
-// for the given length is a pure integer (digits only). Returns 0 if no override applies.
+// GetMaxTokensOverride returns a custom max_tokens value for the given length: the
+// prompt's max_tokens entry if set, otherwise the length directive when it is a pure
+// integer (digits only). Returns 0 if no override applies.
 func (s *Store) GetMaxTokensOverride(name string, length types.OutputLength) int {
 	prompt, err := s.Get(name)
 	if err != nil {
 		return 0
 	}
-	directive, ok := prompt.LengthDirectives[string(length)]
+	return prompt.maxTokens(length)
+}
+
+// maxTokens implements GetMaxTokensOverride for a single prompt.
+func (p *Prompt) maxTokens(length types.OutputLength) int {
+	if maxTokens := p.MaxTokens[string(length)]; maxTokens > 0 {
+		return maxTokens
+	}
+	directive, ok := p.LengthDirectives[string(length)]
 	if !ok {
 		return 0
 	}
@@ -189,26 +639,29 @@ func (s *Store) GetMaxTokensOverride(name string, length types.OutputLength) int
 	return parseNumericDirective(directive)
 }
 
-// Render renders a prompt template with the given output length and project context.
-// The context string is injected into the template via {{.Context}}.
+// Render renders a prompt template with the given output length, project context and variables.
+// The context string is injected into the template via {{.Context}} and variables via {{.Vars.<name>}}.
 // If the length directive for this prompt is a pure integer (used as a max_tokens override),
 // {{.LengthDirective}} is replaced with an empty string.
-func (s *Store) Render(name string, length types.OutputLength, context string) (string, error) {
+func (s *Store) Render(name string, length types.OutputLength, context string, vars map[string]string) (string, error) {
 	prompt, err := s.Get(name)
 	if err != nil {
 		return "", err
 	}
+	if len(prompt.Steps) > 0 {
+		return "", fmt.Errorf("prompt '%s' is a pipeline of %d steps and has no system prompt of its own", name, len(prompt.Steps))
+	}
 
 	directive, ok := prompt.LengthDirectives[string(length)]
 	if !ok {
-		// Check if this prompt has any length directives defined
-		if len(prompt.LengthDirectives) > 0 {
-			// If the prompt has specific length directives but this length isn't supported,
-			// return an error instead of falling back
+		// If the prompt has specific length directives but this length isn't supported,
+		// return an error instead of falling back
+		if !prompt.SupportsLength(length) {
 			return "", fmt.Errorf("prompt '%s' does not support output length '%s'", name, length)
 		}
-		// Fall back to default directive for prompts without specific directives
-		directive = llm.LengthParams[length].Directive
+		// Fall back to the length's own directive
+		params, _ := llm.GetLengthParams(length, s.lengths)
+		directive = params.Directive
 	}
 
 	// Numeric directives act as max_tokens overrides; don't inject them as text.
@@ -216,17 +669,27 @@ func (s *Store) Render(name string, length types.OutputLength, context string) (
 		directive = ""
 	}
 
-	// Parse template
-	tmpl, err := template.New("prompt").Parse(prompt.System)
+	resolvedVars, err := prompt.ResolveVariables(vars)
+	if err != nil {
+		return "", err
+	}
+
+	// Parse template. Unset variables render as empty strings rather than "<no value>".
+	tmpl := s.newTemplate("prompt")
+	if err := s.addPartials(tmpl); err != nil {
+		return "", err
+	}
+	tmpl, err = tmpl.Parse(prompt.System)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse template: %w", err)
 	}
 
 	// Render template
 	var buf bytes.Buffer
-	data := map[string]string{
+	data := map[string]interface{}{
 		"LengthDirective": directive,
 		"Context":         context,
+		"Vars":            resolvedVars,
 	}
 	if err := tmpl.Execute(&buf, data); err != nil {
 		return "", fmt.Errorf("failed to render template: %w", err)
@@ -235,6 +698,38 @@ func (s *Store) Render(name string, length types.OutputLength, context string) (
 	return buf.String(), nil
 }
 
+// SetProjectRoot sets the directory template functions such as {{include}} and
+// {{gitBranch}} resolve against. It defaults to the working directory, where
+// {{include}} only works if it is a project root.
+func (s *Store) SetProjectRoot(dir string) {
+	s.projectRoot = dir
+}
+
+// ProjectRoot returns the directory template functions and project tools resolve
+// paths against: the one set with SetProjectRoot, or the working directory.
+func (s *Store) ProjectRoot() string {
+	if s.projectRoot != "" {
+		return s.projectRoot
+	}
+	root, _ := os.Getwd()
+	return root
+}
+
+// AllowEnv adds environment variable names templates may read with {{env}}.
+func (s *Store) AllowEnv(names ...string) {
+	s.envAllowlist = append(append([]string(nil), s.envAllowlist...), names...)
+}
+
+// newTemplate creates a template with the store's function library installed.
+func (s *Store) newTemplate(name string) *template.Template {
+	root := s.ProjectRoot()
+	now := s.now
+	if now == nil {
+		now = time.Now
+	}
+	return template.New(name).Option("missingkey=zero").Funcs(funcMap(root, s.envAllowlist, now))
+}
+
 // List returns a list of all available prompt names
 func (s *Store) List() []string {
 	names := make([]string, 0, len(s.prompts))
@@ -244,10 +739,22 @@ func (s *Store) List() []string {
 	return names
 }
 
-// SavePrompt saves a custom prompt to the user's prompts directory
+// SavePrompt saves a custom prompt to the user's prompts directory. The prompt is
+// validated by ParsePrompt, as it will be when the file is next loaded.
 func (s *Store) SavePrompt(prompt *Prompt) error {
-	if prompt.Name == "" {
-		return fmt.Errorf("prompt name is required")
+	if err := ValidateName(prompt.Name); err != nil {
+		return err
+	}
+
+	// Marshal the prompt to YAML
+	data, err := yaml.Marshal(prompt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prompt to YAML: %w", err)
+	}
+
+	saved, err := ParsePrompt(data, s.lengths)
+	if err != nil {
+		return err
 	}
 
 	promptsDir, err := config.GetPromptsDir()
@@ -260,65 +767,171 @@ func (s *Store) SavePrompt(prompt *Prompt) error {
 		return fmt.Errorf("failed to create prompts directory: %w", err)
 	}
 
-	// Marshal the prompt to YAML
-	data, err := yaml.Marshal(prompt)
-	if err != nil {
-		return fmt.Errorf("failed to marshal prompt to YAML: %w", err)
-	}
-
-	// Write to file
+	// Write to file, keeping the previous version in the prompt's history
 	filename := filepath.Join(promptsDir, prompt.Name+".yaml")
-	if err := os.WriteFile(filename, data, 0644); err != nil {
-		return fmt.Errorf("failed to write prompt file: %w", err)
+	if err := WritePromptFile(filename, prompt.Name, data); err != nil {
+		return err
 	}
 
 	// Add to the store's prompts map
-	s.prompts[prompt.Name] = prompt
+	if err := s.lengths.Define(saved.Name, saved.Lengths); err != nil {
+		return err
+	}
+	s.prompts[saved.Name] = saved
+	if s.sources == nil {
+		s.sources = make(map[string]string)
+		s.origins = make(map[string]Origin)
+	}
+	s.sources[prompt.Name] = filename
+	s.origins[prompt.Name] = OriginUser
 
 	return nil
 }
 
-// DeletePrompt removes a custom prompt from the user's prompts directory
+// DeletePrompt removes a custom prompt from the user's prompts directory. Deleting a
+// user prompt that shadows a built-in restores the built-in.
 func (s *Store) DeletePrompt(name string) error {
 	// Check if prompt exists
-	prompt, ok := s.prompts[name]
-	if !ok {
+	if _, ok := s.prompts[name]; !ok {
 		return fmt.Errorf("prompt not found: %s", name)
 	}
 
-	// Check if it's a built-in prompt (cannot delete built-ins)
-	if prompt.Name == defaults.MetaPromptName || prompt.Name == defaults.BulletListName {
+	// Built-ins ship with raypaste and can only be shadowed, not deleted
+	if s.Origin(name) == OriginBuiltIn {
 		return fmt.Errorf("cannot delete built-in prompt: %s", name)
 	}
 
+	// Project prompts belong to the repository, not the user
+	if s.Origin(name) == OriginProject {
+		return fmt.Errorf("prompt '%s' is defined by the project in %s; remove it from the repository instead", name, s.sources[name])
+	}
+
 	promptsDir, err := config.GetPromptsDir()
 	if err != nil {
 		return err
 	}
 
-	// Try to delete the file (may not exist if loaded from elsewhere)
-	filename := filepath.Join(promptsDir, name+".yaml")
-	if _, err := os.Stat(filename); err == nil {
+	// Try to delete the file it was loaded from (may not exist if loaded from elsewhere),
+	// and the .yaml and .yml files named after it. The contents are kept in the
+	// prompt's history so it can be restored.
+	filenames := []string{s.sources[name], filepath.Join(promptsDir, name+".yaml"), filepath.Join(promptsDir, name+".yml")}
+	for _, filename := range filenames {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			continue
+		}
+		if err := recordVersion(name, data); err != nil {
+			return err
+		}
 		if err := os.Remove(filename); err != nil {
 			return fmt.Errorf("failed to delete prompt file: %w", err)
 		}
 	}
 
-	// Also try .yml extension
-	filenameYml := filepath.Join(promptsDir, name+".yml")
-	if _, err := os.Stat(filenameYml); err == nil {
-		if err := os.Remove(filenameYml); err != nil {
-			return fmt.Errorf("failed to delete prompt file: %w", err)
-		}
+	// Remove from the store's prompts map, uncovering the built-in it shadowed
+	if err := s.lengths.Define(name, nil); err != nil {
+		return err
 	}
-
-	// Remove from the store's prompts map
 	delete(s.prompts, name)
+	delete(s.sources, name)
+	delete(s.origins, name)
+	if builtIn, ok := s.builtIns[name]; ok {
+		s.prompts[name] = builtIn
+	}
 
 	return nil
 }
 
-// IsBuiltIn checks if a prompt is a built-in prompt
+// Origin returns where the named prompt was loaded from. Prompts not loaded from a
+// file are built-in.
+func (s *Store) Origin(name string) Origin {
+	if origin, ok := s.origins[name]; ok {
+		return origin
+	}
+	return OriginBuiltIn
+}
+
+// Source returns the path of the file the named prompt was loaded from, or "" for
+// built-in prompts.
+func (s *Store) Source(name string) string {
+	return s.sources[name]
+}
+
+// WithPrompts returns a copy of s with added prompts and partials on top of the
+// loaded ones, with `extends` resolved for the added prompts. It lets callers lint
+// prompts before installing them; s itself is not modified.
+func (s *Store) WithPrompts(added []*Prompt, partials map[string]string) (*Store, error) {
+	preview := *s
+	preview.loadIssues = nil
+	preview.prompts = maps.Clone(s.prompts)
+	preview.partials = maps.Clone(s.partials)
+	preview.lengths = s.lengths.Clone()
+	for _, prompt := range added {
+		if err := preview.lengths.Define(prompt.Name, prompt.Lengths); err != nil {
+			return nil, fmt.Errorf("prompt '%s': %w", prompt.Name, err)
+		}
+	}
+
+	resolved := maps.Clone(s.prompts)
+	for _, prompt := range added {
+		preview.prompts[prompt.Name] = prompt
+		delete(resolved, prompt.Name)
+	}
+	for _, prompt := range added {
+		merged, err := preview.resolvePrompt(prompt.Name, resolved, nil)
+		if err != nil {
+			return nil, fmt.Errorf("prompt '%s': %w", prompt.Name, err)
+		}
+		resolved[prompt.Name] = merged
+	}
+	preview.prompts = resolved
+
+	for name, body := range partials {
+		if _, err := template.New(name).Funcs(funcMap("", nil, time.Now)).Parse(body); err != nil {
+			return nil, fmt.Errorf("partial '%s': %w", name, err)
+		}
+		preview.partials[name] = body
+	}
+	for _, name := range sortedKeys(partials) {
+		if cycle := preview.findPartialCycle(name, nil); cycle != nil {
+			return nil, fmt.Errorf("partial cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	return &preview, nil
+}
+
+// Lengths returns the user-defined output lengths of config.yaml and the loaded prompts.
+func (s *Store) Lengths() *config.Lengths {
+	return s.lengths
+}
+
+// ProjectDir returns the project prompts directory in use, or "" if there is none.
+func (s *Store) ProjectDir() string {
+	return s.projectDir
+}
+
+// IsBuiltIn checks if a prompt is a built-in prompt. It is also true for built-ins
+// that a user or project prompt shadows; see Overrides.
 func (s *Store) IsBuiltIn(name string) bool {
-	return name == defaults.MetaPromptName || name == defaults.BulletListName
+	_, ok := s.builtIns[name]
+	return ok
+}
+
+// Overrides reports whether the named prompt is a user or project prompt shadowing
+// a built-in prompt of the same name.
+func (s *Store) Overrides(name string) bool {
+	return s.IsBuiltIn(name) && s.Origin(name) != OriginBuiltIn
+}
+
+// ResetPrompt removes the user prompt that shadows the named built-in, restoring the
+// built-in. The removed file is kept in the prompt's history.
+func (s *Store) ResetPrompt(name string) error {
+	if !s.IsBuiltIn(name) {
+		return fmt.Errorf("'%s' is not a built-in prompt", name)
+	}
+	if !s.Overrides(name) {
+		return fmt.Errorf("built-in prompt '%s' is not overridden", name)
+	}
+	return s.DeletePrompt(name)
 }
@@ -4,8 +4,14 @@ Copyright © 2026 Raypaste
 package prompts
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
 	"github.com/raypaste/raypaste-cli/pkg/types"
 )
 
@@ -73,7 +79,7 @@ func TestStoreRender(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := store.Render(tt.prompt, tt.length, "")
+			got, err := store.Render(tt.prompt, tt.length, "", nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Store.Render() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -130,7 +136,7 @@ func TestBulletListPrompt(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := store.Render("bulletlist", tt.length, "")
+			got, err := store.Render("bulletlist", tt.length, "", nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Store.Render(bulletlist, %s) error = %v, wantErr %v", tt.length, err, tt.wantErr)
 				return
@@ -203,21 +209,51 @@ func TestStoreIsBuiltIn(t *testing.T) {
 }
 
 func TestSavePromptValidation(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
 	store, err := NewStore()
 	if err != nil {
 		t.Fatalf("NewStore() error = %v", err)
 	}
 
-	// Test that saving a prompt without a name fails
-	prompt := &Prompt{
-		Name:        "",
-		Description: "Test description",
-		System:      "Test system prompt",
+	tests := []struct {
+		name   string
+		prompt *Prompt
+	}{
+		{"empty name", &Prompt{Description: "Test description", System: "Test system prompt"}},
+		{"name outside the prompts directory", &Prompt{Name: "../escape", System: "Test system prompt"}},
+		{"pipeline with a system template", &Prompt{Name: "plan", System: "x", Steps: []Step{{Prompt: "metaprompt"}}}},
+		{"step without a prompt", &Prompt{Name: "plan", Steps: []Step{{Length: "short"}}}},
+		{"invalid postprocess pattern", &Prompt{Name: "tidy", System: "x", PostProcess: &postprocess.Rules{Replace: []postprocess.Replacement{{Pattern: "("}}}}},
+		{"unknown tool", &Prompt{Name: "grounded", System: "x", Tools: []string{"rm_rf"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := store.SavePrompt(tt.prompt); err == nil {
+				t.Error("SavePrompt() should fail")
+			}
+			if _, err := store.Get(tt.prompt.Name); tt.prompt.Name != "" && err == nil {
+				t.Error("a prompt that failed to save should not be in the store")
+			}
+		})
 	}
+}
 
-	err = store.SavePrompt(prompt)
-	if err == nil {
-		t.Error("SavePrompt() should fail when name is empty")
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"my-prompt_2", false},
+		{"", true},
+		{"with space", true},
+		{"../escape", true},
+		{"dir/name", true},
+		{"résumé", true},
+	}
+	for _, tt := range tests {
+		if err := ValidateName(tt.name); (err != nil) != tt.wantErr {
+			t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
 	}
 }
 
@@ -329,7 +365,7 @@ func TestRenderNumericDirectiveInjectsEmpty(t *testing.T) {
 		},
 	}
 
-	rendered, err := store.Render("sql", types.OutputLengthShort, "")
+	rendered, err := store.Render("sql", types.OutputLengthShort, "", nil)
 	if err != nil {
 		t.Fatalf("Render() error = %v", err)
 	}
@@ -338,3 +374,492 @@ func TestRenderNumericDirectiveInjectsEmpty(t *testing.T) {
 		t.Errorf("Render() with numeric directive should inject empty string, got: %q", rendered)
 	}
 }
+
+func TestRenderVariables(t *testing.T) {
+	store := &Store{prompts: make(map[string]*Prompt)}
+	store.prompts["email"] = &Prompt{
+		Name:   "email",
+		System: "Write for {{.Vars.audience}} in a {{.Vars.tone}} tone.{{.Vars.extra}}",
+		Variables: []Variable{
+			{Name: "audience", Description: "Who will read it", Required: true},
+			{Name: "tone", Default: "friendly"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		vars    map[string]string
+		want    string
+		wantErr bool
+	}{
+		{"default applied", map[string]string{"audience": "executives"}, "Write for executives in a friendly tone.", false},
+		{"default overridden", map[string]string{"audience": "engineers", "tone": "terse"}, "Write for engineers in a terse tone.", false},
+		{"undeclared variable passed through", map[string]string{"audience": "a", "extra": " Be brief."}, "Write for a in a friendly tone. Be brief.", false},
+		{"missing required", nil, "", true},
+		{"empty required", map[string]string{"audience": ""}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Render("email", types.OutputLengthMedium, "", tt.vars)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSavePromptVariableValidation(t *testing.T) {
+	tests := []struct {
+		name      string
+		variables []Variable
+	}{
+		{"unnamed variable", []Variable{{Description: "no name"}}},
+		{"duplicate variable", []Variable{{Name: "tone"}, {Name: "tone"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &Store{prompts: make(map[string]*Prompt), lengths: config.NewLengths()}
+			prompt := &Prompt{Name: "p", System: "x", Variables: tt.variables}
+			if err := store.SavePrompt(prompt); err == nil {
+				t.Error("SavePrompt() should reject invalid variables")
+			}
+		})
+	}
+}
+
+func TestPromptRequestOptions(t *testing.T) {
+	temperature := 0.1
+	prompt := &Prompt{
+		Name:             "review",
+		LengthDirectives: map[string]string{"short": "300", "medium": "Be thorough.", "long": "Be exhaustive."},
+		MaxTokens:        map[string]int{"medium": 2000, "short": 100},
+		Temperature:      &temperature,
+		Stop:             []string{"END"},
+		ReasoningEffort:  "high",
+	}
+
+	tests := []struct {
+		length        types.OutputLength
+		wantMaxTokens int
+	}{
+		{types.OutputLengthShort, 100}, // max_tokens wins over a numeric directive
+		{types.OutputLengthMedium, 2000},
+		{types.OutputLengthLong, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.length), func(t *testing.T) {
+			opts := prompt.RequestOptions(tt.length)
+			if opts.MaxTokens != tt.wantMaxTokens {
+				t.Errorf("MaxTokens = %d, want %d", opts.MaxTokens, tt.wantMaxTokens)
+			}
+			if opts.Temperature == nil || *opts.Temperature != 0.1 || opts.ReasoningEffort != "high" || len(opts.Stop) != 1 {
+				t.Errorf("RequestOptions() = %+v, want prompt parameters", opts)
+			}
+		})
+	}
+}
+
+func TestValidateParams(t *testing.T) {
+	tooHot := 2.5
+	ok := 0.3
+	tests := []struct {
+		name    string
+		prompt  Prompt
+		wantErr bool
+	}{
+		{"no parameters", Prompt{}, false},
+		{"valid parameters", Prompt{Temperature: &ok, MaxTokens: map[string]int{"short": 10}, ReasoningEffort: "low"}, false},
+		{"temperature out of range", Prompt{Temperature: &tooHot}, true},
+		{"unknown length in max_tokens", Prompt{MaxTokens: map[string]int{"huge": 10}}, true},
+		{"non-positive max_tokens", Prompt{MaxTokens: map[string]int{"short": 0}}, true},
+		{"unknown reasoning effort", Prompt{ReasoningEffort: "extreme"}, true},
+		{"negative reasoning_max_tokens", Prompt{ReasoningMaxTokens: -1}, true},
+		{"example without output", Prompt{Examples: []Example{{Input: "x"}}}, true},
+		{"example with unknown length", Prompt{Examples: []Example{{Input: "x", Output: "y", Lengths: []string{"huge"}}}}, true},
+		{"negative examples_per_length", Prompt{ExamplesPerLength: map[string]int{"short": -1}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateParams(&tt.prompt, nil); (err != nil) != tt.wantErr {
+				t.Errorf("validateParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPromptExamplesFor(t *testing.T) {
+	prompt := &Prompt{
+		Name: "tagger",
+		Examples: []Example{
+			{Input: "a", Output: "A"},
+			{Input: "b", Output: "B", Lengths: []string{"long"}},
+			{Input: "c", Output: "C"},
+			{Input: "d", Output: "D"},
+		},
+		ExamplesPerLength: map[string]int{"short": 1, "medium": 0},
+	}
+
+	tests := []struct {
+		length types.OutputLength
+		want   string
+	}{
+		{types.OutputLengthShort, "a"},
+		{types.OutputLengthMedium, ""},
+		{types.OutputLengthLong, "abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.length), func(t *testing.T) {
+			var got string
+			for _, example := range prompt.RequestOptions(tt.length).Examples {
+				got += example.Input
+			}
+			if got != tt.want {
+				t.Errorf("examples for %s = %q, want %q", tt.length, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromptDefinedLength(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tweet.yaml")
+	content := `name: prompts-test-tweeter
+description: Tweets
+system: "{{.LengthDirective}}"
+lengths:
+  prompts-test-tweet:
+    max_tokens: 80
+    directive: Fit in one tweet.
+length_directives:
+  short: Be brief.
+max_tokens:
+  prompts-test-tweet: 70
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &Store{prompts: make(map[string]*Prompt), lengths: config.NewLengths(), now: time.Now}
+	if err := store.loadPromptFile(path, OriginUser); err != nil {
+		t.Fatalf("loadPromptFile() error = %v", err)
+	}
+
+	prompt, _ := store.Get("prompts-test-tweeter")
+	tests := []struct {
+		length types.OutputLength
+		want   bool
+	}{
+		{types.OutputLengthShort, true},
+		{types.OutputLengthLong, false},
+		{"prompts-test-tweet", true},
+	}
+	for _, tt := range tests {
+		if got := prompt.SupportsLength(tt.length); got != tt.want {
+			t.Errorf("SupportsLength(%s) = %v, want %v", tt.length, got, tt.want)
+		}
+	}
+
+	got, err := store.Render("prompts-test-tweeter", "prompts-test-tweet", "", nil)
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if got != "Fit in one tweet." {
+		t.Errorf("Render() = %q, want the length's own directive", got)
+	}
+	if opts := prompt.RequestOptions("prompts-test-tweet"); opts.MaxTokens != 70 {
+		t.Errorf("RequestOptions().MaxTokens = %d, want 70", opts.MaxTokens)
+	}
+}
+
+func TestPromptLengthsPerStore(t *testing.T) {
+	dir := t.TempDir()
+	tweeter := "name: tweeter\nsystem: \"{{.Context}}\"\nlengths:\n  tweet:\n    max_tokens: 100\n"
+	broken := "name: broken\nsystem: \"{{.Context}}\"\nlengths:\n  haiku:\n    max_tokens: 30\ntemperature: 5\n"
+	for file, content := range map[string]string{"tweeter.yaml": tweeter, "broken.yaml": broken} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &Store{prompts: make(map[string]*Prompt), partials: make(map[string]string), lengths: config.NewLengths(), now: time.Now}
+	if err := store.loadPromptsDir(dir, OriginUser); err != nil {
+		t.Fatalf("loadPromptsDir() error = %v", err)
+	}
+	if _, ok := store.Lengths().Get("tweet"); !ok {
+		t.Error("Lengths() should hold the length tweeter defines")
+	}
+	if _, ok := store.Lengths().Get("haiku"); ok {
+		t.Error("Lengths() should not hold a length from a prompt that failed to load")
+	}
+
+	// A prompt can redefine its own lengths, but not another prompt's
+	redefined := strings.Replace(tweeter, "100", "120", 1)
+	if _, _, err := store.LintFile([]byte(redefined), nil, ""); err != nil {
+		t.Errorf("LintFile() redefining its own length error = %v", err)
+	}
+	if params, _ := store.Lengths().Get("tweet"); params.MaxTokens != 100 {
+		t.Errorf("LintFile() changed the store's tweet length to %d", params.MaxTokens)
+	}
+	other := strings.Replace(redefined, "name: tweeter", "name: other", 1)
+	if _, _, err := store.LintFile([]byte(other), nil, ""); err == nil {
+		t.Error("LintFile() should reject a length another prompt defines differently")
+	}
+
+	if _, ok := (&Store{lengths: config.NewLengths()}).Lengths().Get("tweet"); ok {
+		t.Error("a new store should not see another store's lengths")
+	}
+}
+
+func TestNewStoreForDirProjectPrompts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	writePrompt := func(dir, file, content string) {
+		t.Helper()
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	userDir := filepath.Join(home, ".raypaste", "prompts")
+	writePrompt(userDir, "review.yaml", "name: review\ndescription: user\nsystem: \"{{.Context}}\"\n")
+	writePrompt(userDir, "mine.yaml", "name: mine\nsystem: \"{{.Context}}\"\n")
+
+	repo := t.TempDir()
+	projectDir := filepath.Join(repo, ".raypaste", "prompts")
+	writePrompt(projectDir, "review.yaml", "name: review\ndescription: project\nsystem: \"{{.Context}}\"\n")
+	writePrompt(projectDir, "metaprompt.yaml", "name: metaprompt\ndescription: team metaprompt\nsystem: \"{{.Context}}\"\n")
+
+	workingDir := filepath.Join(repo, "src", "pkg")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewStoreForDir(workingDir)
+	if err != nil {
+		t.Fatalf("NewStoreForDir() error = %v", err)
+	}
+	if store.ProjectDir() != projectDir {
+		t.Errorf("ProjectDir() = %q, want %q", store.ProjectDir(), projectDir)
+	}
+
+	tests := []struct {
+		name        string
+		origin      Origin
+		description string
+	}{
+		{"review", OriginProject, "project"},
+		{"metaprompt", OriginProject, "team metaprompt"},
+		{"mine", OriginUser, ""},
+		{"bulletlist", OriginBuiltIn, ""},
+	}
+	for _, tt := range tests {
+		prompt, err := store.Get(tt.name)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", tt.name, err)
+		}
+		if got := store.Origin(tt.name); got != tt.origin {
+			t.Errorf("Origin(%s) = %q, want %q", tt.name, got, tt.origin)
+		}
+		if tt.description != "" && prompt.Description != tt.description {
+			t.Errorf("Get(%s).Description = %q, want %q", tt.name, prompt.Description, tt.description)
+		}
+	}
+
+	if got := store.Source("review"); got != filepath.Join(projectDir, "review.yaml") {
+		t.Errorf("Source(review) = %q", got)
+	}
+	if err := store.DeletePrompt("review"); err == nil {
+		t.Error("DeletePrompt() should refuse to delete a project prompt")
+	}
+
+	// Without a working directory only user prompts are loaded
+	store, err = NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+	if got := store.Origin("review"); got != OriginUser {
+		t.Errorf("NewStore() Origin(review) = %q, want %q", got, OriginUser)
+	}
+}
+
+func TestOverrideBuiltIn(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	promptsDir := filepath.Join(home, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		// Shadows bulletlist, inheriting everything but the description
+		"bulletlist.yaml": "name: bulletlist\nextends: bulletlist\ndescription: Team bullets\n",
+		// Shadows metaprompt outright, from a file with another name
+		"my-meta.yaml": "name: metaprompt\nsystem: \"custom {{.Context}}\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	bullets, err := store.Get("bulletlist")
+	if err != nil {
+		t.Fatalf("Get(bulletlist) error = %v", err)
+	}
+	if bullets.Description != "Team bullets" || !strings.Contains(bullets.System, "text organizer") {
+		t.Errorf("bulletlist override = %+v, want the built-in template with the new description", bullets)
+	}
+	if _, ok := bullets.LengthDirectives["long"]; ok {
+		t.Error("bulletlist override should inherit the built-in's lengths")
+	}
+
+	for _, name := range []string{"bulletlist", "metaprompt"} {
+		if !store.IsBuiltIn(name) || !store.Overrides(name) || store.Origin(name) != OriginUser {
+			t.Errorf("%s: IsBuiltIn = %v, Overrides = %v, Origin = %s", name, store.IsBuiltIn(name), store.Overrides(name), store.Origin(name))
+		}
+	}
+
+	if err := store.ResetPrompt("metaprompt"); err != nil {
+		t.Fatalf("ResetPrompt() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(promptsDir, "my-meta.yaml")); !os.IsNotExist(err) {
+		t.Errorf("ResetPrompt() should remove the override file, stat error = %v", err)
+	}
+	meta, err := store.Get("metaprompt")
+	if err != nil || !strings.Contains(meta.System, "meta-prompt engineer") {
+		t.Errorf("Get(metaprompt) after ResetPrompt() = %+v, %v, want the built-in", meta, err)
+	}
+	if store.Overrides("metaprompt") {
+		t.Error("metaprompt should no longer be overridden")
+	}
+
+	if err := store.ResetPrompt("metaprompt"); err == nil {
+		t.Error("ResetPrompt() should fail when the built-in is not overridden")
+	}
+	if err := store.ResetPrompt("nonexistent"); err == nil {
+		t.Error("ResetPrompt() should fail for a prompt that is not built-in")
+	}
+	if err := store.DeletePrompt("metaprompt"); err == nil {
+		t.Error("DeletePrompt() should fail for a built-in prompt")
+	}
+}
+
+func TestPipelineSteps(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	promptsDir := filepath.Join(home, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"refine.yaml":      "name: refine\nsteps:\n  - prompt: bulletlist\n    length: short\n  - prompt: metaprompt\n    model: openai-gpt5-nano\n",
+		"nested.yaml":      "name: nested\nsteps:\n  - prompt: refine\n",
+		"unknown.yaml":     "name: unknown\nsteps:\n  - prompt: missing\n",
+		"bad-length.yaml":  "name: bad-length\nsteps:\n  - prompt: bulletlist\n    length: long\n",
+		"with-system.yaml": "name: with-system\nsystem: hi\nsteps:\n  - prompt: metaprompt\n",
+		"with-schema.yaml": "name: with-schema\nsteps:\n  - prompt: metaprompt\nschema:\n  type: object\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	steps, err := store.Steps("refine")
+	if err != nil || len(steps) != 2 || steps[1].Model != "openai-gpt5-nano" {
+		t.Errorf("Steps(refine) = %+v, %v", steps, err)
+	}
+	if steps, err := store.Steps("metaprompt"); err != nil || steps != nil {
+		t.Errorf("Steps(metaprompt) = %+v, %v, want no steps", steps, err)
+	}
+	for _, name := range []string{"nested", "unknown", "bad-length"} {
+		if _, err := store.Steps(name); err == nil {
+			t.Errorf("Steps(%s) should fail", name)
+		}
+	}
+	if _, err := store.Get("with-system"); err == nil {
+		t.Error("a prompt with both system and steps should not load")
+	}
+	if _, err := store.Get("with-schema"); err == nil {
+		t.Error("a pipeline with a schema should not load")
+	}
+	if _, err := store.Render("refine", types.OutputLengthShort, "", nil); err == nil {
+		t.Error("Render() should fail for a pipeline")
+	}
+}
+
+func TestPromptPostProcess(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	promptsDir := filepath.Join(home, ".raypaste", "prompts")
+	if err := os.MkdirAll(promptsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"clean.yaml":     "name: clean\nsystem: hi\npostprocess:\n  strip_preamble: true\n  replace:\n    - pattern: '\\*\\*'\n      with: ''\n",
+		"child.yaml":     "name: child\nextends: clean\nsystem: hello\n",
+		"bad-regex.yaml": "name: bad-regex\nsystem: hi\npostprocess:\n  replace:\n    - pattern: '('\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(promptsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := NewStore()
+	if err != nil {
+		t.Fatalf("NewStore() error = %v", err)
+	}
+
+	for _, name := range []string{"clean", "child"} {
+		prompt, err := store.Get(name)
+		if err != nil {
+			t.Fatalf("Get(%s) error = %v", name, err)
+		}
+		if got := prompt.PostProcess.Apply("Sure!\n**Bold** text"); got != "Bold text" {
+			t.Errorf("%s: Apply() = %q, want %q", name, got, "Bold text")
+		}
+	}
+	if _, err := store.Get("bad-regex"); err == nil {
+		t.Error("a prompt with an invalid replace pattern should not load")
+	}
+}
+
+func TestPromptTools(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr bool
+	}{
+		{"known tools", "name: p\nsystem: x\ntools: [read_file, list_directory]\n", false},
+		{"unknown tool", "name: p\nsystem: x\ntools: [run_shell]\n", true},
+		{"duplicate tool", "name: p\nsystem: x\ntools: [read_file, read_file]\n", true},
+		{"pipeline with tools", "name: p\nsteps:\n  - prompt: metaprompt\ntools: [read_file]\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePrompt([]byte(tt.yaml), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParsePrompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
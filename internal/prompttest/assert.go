@@ -0,0 +1,72 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Assertions are the checks applied to a case's output. Every set check must pass.
+type Assertions struct {
+	MustContain      []string `yaml:"must_contain,omitempty"`
+	MustNotContain   []string `yaml:"must_not_contain,omitempty"`
+	MustNotStartWith []string `yaml:"must_not_start_with,omitempty"`
+	MaxWords         int      `yaml:"max_words,omitempty"`
+	Matches          []string `yaml:"matches,omitempty"`
+	JSON             bool     `yaml:"json,omitempty"`
+}
+
+func (a Assertions) validate() error {
+	if a.MaxWords < 0 {
+		return fmt.Errorf("max_words must not be negative, got %d", a.MaxWords)
+	}
+	for _, pattern := range a.Matches {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Check returns a description of every assertion output fails, or nil if it passes.
+// Leading and trailing whitespace is ignored.
+func (a Assertions) Check(output string) []string {
+	output = strings.TrimSpace(output)
+	var failures []string
+
+	for _, s := range a.MustContain {
+		if !strings.Contains(output, s) {
+			failures = append(failures, fmt.Sprintf("must contain %q", s))
+		}
+	}
+	for _, s := range a.MustNotContain {
+		if strings.Contains(output, s) {
+			failures = append(failures, fmt.Sprintf("must not contain %q", s))
+		}
+	}
+	for _, s := range a.MustNotStartWith {
+		if strings.HasPrefix(output, s) {
+			failures = append(failures, fmt.Sprintf("must not start with %q", s))
+		}
+	}
+	if a.MaxWords > 0 {
+		if words := len(strings.Fields(output)); words > a.MaxWords {
+			failures = append(failures, fmt.Sprintf("must be at most %d words, got %d", a.MaxWords, words))
+		}
+	}
+	for _, pattern := range a.Matches {
+		// Patterns are checked by validate when the suite is loaded.
+		if !regexp.MustCompile(pattern).MatchString(output) {
+			failures = append(failures, fmt.Sprintf("must match /%s/", pattern))
+		}
+	}
+	if a.JSON && !json.Valid([]byte(output)) {
+		failures = append(failures, "must be valid JSON")
+	}
+
+	return failures
+}
@@ -0,0 +1,47 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAssertionsCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		assert Assertions
+		output string
+		want   []string
+	}{
+		{"no assertions", Assertions{}, "anything", nil},
+		{"must contain", Assertions{MustContain: []string{"SELECT", "WHERE"}}, "SELECT * FROM t", []string{`must contain "WHERE"`}},
+		{"must not contain", Assertions{MustNotContain: []string{"sorry"}}, "I'm sorry", []string{`must not contain "sorry"`}},
+		{"must not start with ignores leading whitespace", Assertions{MustNotStartWith: []string{"Here is"}}, "\n  Here is the prompt", []string{`must not start with "Here is"`}},
+		{"must not start with passes", Assertions{MustNotStartWith: []string{"Here is"}}, "Write a haiku. Here is why.", nil},
+		{"max words", Assertions{MaxWords: 3}, "one two three four", []string{"must be at most 3 words, got 4"}},
+		{"max words passes", Assertions{MaxWords: 4}, "one two three four", nil},
+		{"matches", Assertions{Matches: []string{`^\d+$`}}, "42a", []string{`must match /^\d+$/`}},
+		{"json valid", Assertions{JSON: true}, `{"a": 1}`, nil},
+		{"json invalid", Assertions{JSON: true}, "```json\n{}\n```", []string{"must be valid JSON"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.assert.Check(tt.output)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssertionsValidate(t *testing.T) {
+	if err := (Assertions{Matches: []string{"("}}).validate(); err == nil {
+		t.Error("validate() should reject an invalid regex")
+	}
+	if err := (Assertions{MaxWords: -1}).validate(); err == nil {
+		t.Error("validate() should reject negative max_words")
+	}
+}
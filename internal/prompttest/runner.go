@@ -0,0 +1,124 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/internal/schema"
+	"github.com/raypaste/raypaste-cli/internal/textdiff"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// Options controls how a suite is run.
+type Options struct {
+	// Model overrides the prompt's model and the default model when set.
+	Model string
+	// Length is used for cases that don't set their own.
+	Length types.OutputLength
+	// Fixtures are the recorded results for the suite's prompt, keyed by case name.
+	Fixtures map[string]Fixture
+	// Offline checks the recorded fixtures instead of calling the model. A case fails
+	// if it has no fixture or its system prompt changed since it was recorded.
+	Offline bool
+}
+
+// Result is the outcome of one case.
+type Result struct {
+	Case     Case
+	Output   string
+	Failures []string
+	// Diff shows what changed relative to the recorded fixture: the output for a
+	// failing live run, or the system prompt for a stale fixture.
+	Diff string
+	// Fixture is the result to record for this case; unset if the case didn't run.
+	Fixture *Fixture
+	Usage   types.TokenUsage
+}
+
+// Passed reports whether every assertion passed.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// RunCase runs a single case of the named prompt's suite.
+func RunCase(ctx context.Context, env *generate.Env, client generate.Completer, promptName string, c Case, opts Options) Result {
+	result := Result{Case: c}
+	length := opts.Length
+	if c.Length != "" {
+		length = types.OutputLength(c.Length)
+	}
+	recorded, hasFixture := opts.Fixtures[c.Name]
+
+	// A fixture records one system prompt and reply, which a pipeline doesn't have
+	if prompt, err := env.Store.Get(promptName); err == nil && len(prompt.Steps) > 0 {
+		result.Failures = []string{fmt.Sprintf("'%s' is a pipeline, which can't be tested directly; add a suite for each step's prompt instead", promptName)}
+		return result
+	}
+
+	req, err := env.BuildRequest(generate.Params{
+		Input:      c.Input,
+		PromptName: promptName,
+		Model:      opts.Model,
+		Length:     length,
+		Vars:       c.Vars,
+	})
+	if err != nil {
+		result.Failures = []string{err.Error()}
+		return result
+	}
+	system := req.Messages[0].Content
+
+	// Assertions see the output as it would be printed; fixtures keep it raw
+	var rules *postprocess.Rules
+	var outputSchema *schema.Schema
+	if prompt, err := env.Store.Get(promptName); err == nil {
+		rules, outputSchema = prompt.PostProcess, prompt.Schema
+	}
+
+	if opts.Offline {
+		if !hasFixture {
+			result.Failures = []string{"no recorded fixture (run with --record first)"}
+			return result
+		}
+		if recorded.System != system {
+			result.Failures = []string{"system prompt changed since the fixture was recorded (re-record with --record)"}
+			result.Diff = textdiff.Lines(recorded.System, system)
+			return result
+		}
+		result.Output = rules.Apply(recorded.Output)
+		result.Failures = check(c.Assert, outputSchema, result.Output)
+		return result
+	}
+
+	output, usage, err := env.Complete(ctx, client, promptName, req)
+	if err != nil {
+		result.Failures = []string{fmt.Sprintf("completion failed: %v", err)}
+		return result
+	}
+
+	result.Output = rules.Apply(output)
+	result.Usage = usage
+	result.Fixture = &Fixture{Model: req.Model, System: system, Output: output}
+	result.Failures = check(c.Assert, outputSchema, result.Output)
+	if !result.Passed() && hasFixture {
+		result.Diff = textdiff.Lines(recorded.Output, output)
+	}
+	return result
+}
+
+// check runs the case's assertions, after checking the output against the prompt's
+// schema when it declares one.
+func check(assert Assertions, outputSchema *schema.Schema, output string) []string {
+	var failures []string
+	if outputSchema != nil {
+		if _, err := outputSchema.Validate(output); err != nil {
+			failures = append(failures, err.Error())
+		}
+	}
+	return append(failures, assert.Check(output)...)
+}
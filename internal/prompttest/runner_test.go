@@ -0,0 +1,111 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestRunCaseLive(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	c := Case{Name: "c", Input: "goal", Assert: Assertions{MustNotStartWith: []string{"Here is"}}}
+
+	client := &generatetest.Completer{Reply: "Write a haiku."}
+	result := RunCase(context.Background(), env, client, "metaprompt", c, Options{Length: types.OutputLengthShort})
+	if !result.Passed() {
+		t.Fatalf("RunCase() failures = %v", result.Failures)
+	}
+	if result.Fixture == nil || result.Fixture.Output != "Write a haiku." || result.Fixture.Model != "test/writer" {
+		t.Errorf("RunCase() fixture = %+v, want the output and model recorded", result.Fixture)
+	}
+	if result.Usage != generatetest.Usage {
+		t.Errorf("RunCase() usage = %+v", result.Usage)
+	}
+
+	// A failing case shows how the output differs from the recorded one
+	client.Reply = "Here is a haiku."
+	fixtures := map[string]Fixture{"c": *result.Fixture}
+	result = RunCase(context.Background(), env, client, "metaprompt", c, Options{Length: types.OutputLengthShort, Fixtures: fixtures})
+	if result.Passed() {
+		t.Fatal("RunCase() should fail the must_not_start_with assertion")
+	}
+	if !strings.Contains(result.Diff, "-Write a haiku.") || !strings.Contains(result.Diff, "+Here is a haiku.") {
+		t.Errorf("RunCase() diff = %q", result.Diff)
+	}
+
+	client.Err = errors.New("boom")
+	result = RunCase(context.Background(), env, client, "metaprompt", c, Options{Length: types.OutputLengthShort})
+	if result.Passed() || result.Fixture != nil {
+		t.Errorf("RunCase() = %+v, want a failure and nothing to record when the completion fails", result)
+	}
+}
+
+func TestRunCasePipeline(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	store, err := env.Store.WithPrompts([]*prompts.Prompt{{
+		Name:  "plan",
+		Steps: []prompts.Step{{Prompt: "bulletlist"}, {Prompt: "metaprompt"}},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.Store = store
+
+	client := &generatetest.Completer{Reply: "x"}
+	result := RunCase(context.Background(), env, client, "plan", Case{Name: "c", Input: "goal"}, Options{Length: types.OutputLengthShort})
+	if result.Passed() || !strings.Contains(result.Failures[0], "pipeline") || len(client.Requests) != 0 {
+		t.Errorf("RunCase() = %+v after %d calls, want a pipeline failure before any request", result, len(client.Requests))
+	}
+}
+
+func TestRunCaseOffline(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	c := Case{Name: "c", Input: "goal", Length: "short", Assert: Assertions{MaxWords: 3}}
+
+	system, err := env.RenderSystemPrompt("metaprompt", types.OutputLengthShort, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		fixtures    map[string]Fixture
+		wantFailure string
+		wantDiff    bool
+	}{
+		{"passing fixture", map[string]Fixture{"c": {System: system, Output: "three short words"}}, "", false},
+		{"failing fixture", map[string]Fixture{"c": {System: system, Output: "one two three four"}}, "at most 3 words", false},
+		{"missing fixture", nil, "no recorded fixture", false},
+		{"stale fixture", map[string]Fixture{"c": {System: "old prompt", Output: "ok"}}, "system prompt changed", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &generatetest.Completer{}
+			result := RunCase(context.Background(), env, client, "metaprompt", c, Options{Length: types.OutputLengthMedium, Fixtures: tt.fixtures, Offline: true})
+			if len(client.Requests) != 0 {
+				t.Error("RunCase() called the model in offline mode")
+			}
+			if tt.wantFailure == "" {
+				if !result.Passed() {
+					t.Errorf("RunCase() failures = %v", result.Failures)
+				}
+				return
+			}
+			if result.Passed() || !strings.Contains(result.Failures[0], tt.wantFailure) {
+				t.Errorf("RunCase() failures = %v, want %q", result.Failures, tt.wantFailure)
+			}
+			if (result.Diff != "") != tt.wantDiff {
+				t.Errorf("RunCase() diff = %q, wantDiff %v", result.Diff, tt.wantDiff)
+			}
+		})
+	}
+}
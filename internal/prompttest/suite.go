@@ -0,0 +1,203 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// testsDirName is the subdirectory of the prompts directory holding test suites.
+	testsDirName = "tests"
+	// fixturesDirName is the subdirectory of the tests directory holding recorded outputs.
+	fixturesDirName = "fixtures"
+)
+
+// Suite is the set of test cases for one prompt, stored in
+// ~/.raypaste/prompts/tests/<prompt>.yaml.
+type Suite struct {
+	// Prompt is the prompt under test; it defaults to the file name without extension.
+	Prompt string `yaml:"prompt,omitempty"`
+	Cases  []Case `yaml:"cases"`
+}
+
+// Case is a single input run through the prompt and checked with assertions.
+type Case struct {
+	Name   string            `yaml:"name"`
+	Input  string            `yaml:"input"`
+	Length string            `yaml:"length,omitempty"`
+	Vars   map[string]string `yaml:"vars,omitempty"`
+	Assert Assertions        `yaml:"assert"`
+}
+
+// Fixture is the recorded result of a case, used to run suites offline and to
+// show what changed when a case starts failing.
+type Fixture struct {
+	Model  string `yaml:"model"`
+	System string `yaml:"system"`
+	Output string `yaml:"output"`
+}
+
+// fixtureFile is the on-disk layout of a prompt's recorded fixtures.
+type fixtureFile struct {
+	Cases map[string]Fixture `yaml:"cases"`
+}
+
+// TestsDir returns the directory holding prompt test suites.
+func TestsDir() (string, error) {
+	promptsDir, err := config.GetPromptsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(promptsDir, testsDirName), nil
+}
+
+// SuitePath returns the path of the named prompt's test suite.
+func SuitePath(promptName string) (string, error) {
+	dir, err := TestsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, promptName+".yaml"), nil
+}
+
+// ListSuites returns the paths of every test suite, sorted.
+func ListSuites() ([]string, error) {
+	dir, err := TestsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list test suites: %w", err)
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadSuite reads and validates a test suite file; case lengths must be built in or
+// defined in lengths.
+func LoadSuite(path string, lengths *config.Lengths) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read test suite: %w", err)
+	}
+	return ParseSuite(data, filepath.Base(path), lengths)
+}
+
+// ParseSuite parses and validates the contents of a test suite file named filename.
+// The suite's prompt defaults to filename without its extension. Case lengths must be
+// built in or defined in lengths; they aren't checked when lengths is nil, such as
+// when the prompts that define them haven't been loaded yet.
+func ParseSuite(data []byte, filename string, lengths *config.Lengths) (*Suite, error) {
+	var suite Suite
+	if err := yaml.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("failed to parse test suite %s: %w", filename, err)
+	}
+	if suite.Prompt == "" {
+		suite.Prompt = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+
+	if err := suite.validate(lengths); err != nil {
+		return nil, fmt.Errorf("invalid test suite %s: %w", filename, err)
+	}
+	return &suite, nil
+}
+
+func (s *Suite) validate(lengths *config.Lengths) error {
+	if len(s.Cases) == 0 {
+		return fmt.Errorf("no cases defined")
+	}
+
+	seen := make(map[string]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		if c.Name == "" {
+			return fmt.Errorf("cases[%d]: name is required", i)
+		}
+		if seen[c.Name] {
+			return fmt.Errorf("duplicate case: %s", c.Name)
+		}
+		seen[c.Name] = true
+
+		if strings.TrimSpace(c.Input) == "" {
+			return fmt.Errorf("case '%s': input is required", c.Name)
+		}
+		if c.Length != "" && lengths != nil {
+			if _, err := lengths.Validate(c.Length); err != nil {
+				return fmt.Errorf("case '%s': %w", c.Name, err)
+			}
+		}
+		if err := c.Assert.validate(); err != nil {
+			return fmt.Errorf("case '%s': %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// fixturesPath returns the path of the named prompt's recorded fixtures.
+func fixturesPath(promptName string) (string, error) {
+	dir, err := TestsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fixturesDirName, promptName+".yaml"), nil
+}
+
+// LoadFixtures returns the recorded fixtures for the named prompt, keyed by case
+// name. A prompt without recorded fixtures returns an empty map.
+func LoadFixtures(promptName string) (map[string]Fixture, error) {
+	path, err := fixturesPath(promptName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]Fixture{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixtures: %w", err)
+	}
+
+	var file fixtureFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse fixtures for %s: %w", promptName, err)
+	}
+	if file.Cases == nil {
+		file.Cases = map[string]Fixture{}
+	}
+	return file.Cases, nil
+}
+
+// SaveFixtures writes the recorded fixtures for the named prompt.
+func SaveFixtures(promptName string, fixtures map[string]Fixture) error {
+	path, err := fixturesPath(promptName)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create fixtures directory: %w", err)
+	}
+
+	data, err := yaml.Marshal(fixtureFile{Cases: fixtures})
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixtures: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write fixtures: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package prompttest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+)
+
+func TestLoadSuite(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{"valid", "cases:\n  - name: a\n    input: x\n    length: short\n    assert:\n      max_words: 10\n", false},
+		{"no cases", "cases: []\n", true},
+		{"missing name", "cases:\n  - input: x\n", true},
+		{"duplicate name", "cases:\n  - name: a\n    input: x\n  - name: a\n    input: y\n", true},
+		{"missing input", "cases:\n  - name: a\n", true},
+		{"unknown length", "cases:\n  - name: a\n    input: x\n    length: huge\n", true},
+		{"invalid regex", "cases:\n  - name: a\n    input: x\n    assert:\n      matches: [\"(\"]\n", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "code-review.yaml")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			suite, err := LoadSuite(path, config.NewLengths())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadSuite() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && suite.Prompt != "code-review" {
+				t.Errorf("LoadSuite() prompt = %q, want the file name", suite.Prompt)
+			}
+		})
+	}
+}
+
+func TestFixturesRoundTrip(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	fixtures, err := LoadFixtures("p")
+	if err != nil || len(fixtures) != 0 {
+		t.Fatalf("LoadFixtures() = %v, %v, want an empty map", fixtures, err)
+	}
+
+	fixtures["a"] = Fixture{Model: "m", System: "sys\nline", Output: "out"}
+	if err := SaveFixtures("p", fixtures); err != nil {
+		t.Fatalf("SaveFixtures() error = %v", err)
+	}
+
+	got, err := LoadFixtures("p")
+	if err != nil {
+		t.Fatalf("LoadFixtures() error = %v", err)
+	}
+	if got["a"] != fixtures["a"] {
+		t.Errorf("LoadFixtures() = %+v, want %+v", got["a"], fixtures["a"])
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package refine
+
+import "fmt"
+
+// noIssues is the reply the critic gives when a draft follows every rule.
+const noIssues = "NO ISSUES"
+
+// criticInstructions asks the critic to check a draft against the rules it was
+// generated with.
+const criticInstructions = `You are a strict reviewer. A response was written by an assistant following the
+instructions below. Check the response against every instruction, paying particular
+attention to output format: preamble, explanations, or wrapper text that the
+instructions forbid, and the requested length.
+
+INSTRUCTIONS:
+%s
+
+List each problem on its own line, quoting the offending text where possible. Do not
+rewrite the response. If the response follows every instruction, reply with exactly:
+` + noIssues
+
+// rewriteInstructions is sent to the generating model with the critique.
+const rewriteInstructions = `A reviewer found these problems with your response:
+
+%s
+
+Rewrite your response to fix them while following your original instructions. Output
+ONLY the rewritten response.`
+
+func criticSystemPrompt(rules string) string {
+	return fmt.Sprintf(criticInstructions, rules)
+}
+
+func criticUserPrompt(input, draft string) string {
+	return fmt.Sprintf("USER INPUT:\n%s\n\nRESPONSE:\n%s", input, draft)
+}
@@ -0,0 +1,184 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package refine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/llm"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/internal/schema"
+	"github.com/raypaste/raypaste-cli/internal/textdiff"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// criticMaxTokens caps the critic's reply, which is a short list of problems.
+const criticMaxTokens = 600
+
+// Options controls a refinement.
+type Options struct {
+	// Rounds is the maximum number of critique and rewrite rounds.
+	Rounds int
+	// CriticModel is the model alias or ID that critiques each draft. The model that
+	// generated the draft is used when empty.
+	CriticModel string
+	// OnRound, when set, is called after each round.
+	OnRound func(Round)
+}
+
+// Round is one critique and rewrite of a draft.
+type Round struct {
+	Number   int
+	Critique string
+	Before   string
+	After    string
+	// Approved is set when the critic found nothing to fix; After is then Before and
+	// no further rounds run.
+	Approved bool
+	Usage    types.TokenUsage
+}
+
+// Diff returns the line changes the round made to the draft.
+func (r Round) Diff() string {
+	return textdiff.Lines(r.Before, r.After)
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	Output string
+	Rounds []Round
+	// Usage is the total token usage of every critique and rewrite.
+	Usage types.TokenUsage
+}
+
+// Run critiques gen's output against the system prompt it was generated with and
+// rewrites it, for up to opts.Rounds rounds. For a pipeline prompt, the rules and
+// input are those of its final step, which was given the previous step's output
+// rather than p.Input. It stops early once the critic finds nothing to fix.
+// On error, the Result holds the output of the last round that finished, or gen's
+// output if none did, so callers can fall back to it.
+func Run(ctx context.Context, env *generate.Env, client generate.Completer, p generate.Params, gen generate.Result, opts Options) (Result, error) {
+	// The final step of a pipeline produced the output, so its prompt sets the rules
+	promptName, length, model := p.PromptName, p.Length, gen.Model
+	input, attachments := p.Input, p.Attachments
+	if len(gen.Steps) > 0 {
+		last := gen.Steps[len(gen.Steps)-1]
+		promptName, length = last.Prompt, types.OutputLength(last.Length)
+	}
+	if len(gen.Steps) > 1 {
+		// Attachments only go to the first step
+		input, attachments = gen.Steps[len(gen.Steps)-2].Output, nil
+	}
+
+	result := Result{Output: gen.Output}
+	rules, err := env.RenderSystemPrompt(promptName, length, p.Vars)
+	if err != nil {
+		return result, err
+	}
+
+	// Rewrites are cleaned up and validated like the draft was
+	ruleSources := []string{promptName}
+	if promptName != p.PromptName {
+		ruleSources = append(ruleSources, p.PromptName)
+	}
+	var postprocessRules []*postprocess.Rules
+	var outputSchema *schema.Schema
+	for _, name := range ruleSources {
+		if prompt, err := env.Store.Get(name); err == nil {
+			postprocessRules = append(postprocessRules, prompt.PostProcess)
+			if name == promptName {
+				outputSchema = prompt.Schema
+			}
+		}
+	}
+
+	criticModel := opts.CriticModel
+	if criticModel == "" {
+		criticModel = model
+	}
+
+	for n := 1; n <= opts.Rounds; n++ {
+		round := Round{Number: n, Before: result.Output}
+		// A failed round's tokens still count, though its output is dropped
+		fail := func(err error) (Result, error) {
+			addUsage(&result.Usage, round.Usage)
+			return result, err
+		}
+
+		req, err := llm.BuildRequest(
+			criticModel,
+			criticSystemPrompt(rules),
+			criticUserPrompt(input, result.Output),
+			types.OutputLengthMedium,
+			0,
+			false,
+			env.Models,
+			env.Store.Lengths(),
+			types.RequestOptions{MaxTokens: criticMaxTokens},
+		)
+		if err != nil {
+			return fail(fmt.Errorf("critic: %w", err))
+		}
+		critique, usage, err := client.Complete(ctx, req)
+		addUsage(&round.Usage, usage)
+		if err != nil {
+			return fail(fmt.Errorf("round %d critique: %w", n, err))
+		}
+		round.Critique = strings.TrimSpace(critique)
+
+		if approved(round.Critique) {
+			round.Approved = true
+			round.After = round.Before
+		} else {
+			req, err := env.BuildChatRequest(promptName, model, length, p.Vars, []types.Message{
+				{Role: "user", Content: input, Parts: attachments},
+				{Role: "assistant", Content: result.Output},
+				{Role: "user", Content: fmt.Sprintf(rewriteInstructions, round.Critique)},
+			}, false)
+			if err != nil {
+				return fail(err)
+			}
+			rewrite, usage, err := env.Complete(ctx, client, promptName, req)
+			addUsage(&round.Usage, usage)
+			if err != nil {
+				return fail(fmt.Errorf("round %d rewrite: %w", n, err))
+			}
+			for _, r := range postprocessRules {
+				rewrite = r.Apply(rewrite)
+			}
+			if outputSchema != nil {
+				if rewrite, err = outputSchema.Validate(rewrite); err != nil {
+					return fail(fmt.Errorf("round %d rewrite: %w", n, err))
+				}
+			}
+			round.After = rewrite
+		}
+
+		addUsage(&result.Usage, round.Usage)
+		result.Output = round.After
+		result.Rounds = append(result.Rounds, round)
+		if opts.OnRound != nil {
+			opts.OnRound(round)
+		}
+		if round.Approved {
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// approved reports whether a critique says the draft needs no changes.
+func approved(critique string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(critique)), noIssues)
+}
+
+func addUsage(total *types.TokenUsage, usage types.TokenUsage) {
+	total.PromptTokens += usage.PromptTokens
+	total.CompletionTokens += usage.CompletionTokens
+	total.TotalTokens += usage.TotalTokens
+}
@@ -0,0 +1,153 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package refine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func TestRun(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	params := generate.Params{Input: "plan a launch", PromptName: "metaprompt", Length: types.OutputLengthShort}
+	draft := generate.Result{Output: "Sure! Here is your prompt:\nPlan the launch.", Model: "writer"}
+
+	tests := []struct {
+		name         string
+		rounds       int
+		replies      []string
+		wantOutput   string
+		wantRounds   int
+		wantApproved bool
+	}{
+		{"rewrites until approved", 3, []string{"Starts with a preamble: \"Sure! Here is your prompt:\"", "Plan the launch.", "NO ISSUES"}, "Plan the launch.", 2, true},
+		{"stops after the last round", 1, []string{"Starts with a preamble", "Plan the launch."}, "Plan the launch.", 1, false},
+		{"approved draft is unchanged", 2, []string{"no issues."}, draft.Output, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &generatetest.Completer{Replies: tt.replies}
+			var seen []Round
+			result, err := Run(context.Background(), env, client, params, draft, Options{
+				Rounds:      tt.rounds,
+				CriticModel: "critic",
+				OnRound:     func(r Round) { seen = append(seen, r) },
+			})
+			if err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if result.Output != tt.wantOutput {
+				t.Errorf("Output = %q, want %q", result.Output, tt.wantOutput)
+			}
+			if len(result.Rounds) != tt.wantRounds || len(seen) != tt.wantRounds {
+				t.Fatalf("rounds = %d (OnRound called %d times), want %d", len(result.Rounds), len(seen), tt.wantRounds)
+			}
+			if last := result.Rounds[len(result.Rounds)-1]; last.Approved != tt.wantApproved {
+				t.Errorf("last round Approved = %v, want %v", last.Approved, tt.wantApproved)
+			}
+			if result.Usage.TotalTokens != generatetest.Usage.TotalTokens*len(tt.replies) {
+				t.Errorf("Usage.TotalTokens = %d, want %d", result.Usage.TotalTokens, generatetest.Usage.TotalTokens*len(tt.replies))
+			}
+		})
+	}
+}
+
+func TestRunFailedRound(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	params := generate.Params{Input: "plan a launch", PromptName: "metaprompt", Length: types.OutputLengthShort}
+	draft := generate.Result{Output: "Sure! Plan the launch.", Model: "writer"}
+
+	tests := []struct {
+		name       string
+		replies    []string
+		wantOutput string
+	}{
+		{"first round fails", nil, draft.Output},
+		{"second round fails", []string{"Starts with a preamble", "Plan the launch."}, "Plan the launch."},
+		{"rewrite fails", []string{"Starts with a preamble"}, draft.Output},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &generatetest.Completer{Replies: tt.replies}
+			result, err := Run(context.Background(), env, client, params, draft, Options{Rounds: 2, CriticModel: "critic"})
+			if err == nil {
+				t.Fatal("Run() should fail once the replies run out")
+			}
+			if result.Output != tt.wantOutput {
+				t.Errorf("Output = %q, want the last finished version %q", result.Output, tt.wantOutput)
+			}
+			if result.Usage.TotalTokens != generatetest.Usage.TotalTokens*len(tt.replies) {
+				t.Errorf("Usage.TotalTokens = %d, want %d", result.Usage.TotalTokens, generatetest.Usage.TotalTokens*len(tt.replies))
+			}
+		})
+	}
+}
+
+func TestRunRequests(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	params := generate.Params{Input: "plan a launch", PromptName: "metaprompt", Length: types.OutputLengthShort}
+	draft := generate.Result{Output: "Sure! Plan the launch.", Model: "writer"}
+	client := &generatetest.Completer{Replies: []string{"Has a preamble", "Plan the launch."}}
+
+	result, err := Run(context.Background(), env, client, params, draft, Options{Rounds: 1, CriticModel: "critic"})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	critique, rewrite := client.Requests[0], client.Requests[1]
+	if critique.Model != "test/critic" || !strings.Contains(critique.Messages[0].Content, "meta-prompt engineer") {
+		t.Errorf("critique request should go to the critic with the prompt's rules, got model %q", critique.Model)
+	}
+	if !strings.Contains(critique.Messages[1].Content, "Sure! Plan the launch.") {
+		t.Error("critique request should include the draft")
+	}
+
+	msgs := rewrite.Messages
+	if rewrite.Model != "test/writer" || msgs[0].Role != "system" || msgs[len(msgs)-2].Content != draft.Output || !strings.Contains(msgs[len(msgs)-1].Content, "Has a preamble") {
+		t.Errorf("rewrite request = %+v, want the writer continuing from the draft with the critique", rewrite)
+	}
+
+	if diff := result.Rounds[0].Diff(); !strings.Contains(diff, "-Sure! Plan the launch.") || !strings.Contains(diff, "+Plan the launch.") {
+		t.Errorf("Diff() = %q", diff)
+	}
+}
+
+func TestRunPipelineUsesFinalStepInput(t *testing.T) {
+	env := generatetest.NewEnv(t, nil)
+	params := generate.Params{
+		Input:       "plan a launch",
+		PromptName:  "plan",
+		Length:      types.OutputLengthShort,
+		Attachments: []types.ContentPart{{Type: "text", Text: "spec"}},
+	}
+	draft := generate.Result{
+		Output: "Sure! Plan the launch.",
+		Model:  "writer",
+		Steps: []generate.StepResult{
+			{Prompt: "bulletlist", Length: "short", Output: "- launch in May"},
+			{Prompt: "metaprompt", Length: "short", Output: "Sure! Plan the launch."},
+		},
+	}
+	client := &generatetest.Completer{Replies: []string{"Has a preamble", "Plan the launch."}}
+
+	if _, err := Run(context.Background(), env, client, params, draft, Options{Rounds: 1}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	critique, rewrite := client.Requests[0], client.Requests[1]
+	if content := critique.Messages[1].Content; !strings.Contains(content, "- launch in May") || strings.Contains(content, "plan a launch") {
+		t.Errorf("critique request = %q, want the final step's input", content)
+	}
+	input := rewrite.Messages[len(rewrite.Messages)-3]
+	if input.Content != "- launch in May" || input.Parts != nil {
+		t.Errorf("rewrite input = %+v, want the final step's input without attachments", input)
+	}
+}
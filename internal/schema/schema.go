@@ -0,0 +1,124 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"gopkg.in/yaml.v3"
+)
+
+// resourceURL identifies the schema to the compiler; it is never fetched.
+const resourceURL = "file:///schema.json"
+
+// Schema is a JSON Schema that a prompt's output must match. In a prompt file it is
+// written as YAML under `schema:`.
+type Schema struct {
+	doc      map[string]any
+	compiled *jsonschema.Schema
+}
+
+// New compiles doc, a JSON Schema decoded from JSON or YAML.
+func New(doc map[string]any) (*Schema, error) {
+	// Round-trip through JSON so YAML ints and nested maps reach the compiler as the
+	// JSON types it expects
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	value, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(resourceURL, value); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	compiled, err := compiler.Compile(resourceURL)
+	if err != nil {
+		return nil, fmt.Errorf("schema: invalid JSON Schema: %s", validationMessage(err))
+	}
+	return &Schema{doc: doc, compiled: compiled}, nil
+}
+
+// UnmarshalYAML compiles the schema as it is loaded, so an invalid schema is
+// reported with the prompt that declares it.
+func (s *Schema) UnmarshalYAML(node *yaml.Node) error {
+	var doc map[string]any
+	if err := node.Decode(&doc); err != nil {
+		return fmt.Errorf("schema: must be a mapping: %w", err)
+	}
+	parsed, err := New(doc)
+	if err != nil {
+		return err
+	}
+	*s = *parsed
+	return nil
+}
+
+// MarshalYAML writes the schema back as it was declared.
+func (s *Schema) MarshalYAML() (any, error) {
+	return s.doc, nil
+}
+
+// MarshalJSON encodes the schema document.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.doc)
+}
+
+// Doc returns the schema document, or nil for a nil *Schema.
+func (s *Schema) Doc() map[string]any {
+	if s == nil {
+		return nil
+	}
+	return s.doc
+}
+
+// Validate parses output as JSON and checks it against the schema. A single
+// surrounding code fence is ignored. It returns the value indented for display.
+func (s *Schema) Validate(output string) (string, error) {
+	text := unfence(output)
+	value, err := jsonschema.UnmarshalJSON(strings.NewReader(text))
+	if err != nil {
+		return "", fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	if err := s.compiled.Validate(value); err != nil {
+		return "", fmt.Errorf("response does not match the schema: %s", validationMessage(err))
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, []byte(text), "", "  "); err != nil {
+		return "", fmt.Errorf("response is not valid JSON: %w", err)
+	}
+	return pretty.String(), nil
+}
+
+// unfence returns the contents of text when it is a single fenced code block, as
+// models asked for JSON in the system prompt often reply with ```json ... ```.
+func unfence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") || !strings.HasSuffix(text, "```") || len(text) < 6 {
+		return text
+	}
+	body := strings.TrimSuffix(text, "```")
+	if _, rest, ok := strings.Cut(body, "\n"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return text
+}
+
+// validationMessage drops the header line of a compile or validation error, which
+// names the internal schema URL, and keeps the list of failures.
+func validationMessage(err error) string {
+	msg := err.Error()
+	if _, rest, ok := strings.Cut(msg, "\n"); ok {
+		return strings.TrimSpace(rest)
+	}
+	return msg
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const tagsSchema = `
+type: object
+required: [tags]
+properties:
+  tags:
+    type: array
+    items: {type: string}
+    maxItems: 2
+`
+
+func TestSchemaYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"valid", tagsSchema, ""},
+		{"not a mapping", "[1, 2]", "must be a mapping"},
+		{"invalid schema", "type: 5", "invalid JSON Schema"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Schema
+			err := yaml.Unmarshal([]byte(tt.yaml), &s)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Unmarshal() error = %v", err)
+				}
+				out, err := yaml.Marshal(&s)
+				if err != nil || !strings.Contains(string(out), "maxItems: 2") {
+					t.Errorf("Marshal() = %q, %v, want the schema document", out, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Unmarshal() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	var s Schema
+	if err := yaml.Unmarshal([]byte(tagsSchema), &s); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		output  string
+		want    string
+		wantErr string
+	}{
+		{"valid", `{"tags":["go","cli"]}`, "{\n  \"tags\": [\n    \"go\",\n    \"cli\"\n  ]\n}", ""},
+		{"fenced", "```json\n{\"tags\": []}\n```", "{\n  \"tags\": []\n}", ""},
+		{"not JSON", "Here are the tags: go, cli", "", "not valid JSON"},
+		{"missing property", `{}`, "", "missing property 'tags'"},
+		{"too many items", `{"tags":["a","b","c"]}`, "", "at '/tags'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Validate(tt.output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Validate() error = %v, want containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Validate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// ProxyModelPrefix prefixes the model names understood by the proxy.
+// "raypaste/<prompt>" selects a prompt template and uses the default model;
+// "raypaste/<prompt>@<model>" also selects the upstream model alias or ID.
+const ProxyModelPrefix = "raypaste/"
+
+// Proxy accepts OpenAI-style chat completion requests, prepends a raypaste prompt
+// template selected through the model name and forwards them to the configured provider.
+type Proxy struct {
+	env      *generate.Env
+	client   generate.Completer
+	defaults generate.Defaults
+	addr     string
+	mux      *http.ServeMux
+}
+
+// ChatCompletionRequest is the subset of the OpenAI chat completion request the proxy understands.
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Stream      bool          `json:"stream,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	// Vars fills the prompt's template variables; it extends the OpenAI request.
+	Vars map[string]string `json:"raypaste_vars,omitempty"`
+}
+
+// ChatMessage is an OpenAI chat message. Content may be a string or an array of content parts.
+type ChatMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"`
+}
+
+// chatContentPart is a single element of an array-valued message content.
+type chatContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ChatCompletionResponse is an OpenAI "chat.completion" object.
+type ChatCompletionResponse struct {
+	ID      string           `json:"id"`
+	Object  string           `json:"object"`
+	Created int64            `json:"created"`
+	Model   string           `json:"model"`
+	Choices []ChatChoice     `json:"choices"`
+	Usage   types.TokenUsage `json:"usage"`
+}
+
+// ChatChoice is a choice in a ChatCompletionResponse.
+type ChatChoice struct {
+	Index        int           `json:"index"`
+	Message      types.Message `json:"message"`
+	FinishReason string        `json:"finish_reason"`
+}
+
+// ChatCompletionChunk is an OpenAI "chat.completion.chunk" object sent while streaming.
+type ChatCompletionChunk struct {
+	ID      string            `json:"id"`
+	Object  string            `json:"object"`
+	Created int64             `json:"created"`
+	Model   string            `json:"model"`
+	Choices []ChatChunkChoice `json:"choices"`
+	Usage   *types.TokenUsage `json:"usage,omitempty"`
+}
+
+// ChatChunkChoice is a choice in a ChatCompletionChunk.
+type ChatChunkChoice struct {
+	Index        int         `json:"index"`
+	Delta        types.Delta `json:"delta"`
+	FinishReason *string     `json:"finish_reason"`
+}
+
+// proxyModel is an entry returned by GET /v1/models.
+type proxyModel struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// proxyModelList is the body returned by GET /v1/models.
+type proxyModelList struct {
+	Object string       `json:"object"`
+	Data   []proxyModel `json:"data"`
+}
+
+// NewProxy creates a Proxy backed by env and client. addr is the address it listens
+// on; requests for any other host are rejected, as by Server.
+func NewProxy(env *generate.Env, client generate.Completer, defaults generate.Defaults, addr string) *Proxy {
+	p := &Proxy{
+		env:      env,
+		client:   client,
+		defaults: defaults,
+		addr:     addr,
+		mux:      http.NewServeMux(),
+	}
+
+	p.mux.HandleFunc("GET /v1/models", p.handleModels)
+	p.mux.HandleFunc("POST /v1/chat/completions", p.handleChatCompletions)
+
+	return p
+}
+
+// ServeHTTP implements http.Handler.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := checkRequest(r, p.addr); err != nil {
+		writeOpenAIError(w, status, "invalid_request_error", err)
+		return
+	}
+	p.mux.ServeHTTP(w, r)
+}
+
+// ParseProxyModel splits a "raypaste/<prompt>[@<model>]" model name into its parts.
+// model is empty when the name does not select one.
+func ParseProxyModel(name string) (promptName, model string, err error) {
+	if !strings.HasPrefix(name, ProxyModelPrefix) {
+		return "", "", fmt.Errorf("model %q must be of the form %s<prompt> or %s<prompt>@<model>", name, ProxyModelPrefix, ProxyModelPrefix)
+	}
+
+	rest := strings.TrimPrefix(name, ProxyModelPrefix)
+	promptName, model, _ = strings.Cut(rest, "@")
+	if promptName == "" {
+		return "", "", fmt.Errorf("model %q does not name a prompt", name)
+	}
+	return promptName, model, nil
+}
+
+// checkPrompt returns an error if the named prompt can't run through the proxy, which
+// prepends the prompt's template to a single completion, leaves tool calls to the
+// client and doesn't retry replies that don't match a schema.
+func (p *Proxy) checkPrompt(name string) error {
+	prompt, err := p.env.Store.Get(name)
+	if err != nil {
+		return err
+	}
+	if len(prompt.Steps) > 0 {
+		return fmt.Errorf("prompt '%s' is a pipeline, which the proxy can't run; use POST /v1/generate on raypaste serve instead", name)
+	}
+	if len(prompt.Tools) > 0 {
+		return fmt.Errorf("prompt '%s' uses tools, which the proxy can't run; use POST /v1/generate on raypaste serve instead", name)
+	}
+	if prompt.Schema != nil {
+		return fmt.Errorf("prompt '%s' declares a schema, which the proxy can't enforce; use POST /v1/generate on raypaste serve instead", name)
+	}
+	return nil
+}
+
+func (p *Proxy) handleModels(w http.ResponseWriter, _ *http.Request) {
+	infos := p.env.ListPrompts()
+	list := proxyModelList{Object: "list", Data: make([]proxyModel, 0, len(infos))}
+	for _, info := range infos {
+		if p.checkPrompt(info.Name) != nil {
+			continue
+		}
+		list.Data = append(list.Data, proxyModel{
+			ID:      ProxyModelPrefix + info.Name,
+			Object:  "model",
+			OwnedBy: "raypaste",
+		})
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (p *Proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	var body ChatCompletionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	promptName, model, err := ParseProxyModel(body.Model)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", err)
+		return
+	}
+	if model == "" {
+		model = p.defaults.Model
+	}
+
+	promptName, length, err := p.env.ResolvePromptAndLength(p.defaults, promptName, "")
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", err)
+		return
+	}
+	if err := p.checkPrompt(promptName); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err)
+		return
+	}
+	prompt, err := p.env.Store.Get(promptName)
+	if err != nil {
+		writeOpenAIError(w, http.StatusNotFound, "invalid_request_error", err)
+		return
+	}
+
+	messages, err := flattenMessages(body.Messages)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err)
+		return
+	}
+	if len(messages) == 0 {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", fmt.Errorf("messages must not be empty"))
+		return
+	}
+
+	req, err := p.env.BuildChatRequest(promptName, model, length, body.Vars, messages, body.Stream)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err)
+		return
+	}
+	if body.Temperature != nil {
+		req.Temperature = *body.Temperature
+	}
+	if body.MaxTokens > 0 {
+		if req.MaxCompletionTokens > 0 {
+			// max_completion_tokens also covers the reasoning budget, which the client
+			// doesn't know about
+			req.MaxCompletionTokens = body.MaxTokens
+			if req.Reasoning != nil {
+				req.MaxCompletionTokens += req.Reasoning.MaxTokens
+			}
+		} else {
+			req.MaxTokens = body.MaxTokens
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), generationTimeout)
+	defer cancel()
+
+	id := fmt.Sprintf("chatcmpl-raypaste-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+
+	if body.Stream {
+		p.streamChatCompletion(ctx, w, req, prompt.PostProcess, id, created, body.Model)
+		return
+	}
+
+	result, usage, err := p.client.Complete(ctx, req)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "api_error", fmt.Errorf("generation failed: %w", err))
+		return
+	}
+	result = prompt.PostProcess.Apply(result)
+
+	writeJSON(w, http.StatusOK, ChatCompletionResponse{
+		ID:      id,
+		Object:  "chat.completion",
+		Created: created,
+		Model:   body.Model,
+		Choices: []ChatChoice{{
+			Index:        0,
+			Message:      types.Message{Role: "assistant", Content: result},
+			FinishReason: "stop",
+		}},
+		Usage: usage,
+	})
+}
+
+// streamChatCompletion relays the generation as OpenAI "chat.completion.chunk" events
+// terminated by "data: [DONE]". Of the prompt's postprocess rules, only a leading
+// preamble is stripped, as the others need the whole output.
+func (p *Proxy) streamChatCompletion(ctx context.Context, w http.ResponseWriter, req types.CompletionRequest, rules *postprocess.Rules, id string, created int64, model string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, http.StatusInternalServerError, "api_error", fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	chunk := func(delta types.Delta, finishReason *string, usage *types.TokenUsage) ChatCompletionChunk {
+		return ChatCompletionChunk{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   model,
+			Choices: []ChatChunkChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+			Usage:   usage,
+		}
+	}
+
+	_ = writeData(w, chunk(types.Delta{Role: "assistant"}, nil, nil))
+	flusher.Flush()
+
+	filter := postprocess.NewStream(rules)
+	send := func(text string) error {
+		if text == "" {
+			return nil
+		}
+		if err := writeData(w, chunk(types.Delta{Content: text}, nil, nil)); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	usage, err := p.client.StreamComplete(ctx, req, func(token string) error {
+		return send(filter.Process(token))
+	})
+	if err == nil {
+		err = send(filter.Flush())
+	}
+	if err != nil {
+		_ = writeData(w, types.ErrorResponse{Error: types.APIError{Message: fmt.Sprintf("streaming failed: %v", err), Type: "api_error"}})
+		flusher.Flush()
+		return
+	}
+
+	stop := "stop"
+	_ = writeData(w, chunk(types.Delta{}, &stop, &usage))
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+// flattenMessages converts OpenAI messages to raypaste messages, joining the text
+// of array-valued content. Non-text parts are rejected.
+func flattenMessages(messages []ChatMessage) ([]types.Message, error) {
+	flattened := make([]types.Message, 0, len(messages))
+	for i, msg := range messages {
+		var text string
+		if len(msg.Content) > 0 && msg.Content[0] == '[' {
+			var parts []chatContentPart
+			if err := json.Unmarshal(msg.Content, &parts); err != nil {
+				return nil, fmt.Errorf("messages[%d]: invalid content: %w", i, err)
+			}
+			var b strings.Builder
+			for _, part := range parts {
+				if part.Type != "text" {
+					return nil, fmt.Errorf("messages[%d]: unsupported content part type %q", i, part.Type)
+				}
+				b.WriteString(part.Text)
+			}
+			text = b.String()
+		} else if len(msg.Content) > 0 && string(msg.Content) != "null" {
+			if err := json.Unmarshal(msg.Content, &text); err != nil {
+				return nil, fmt.Errorf("messages[%d]: invalid content: %w", i, err)
+			}
+		}
+		flattened = append(flattened, types.Message{Role: msg.Role, Content: text})
+	}
+	return flattened, nil
+}
+
+// writeData writes v as a single unnamed SSE event.
+func writeData(w http.ResponseWriter, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// writeOpenAIError writes an error in the OpenAI error format so clients surface it.
+func writeOpenAIError(w http.ResponseWriter, status int, errType string, err error) {
+	writeJSON(w, status, types.ErrorResponse{Error: types.APIError{Message: err.Error(), Type: errType}})
+}
@@ -0,0 +1,306 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/internal/postprocess"
+	"github.com/raypaste/raypaste-cli/internal/prompts"
+	"github.com/raypaste/raypaste-cli/internal/schema"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func newTestProxy(t *testing.T) (*Proxy, *generatetest.Completer) {
+	t.Helper()
+	s, client := newTestServer(t)
+	return NewProxy(s.env, client, s.defaults, testAddr), client
+}
+
+func doProxyRequest(p *Proxy, method, path, body string) *httptest.ResponseRecorder {
+	req := newLocalRequest(method, path, body)
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestParseProxyModel(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantPrompt string
+		wantModel  string
+		wantErr    bool
+	}{
+		{"prompt only", "raypaste/metaprompt", "metaprompt", "", false},
+		{"prompt with alias", "raypaste/bulletlist@cerebras-llama-8b", "bulletlist", "cerebras-llama-8b", false},
+		{"prompt with OpenRouter ID", "raypaste/metaprompt@openai/gpt-5-nano", "metaprompt", "openai/gpt-5-nano", false},
+		{"missing prefix", "gpt-4o", "", "", true},
+		{"missing prompt", "raypaste/", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prompt, model, err := ParseProxyModel(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProxyModel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if prompt != tt.wantPrompt || model != tt.wantModel {
+				t.Errorf("ParseProxyModel(%q) = (%q, %q), want (%q, %q)", tt.input, prompt, model, tt.wantPrompt, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestProxyModels(t *testing.T) {
+	p, _ := newTestProxy(t)
+	rec := doProxyRequest(p, http.MethodGet, "/v1/models", "")
+	if !strings.Contains(rec.Body.String(), `"id":"raypaste/metaprompt"`) {
+		t.Errorf("GET /v1/models should list raypaste/metaprompt, got %s", rec.Body.String())
+	}
+}
+
+func TestProxyChatCompletion(t *testing.T) {
+	p, client := newTestProxy(t)
+	body := `{
+		"model": "raypaste/bulletlist@openai-gpt5-nano",
+		"max_tokens": 123,
+		"messages": [
+			{"role": "system", "content": "client system"},
+			{"role": "user", "content": [{"type": "text", "text": "my "}, {"type": "text", "text": "notes"}]}
+		]
+	}`
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Object != "chat.completion" || resp.Model != "raypaste/bulletlist@openai-gpt5-nano" {
+		t.Errorf("unexpected response envelope: %+v", resp)
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Hello world" {
+		t.Errorf("unexpected choices: %+v", resp.Choices)
+	}
+
+	req := client.LastRequest()
+	if req.Model != config.DefaultModels["openai-gpt5-nano"].ID {
+		t.Errorf("upstream model = %q, want resolved alias", req.Model)
+	}
+	if len(req.Messages) != 3 {
+		t.Fatalf("upstream messages = %d, want 3 (template + client messages)", len(req.Messages))
+	}
+	if !strings.Contains(req.Messages[0].Content, "text organizer") {
+		t.Error("first message should be the rendered bulletlist template")
+	}
+	if req.Messages[1].Content != "client system" || req.Messages[2].Content != "my notes" {
+		t.Errorf("client messages not forwarded intact: %+v", req.Messages[1:])
+	}
+	if req.MaxCompletionTokens != 123 || req.MaxTokens != 0 {
+		t.Errorf("max_tokens should map to max_completion_tokens for GPT-5, got %d/%d", req.MaxTokens, req.MaxCompletionTokens)
+	}
+}
+
+func TestProxyChatCompletionReasoningBudget(t *testing.T) {
+	p, client := newTestProxy(t)
+	p.env.ReasoningMaxTokens = 2000
+	body := `{"model": "raypaste/bulletlist@openai-gpt5-nano", "max_tokens": 123, "messages": [{"role": "user", "content": "notes"}]}`
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	req := client.LastRequest()
+	if req.Reasoning == nil || req.Reasoning.MaxTokens != 2000 {
+		t.Fatalf("upstream reasoning = %+v, want a 2000 token budget", req.Reasoning)
+	}
+	if req.MaxCompletionTokens != 2123 {
+		t.Errorf("max_completion_tokens = %d, want max_tokens plus the reasoning budget (2123)", req.MaxCompletionTokens)
+	}
+}
+
+func TestProxyChatCompletionPostProcess(t *testing.T) {
+	p, client := newTestProxy(t)
+	client.Reply = "Sure!\n- one  \n\n\n- two\n\nLet me know if you need changes."
+	store, err := p.env.Store.WithPrompts([]*prompts.Prompt{{
+		Name:   "tidy",
+		System: "List the notes.",
+		PostProcess: &postprocess.Rules{
+			StripPreamble:          true,
+			TrimTrailingCommentary: true,
+			CollapseWhitespace:     true,
+		},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.env.Store = store
+
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", `{"model": "raypaste/tidy", "messages": [{"role": "user", "content": "notes"}]}`)
+	var resp ChatCompletionResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v (body: %s)", err, rec.Body.String())
+	}
+	if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "- one\n\n- two" {
+		t.Errorf("unexpected choices: %+v, want every rule applied", resp.Choices)
+	}
+
+	rec = doProxyRequest(p, http.MethodPost, "/v1/chat/completions", `{"model": "raypaste/tidy", "stream": true, "messages": [{"role": "user", "content": "notes"}]}`)
+	if body := rec.Body.String(); strings.Contains(body, "Sure!") || !strings.Contains(body, "- one") {
+		t.Errorf("stream should strip the preamble, got:\n%s", body)
+	}
+}
+
+func TestProxyChatCompletionZeroTemperature(t *testing.T) {
+	p, client := newTestProxy(t)
+	body := `{"model": "raypaste/bulletlist", "temperature": 0, "messages": [{"role": "user", "content": "notes"}]}`
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	data, err := json.Marshal(client.LastRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sent map[string]any
+	if err := json.Unmarshal(data, &sent); err != nil {
+		t.Fatal(err)
+	}
+	if temperature, ok := sent["temperature"]; !ok || temperature != 0.0 {
+		t.Errorf("upstream request = %s, want temperature 0", data)
+	}
+}
+
+func TestProxyChatCompletionVars(t *testing.T) {
+	p, client := newTestProxy(t)
+	store, err := p.env.Store.WithPrompts([]*prompts.Prompt{{
+		Name:      "audience",
+		System:    "Write for {{.Vars.audience}}.",
+		Variables: []prompts.Variable{{Name: "audience", Required: true}},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.env.Store = store
+
+	body := `{"model": "raypaste/audience", "raypaste_vars": {"audience": "engineers"}, "messages": [{"role": "user", "content": "notes"}]}`
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", body)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+	if got := client.LastRequest().Messages[0].Content; got != "Write for engineers." {
+		t.Errorf("system message = %q, want the variable filled in", got)
+	}
+
+	rec = doProxyRequest(p, http.MethodPost, "/v1/chat/completions", `{"model": "raypaste/audience", "messages": [{"role": "user", "content": "notes"}]}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status without raypaste_vars = %d, want 400", rec.Code)
+	}
+}
+
+func TestProxyUnsupportedPrompts(t *testing.T) {
+	p, _ := newTestProxy(t)
+	objectSchema, err := schema.New(map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := p.env.Store.WithPrompts([]*prompts.Prompt{
+		{Name: "plan", Steps: []prompts.Step{{Prompt: "bulletlist"}, {Prompt: "metaprompt"}}},
+		{Name: "grounded", System: "Answer from the notes.", Tools: []string{"read_file"}},
+		{Name: "typed", System: "Reply in JSON.", Schema: objectSchema},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.env.Store = store
+
+	tests := []struct {
+		prompt  string
+		wantErr string
+	}{
+		{"plan", "pipeline"},
+		{"grounded", "uses tools"},
+		{"typed", "declares a schema"},
+	}
+	models := doProxyRequest(p, http.MethodGet, "/v1/models", "").Body.String()
+	for _, tt := range tests {
+		if strings.Contains(models, `"raypaste/`+tt.prompt+`"`) {
+			t.Errorf("GET /v1/models should not list %s, got %s", tt.prompt, models)
+		}
+
+		rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", `{"model": "raypaste/`+tt.prompt+`", "messages": [{"role": "user", "content": "notes"}]}`)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.wantErr) {
+			t.Errorf("%s request = %d %s, want 400 containing %q", tt.prompt, rec.Code, rec.Body.String(), tt.wantErr)
+		}
+	}
+}
+
+func TestProxyChatCompletionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"non-raypaste model", `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound},
+		{"unknown prompt", `{"model":"raypaste/nope","messages":[{"role":"user","content":"hi"}]}`, http.StatusNotFound},
+		{"empty messages", `{"model":"raypaste/metaprompt","messages":[]}`, http.StatusBadRequest},
+		{"image content", `{"model":"raypaste/metaprompt","messages":[{"role":"user","content":[{"type":"image_url"}]}]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProxy(t)
+			rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var errResp types.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Error.Message == "" {
+				t.Errorf("error should use the OpenAI error shape, got %s", rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyChatCompletionStreaming(t *testing.T) {
+	p, _ := newTestProxy(t)
+	rec := doProxyRequest(p, http.MethodPost, "/v1/chat/completions",
+		`{"model":"raypaste/metaprompt","stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`"object":"chat.completion.chunk"`,
+		`"delta":{"content":"Hello"}`,
+		`"finish_reason":"stop"`,
+		"data: [DONE]\n\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stream missing %q\nbody:\n%s", want, body)
+		}
+	}
+}
+
+func TestProxyRejectsBrowserRequests(t *testing.T) {
+	p, _ := newTestProxy(t)
+	req := newLocalRequest(http.MethodPost, "/v1/chat/completions", `{"model":"raypaste/metaprompt","messages":[{"role":"user","content":"hi"}]}`)
+	req.Header.Set("Origin", "https://attacker.example")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+
+	var resp types.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || rec.Code != http.StatusForbidden || resp.Error.Message == "" {
+		t.Errorf("request with an Origin = %d %s, want 403 with an OpenAI error", rec.Code, rec.Body.String())
+	}
+}
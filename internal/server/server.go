@@ -0,0 +1,349 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+// generationTimeout bounds a single generation request, matching the REPL.
+const generationTimeout = 120 * time.Second
+
+// Server exposes raypaste generation, prompts and models over a local HTTP API.
+type Server struct {
+	env      *generate.Env
+	client   generate.Completer
+	defaults generate.Defaults
+	addr     string
+	mux      *http.ServeMux
+}
+
+// GenerateRequest is the body accepted by POST /v1/generate.
+type GenerateRequest struct {
+	Input  string            `json:"input"`
+	Prompt string            `json:"prompt,omitempty"`
+	Model  string            `json:"model,omitempty"`
+	Length string            `json:"length,omitempty"`
+	Stream bool              `json:"stream,omitempty"`
+	Vars   map[string]string `json:"vars,omitempty"`
+}
+
+// GenerateResponse is returned by POST /v1/generate when streaming is disabled.
+// Steps lists every step of a pipeline prompt.
+type GenerateResponse struct {
+	Output     string                `json:"output"`
+	Prompt     string                `json:"prompt"`
+	Model      string                `json:"model"`
+	Length     string                `json:"length"`
+	Usage      types.TokenUsage      `json:"usage"`
+	DurationMs int64                 `json:"duration_ms"`
+	Steps      []generate.StepResult `json:"steps,omitempty"`
+	// Structured is set when Output is JSON that matched the prompt's schema.
+	Structured bool `json:"structured,omitempty"`
+}
+
+// RenderRequest is the body accepted by POST /v1/render.
+type RenderRequest struct {
+	Prompt string            `json:"prompt,omitempty"`
+	Length string            `json:"length,omitempty"`
+	Vars   map[string]string `json:"vars,omitempty"`
+}
+
+// RenderResponse is returned by POST /v1/render.
+type RenderResponse struct {
+	Prompt string `json:"prompt"`
+	Length string `json:"length"`
+	System string `json:"system"`
+}
+
+// StreamEvent is a single SSE payload sent by POST /v1/generate when streaming.
+// Content events carry Content; a "step" event carries each intermediate step of a
+// pipeline prompt; the final "done" event carries Usage and DurationMs.
+type StreamEvent struct {
+	Content    string               `json:"content,omitempty"`
+	Step       *generate.StepResult `json:"step,omitempty"`
+	Usage      *types.TokenUsage    `json:"usage,omitempty"`
+	DurationMs int64                `json:"duration_ms,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+// errorBody is the JSON shape of every error response.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// New creates a Server backed by env and client. addr is the address it listens on;
+// requests for any other host are rejected.
+func New(env *generate.Env, client generate.Completer, defaults generate.Defaults, addr string) *Server {
+	s := &Server{
+		env:      env,
+		client:   client,
+		defaults: defaults,
+		addr:     addr,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /healthz", s.handleHealth)
+	s.mux.HandleFunc("GET /v1/prompts", s.handleListPrompts)
+	s.mux.HandleFunc("GET /v1/models", s.handleListModels)
+	s.mux.HandleFunc("POST /v1/render", s.handleRender)
+	s.mux.HandleFunc("POST /v1/generate", s.handleGenerate)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if status, err := checkRequest(r, s.addr); err != nil {
+		writeError(w, status, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkRequest rejects requests a web page could have made, returning the status
+// to respond with: a Host other than addr (DNS rebinding), any Origin header (a
+// cross-site fetch), and a POST whose body isn't JSON (a form or text/plain fetch,
+// which browsers send without asking first).
+func checkRequest(r *http.Request, addr string) (int, error) {
+	if !allowedHost(r.Host, addr) {
+		return http.StatusForbidden, fmt.Errorf("host %q is not allowed; connect to %s", r.Host, addr)
+	}
+	if r.Header.Get("Origin") != "" {
+		return http.StatusForbidden, fmt.Errorf("requests from web pages are not allowed")
+	}
+	if r.Method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return http.StatusUnsupportedMediaType, fmt.Errorf("the request body must be sent as Content-Type: application/json")
+		}
+	}
+	return 0, nil
+}
+
+// allowedHost reports whether a request's Host names the address the server listens
+// on. A server bound to a loopback or wildcard address also answers to localhost
+// and the loopback IPs.
+func allowedHost(host, addr string) bool {
+	host, bound := hostname(host), hostname(addr)
+	if host == "" {
+		return false
+	}
+	if strings.EqualFold(host, bound) {
+		return true
+	}
+	if bound == "" || isLoopback(bound) || net.ParseIP(bound).IsUnspecified() {
+		return isLoopback(host)
+	}
+	return false
+}
+
+// hostname returns the host of a host[:port] string, without IPv6 brackets.
+func hostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+	return strings.Trim(hostport, "[]")
+}
+
+// isLoopback reports whether host is localhost or a loopback IP.
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handleListPrompts(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.env.ListPrompts())
+}
+
+func (s *Server) handleListModels(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.env.ListModels())
+}
+
+func (s *Server) handleRender(w http.ResponseWriter, r *http.Request) {
+	var body RenderRequest
+	if err := decodeJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	promptName, length, err := s.env.ResolvePromptAndLength(s.defaults, body.Prompt, body.Length)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	system, err := s.env.RenderSystemPrompt(promptName, length, body.Vars)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, RenderResponse{
+		Prompt: promptName,
+		Length: string(length),
+		System: system,
+	})
+}
+
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var body GenerateRequest
+	if err := decodeJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(body.Input) == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("no input provided"))
+		return
+	}
+
+	promptName, length, err := s.env.ResolvePromptAndLength(s.defaults, body.Prompt, body.Length)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	model := body.Model
+	if model == "" {
+		model = s.defaults.Model
+	}
+
+	params := generate.Params{
+		Input:      body.Input,
+		PromptName: promptName,
+		Model:      model,
+		Length:     length,
+		Stream:     body.Stream,
+		Vars:       body.Vars,
+	}
+
+	if err := s.env.Validate(params); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), generationTimeout)
+	defer cancel()
+
+	if body.Stream {
+		s.streamGenerate(ctx, w, params)
+		return
+	}
+
+	startTime := time.Now()
+	result, err := s.env.Run(ctx, s.client, params, generate.RunOptions{})
+	durationMs := time.Since(startTime).Milliseconds()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, fmt.Errorf("generation failed: %w", err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, GenerateResponse{
+		Output:     result.Output,
+		Prompt:     promptName,
+		Model:      result.Model,
+		Length:     string(length),
+		Usage:      result.Usage,
+		DurationMs: durationMs,
+		Steps:      result.Steps,
+		Structured: result.Structured,
+	})
+}
+
+// streamGenerate writes the generation as Server-Sent Events. Each token is sent
+// as a default "message" event, preceded by a "step" event for each intermediate
+// step of a pipeline; a final "done" (or "error") event closes the stream.
+func (s *Server) streamGenerate(ctx context.Context, w http.ResponseWriter, params generate.Params) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	startTime := time.Now()
+	result, err := s.env.Run(ctx, s.client, params, generate.RunOptions{
+		OnToken: func(token string) error {
+			if err := writeEvent(w, "", StreamEvent{Content: token}); err != nil {
+				return err
+			}
+			flusher.Flush()
+			return nil
+		},
+		OnStep: func(step generate.StepResult) {
+			_ = writeEvent(w, "step", StreamEvent{Step: &step})
+			flusher.Flush()
+		},
+	})
+	durationMs := time.Since(startTime).Milliseconds()
+	usage := result.Usage
+
+	if err != nil {
+		_ = writeEvent(w, "error", StreamEvent{Error: fmt.Sprintf("streaming failed: %v", err)})
+		flusher.Flush()
+		return
+	}
+
+	_ = writeEvent(w, "done", StreamEvent{Usage: &usage, DurationMs: durationMs})
+	flusher.Flush()
+}
+
+// writeEvent writes a single SSE event. An empty name sends a default "message" event.
+func writeEvent(w http.ResponseWriter, name string, event StreamEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	if name != "" {
+		if _, err := fmt.Fprintf(w, "event: %s\n", name); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
+
+// decodeJSON decodes the request body into v. An empty body leaves v unchanged.
+func decodeJSON(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil && err != io.EOF {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
@@ -0,0 +1,251 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/raypaste/raypaste-cli/internal/config"
+	"github.com/raypaste/raypaste-cli/internal/generate"
+	"github.com/raypaste/raypaste-cli/internal/generate/generatetest"
+	"github.com/raypaste/raypaste-cli/pkg/types"
+)
+
+func newTestServer(t *testing.T) (*Server, *generatetest.Completer) {
+	t.Helper()
+	client := &generatetest.Completer{Reply: "Hello world"}
+	return New(generatetest.NewEnv(t, nil), client, generate.Defaults{
+		PromptName: "metaprompt",
+		Model:      "cerebras-llama-8b",
+		Length:     types.OutputLengthMedium,
+	}, testAddr), client
+}
+
+// testAddr is the address test servers are bound to.
+const testAddr = "127.0.0.1:7766"
+
+// newLocalRequest returns a request as a local client would send it: to testAddr,
+// with a JSON body.
+func newLocalRequest(method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = testAddr
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func doRequest(t *testing.T, s *Server, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := newLocalRequest(method, path, body)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestListPrompts(t *testing.T) {
+	s, _ := newTestServer(t)
+	rec := doRequest(t, s, http.MethodGet, "/v1/prompts", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var infos []generate.PromptInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	found := false
+	for _, info := range infos {
+		if info.Name == "bulletlist" {
+			found = true
+			if !info.BuiltIn {
+				t.Error("bulletlist should be reported as built-in")
+			}
+			if len(info.Lengths) != 2 {
+				t.Errorf("bulletlist lengths = %v, want [short medium]", info.Lengths)
+			}
+		}
+	}
+	if !found {
+		t.Error("GET /v1/prompts should include bulletlist")
+	}
+}
+
+func TestListModels(t *testing.T) {
+	s, _ := newTestServer(t)
+	rec := doRequest(t, s, http.MethodGet, "/v1/models", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var infos []generate.ModelInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if want := len(config.DefaultModels) + len(generatetest.Models); len(infos) != want {
+		t.Errorf("got %d models, want %d", len(infos), want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{"defaults with empty body", "", http.StatusOK},
+		{"explicit prompt and length", `{"prompt":"bulletlist","length":"short"}`, http.StatusOK},
+		{"unsupported length", `{"prompt":"bulletlist","length":"long"}`, http.StatusBadRequest},
+		{"unknown prompt", `{"prompt":"nonexistent"}`, http.StatusBadRequest},
+		{"invalid length", `{"length":"huge"}`, http.StatusBadRequest},
+		{"unknown field", `{"promt":"metaprompt"}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestServer(t)
+			rec := doRequest(t, s, http.MethodPost, "/v1/render", tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var resp RenderResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if resp.System == "" || strings.Contains(resp.System, "{{.LengthDirective}}") {
+				t.Errorf("render returned unrendered system prompt: %q", resp.System)
+			}
+		})
+	}
+}
+
+func TestGenerateBlocking(t *testing.T) {
+	s, client := newTestServer(t)
+	rec := doRequest(t, s, http.MethodPost, "/v1/generate", `{"input":"write a haiku","length":"short"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (body: %s)", rec.Code, rec.Body.String())
+	}
+
+	var resp GenerateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Output != "Hello world" {
+		t.Errorf("Output = %q, want %q", resp.Output, "Hello world")
+	}
+	if resp.Prompt != "metaprompt" || resp.Model != "cerebras-llama-8b" || resp.Length != "short" {
+		t.Errorf("unexpected defaults in response: %+v", resp)
+	}
+	if resp.Usage.TotalTokens != 15 {
+		t.Errorf("Usage.TotalTokens = %d, want 15", resp.Usage.TotalTokens)
+	}
+	if client.LastRequest().Model != config.DefaultModels["cerebras-llama-8b"].ID {
+		t.Errorf("request model = %q, want resolved ID", client.LastRequest().Model)
+	}
+	if len(client.LastRequest().Messages) != 2 || client.LastRequest().Messages[1].Content != "write a haiku" {
+		t.Errorf("unexpected request messages: %+v", client.LastRequest().Messages)
+	}
+}
+
+func TestGenerateRequiresInput(t *testing.T) {
+	s, _ := newTestServer(t)
+	rec := doRequest(t, s, http.MethodPost, "/v1/generate", `{"input":"   "}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", rec.Code)
+	}
+}
+
+func TestGenerateStreaming(t *testing.T) {
+	s, client := newTestServer(t)
+	rec := doRequest(t, s, http.MethodPost, "/v1/generate", `{"input":"write a haiku","stream":true}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	if !client.LastRequest().Stream {
+		t.Error("streaming request should set Stream = true")
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		`data: {"content":"Hello"}`,
+		`data: {"content":" world"}`,
+		"event: done\n",
+		`"total_tokens":15`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("stream body missing %q\nbody:\n%s", want, body)
+		}
+	}
+}
+
+func TestCheckRequest(t *testing.T) {
+	s, client := newTestServer(t)
+	body := `{"input":"notes"}`
+
+	tests := []struct {
+		name       string
+		host       string
+		header     map[string]string
+		wantStatus int
+	}{
+		{"bound address", testAddr, nil, http.StatusOK},
+		{"localhost", "localhost:7766", nil, http.StatusOK},
+		{"JSON with charset", testAddr, map[string]string{"Content-Type": "application/json; charset=utf-8"}, http.StatusOK},
+		{"rebound host", "attacker.example:7766", nil, http.StatusForbidden},
+		{"other address", "192.168.1.10:7766", nil, http.StatusForbidden},
+		{"origin", testAddr, map[string]string{"Origin": "https://attacker.example"}, http.StatusForbidden},
+		{"text/plain body", testAddr, map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		{"form body", testAddr, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType},
+		{"no content type", testAddr, map[string]string{"Content-Type": ""}, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.Requests = nil
+			req := newLocalRequest(http.MethodPost, "/v1/generate", body)
+			req.Host = tt.host
+			for key, value := range tt.header {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body: %s)", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && len(client.Requests) > 0 {
+				t.Error("a rejected request should not reach the model")
+			}
+		})
+	}
+}
+
+func TestAllowedHost(t *testing.T) {
+	tests := []struct {
+		host string
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:7766", "127.0.0.1:7766", true},
+		{"[::1]:7766", "127.0.0.1:7766", true},
+		{"localhost", ":7766", true},
+		{"127.0.0.1:7766", "0.0.0.0:7766", true},
+		{"192.168.1.10:7766", "192.168.1.10:7766", true},
+		{"localhost:7766", "192.168.1.10:7766", false},
+		{"evil.example", "127.0.0.1:7766", false},
+		{"", "127.0.0.1:7766", false},
+	}
+	for _, tt := range tests {
+		if got := allowedHost(tt.host, tt.addr); got != tt.want {
+			t.Errorf("allowedHost(%q, %q) = %v, want %v", tt.host, tt.addr, got, tt.want)
+		}
+	}
+}
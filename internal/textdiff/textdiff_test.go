@@ -0,0 +1,29 @@
+/*
+Copyright © 2026 Raypaste
+*/
+package textdiff
+
+import "testing"
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		want   string
+	}{
+		{"equal", "a\nb", "a\nb", ""},
+		{"changed line", "a\nb\nc", "a\nx\nc", " a\n-b\n+x\n c\n"},
+		{"added line", "a\nc", "a\nb\nc", " a\n+b\n c\n"},
+		{"removed line", "a\nb\nc", "a\nc", " a\n-b\n c\n"},
+		{"from empty", "", "a", "-\n+a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lines(tt.before, tt.after); got != tt.want {
+				t.Errorf("Lines() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
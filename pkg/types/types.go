@@ -3,6 +3,8 @@ Copyright © 2026 Raypaste
 */
 package types
 
+import "encoding/json"
+
 // OutputLength represents the desired response length from the LLM
 type OutputLength string
 
@@ -16,6 +18,88 @@ const (
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Parts are images and files sent with the message. When set, the message's
+	// content is sent as an array of parts: these, then Content as a text part.
+	Parts []ContentPart `json:"-"`
+	// ToolCalls are the tools an assistant message asks to run.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID identifies the call a "tool" role message answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	// Reasoning is the thinking a reasoning model returned with its answer. It is
+	// never part of Content.
+	Reasoning string `json:"reasoning,omitempty"`
+}
+
+// MarshalJSON encodes Content as a string, or as an array of content parts when the
+// message has Parts.
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	if len(m.Parts) == 0 {
+		return json.Marshal(message(m))
+	}
+
+	parts := append([]ContentPart(nil), m.Parts...)
+	if m.Content != "" {
+		parts = append(parts, ContentPart{Type: "text", Text: m.Content})
+	}
+	return json.Marshal(struct {
+		message
+		Content []ContentPart `json:"content"`
+	}{message(m), parts})
+}
+
+// ContentPart is one part of a message's array-valued content.
+type ContentPart struct {
+	Type     string    `json:"type"` // "text", "image_url" or "file"
+	Text     string    `json:"text,omitempty"`
+	ImageURL *ImageURL `json:"image_url,omitempty"`
+	File     *File     `json:"file,omitempty"`
+}
+
+// ImageURL holds an image, usually as a base64 data URL.
+type ImageURL struct {
+	URL string `json:"url"`
+}
+
+// File holds a document such as a PDF as a base64 data URL.
+type File struct {
+	Filename string `json:"filename"`
+	FileData string `json:"file_data"`
+}
+
+// Tool describes a function the model may call.
+type Tool struct {
+	Type     string       `json:"type"` // "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is the name, description and JSON Schema parameters of a tool.
+type ToolFunction struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters,omitempty"`
+}
+
+// ToolCall is a model's request to run a tool.
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"` // "function"
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the tool to run and holds its JSON-encoded arguments.
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCallDelta is a fragment of a tool call in a streaming response. Fragments
+// with the same Index belong to one call; Arguments arrive in pieces.
+type ToolCallDelta struct {
+	Index    int              `json:"index"`
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
 }
 
 // CompletionRequest represents a request to the OpenRouter API
@@ -25,8 +109,68 @@ type CompletionRequest struct {
 	MaxTokens           int       `json:"max_tokens,omitempty"`
 	MaxCompletionTokens int       `json:"max_completion_tokens,omitempty"`
 	ReasoningEffort     string    `json:"reasoning_effort,omitempty"`
-	Temperature         float64   `json:"temperature,omitempty"`
-	Stream              bool      `json:"stream,omitempty"`
+	// Reasoning caps the model's reasoning tokens; it is sent instead of
+	// ReasoningEffort when a budget is set
+	Reasoning *Reasoning `json:"reasoning,omitempty"`
+	// Temperature is always sent, as 0 is a valid setting rather than "unset"
+	Temperature float64  `json:"temperature"`
+	Stop        []string `json:"stop,omitempty"`
+	Stream      bool     `json:"stream,omitempty"`
+	// StreamOptions asks a streaming response to report its token usage
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	// ResponseFormat constrains the reply to JSON matching a schema, on models that
+	// support structured outputs
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// Tools are the functions the model may call
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice is "none" to stop the model calling tools; empty lets it choose
+	ToolChoice string `json:"tool_choice,omitempty"`
+}
+
+// StreamOptions is the OpenAI-style stream_options request field.
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Reasoning is OpenRouter's reasoning request field.
+type Reasoning struct {
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// ResponseFormat is the OpenAI-style response_format request field.
+type ResponseFormat struct {
+	Type       string          `json:"type"` // "json_schema"
+	JSONSchema *JSONSchemaSpec `json:"json_schema,omitempty"`
+}
+
+// JSONSchemaSpec names a JSON Schema sent in a response format.
+type JSONSchemaSpec struct {
+	Name   string         `json:"name"`
+	Schema map[string]any `json:"schema"`
+}
+
+// RequestOptions holds optional request parameters, usually declared by a prompt.
+// Zero values leave the defaults chosen by the router in place.
+type RequestOptions struct {
+	MaxTokens       int      // Replaces the output length's max_tokens when > 0
+	Temperature     *float64 // Replaces the configured temperature when set
+	Stop            []string // Stop sequences
+	ReasoningEffort string   // minimal|low|medium|high; replaces the model's default effort
+	// ReasoningMaxTokens caps the model's reasoning tokens when > 0. Together with
+	// ReasoningEffort it replaces the model's reasoning settings.
+	ReasoningMaxTokens int
+	Examples           []Example
+	// Schema is a JSON Schema the reply must match. It is sent as the response format
+	// when the model supports structured outputs, or described in the system prompt.
+	Schema map[string]any
+	// Attachments are images and files sent with the user's input.
+	Attachments []ContentPart
+}
+
+// Example is a few-shot input/output pair sent as a user/assistant exchange before the real input.
+type Example struct {
+	Input  string `yaml:"input" json:"input"`
+	Output string `yaml:"output" json:"output"`
 }
 
 // TokenUsage represents token usage statistics from the API
@@ -53,8 +197,10 @@ type CompletionResponse struct {
 
 // Delta represents a delta update in a streaming response
 type Delta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string          `json:"role,omitempty"`
+	Content   string          `json:"content,omitempty"`
+	Reasoning string          `json:"reasoning,omitempty"`
+	ToolCalls []ToolCallDelta `json:"tool_calls,omitempty"`
 }
 
 // StreamChoice represents a choice in a streaming response chunk
@@ -95,6 +241,6 @@ type ErrorResponse struct {
 
 // LengthParams holds parameters for a specific output length
 type LengthParams struct {
-	MaxTokens int
-	Directive string
+	MaxTokens int    `yaml:"max_tokens" mapstructure:"max_tokens"`
+	Directive string `yaml:"directive" mapstructure:"directive"`
 }